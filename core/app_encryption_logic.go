@@ -0,0 +1,120 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"time"
+
+	"github.com/rokwire/logging-library-go/v2/logs"
+)
+
+// the number of survey responses loaded at once by the encryption job
+const encryptionBatchSize = 100
+
+// encryptionLogic encrypts the responses to the sensitive surveys which are stored in plain text,
+// and encrypts the data keys of the responses with the active key after the keys are rotated
+type encryptionLogic struct {
+	logger logs.Logger
+
+	app *Application
+
+	//encryption timer
+	encryptionTimer *time.Timer
+	timerDone       chan bool
+}
+
+func (e *encryptionLogic) start() {
+	if !e.app.encryption.IsEnabled() {
+		e.logger.Info("Encryption is not enabled, so the responses to the sensitive surveys are not encrypted")
+		return
+	}
+
+	go e.process()
+}
+
+func (e *encryptionLogic) process() {
+	e.logger.Info("Encryption process")
+
+	//process work
+	e.processEncryption()
+
+	//generate new processing after 24 hours
+	duration := time.Hour * 24
+	e.logger.Infof("Encryption process -> next call after %s", duration)
+	e.encryptionTimer = time.NewTimer(duration)
+	select {
+	case <-e.encryptionTimer.C:
+		e.logger.Info("Encryption process -> timer expired")
+		e.encryptionTimer = nil
+
+		e.process()
+	case <-e.timerDone:
+		// timer aborted
+		e.logger.Info("Encryption process -> timer aborted")
+		e.encryptionTimer = nil
+	}
+}
+
+func (e *encryptionLogic) processEncryption() {
+	activeKeyID := e.app.encryption.ActiveKeyID()
+	encrypted := 0
+	var afterID *string
+	for {
+		responses, err := e.app.storage.GetSurveyResponsesToEncrypt(activeKeyID, afterID, encryptionBatchSize)
+		if err != nil {
+			e.logger.Errorf("error loading the survey responses to encrypt - %s", err)
+			return
+		}
+		if len(responses) == 0 {
+			break
+		}
+
+		for _, response := range responses {
+			updated, err := e.encryptSurveyResponse(response)
+			if err != nil {
+				e.logger.Errorf("error encrypting the survey response %s - %s", response.ID, err)
+				continue
+			}
+			if updated {
+				encrypted++
+			}
+		}
+		afterID = &responses[len(responses)-1].ID
+	}
+	e.logger.Infof("encrypted %d survey responses with the key %s", encrypted, activeKeyID)
+}
+
+// encryptSurveyResponse encrypts a response stored in plain text, or encrypts its data key with the active key.
+// The response is not updated if it is changed in the meantime
+func (e *encryptionLogic) encryptSurveyResponse(response model.SurveyResponse) (bool, error) {
+	var err error
+	updated := response
+	if response.Encrypted != nil {
+		updated.Encrypted, err = e.app.encryption.RotateKey(*response.Encrypted)
+	} else {
+		updated, err = e.app.encryptSurveyResponse(response)
+	}
+	if err != nil {
+		return false, err
+	}
+	return e.app.storage.UpdateSurveyResponseEncryption(response, updated)
+}
+
+// newEncryptionLogic creates new encryptionLogic
+func newEncryptionLogic(app *Application, logger logs.Logger) *encryptionLogic {
+	timerDone := make(chan bool)
+	return &encryptionLogic{app: app, timerDone: timerDone, logger: logger}
+}
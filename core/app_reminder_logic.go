@@ -0,0 +1,85 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"time"
+
+	"github.com/rokwire/logging-library-go/v2/logs"
+)
+
+// how often the reminders of the scheduled surveys are sent
+const surveyReminderInterval = 15 * time.Minute
+
+// surveyReminderLogic reminds the users who did not respond in the current period of the scheduled surveys
+type surveyReminderLogic struct {
+	logger logs.Logger
+
+	app *Application
+
+	//reminder timer
+	reminderTimer *time.Timer
+	timerDone     chan bool
+}
+
+func (r *surveyReminderLogic) start() {
+	go r.process()
+}
+
+func (r *surveyReminderLogic) process() {
+	r.logger.Info("Survey reminder process")
+
+	//process work
+	r.processReminders()
+
+	//generate new processing after the interval
+	r.logger.Infof("Survey reminder process -> next call after %s", surveyReminderInterval)
+	r.reminderTimer = time.NewTimer(surveyReminderInterval)
+	select {
+	case <-r.reminderTimer.C:
+		r.reminderTimer = nil
+
+		r.process()
+	case <-r.timerDone:
+		// timer aborted
+		r.logger.Info("Survey reminder process -> timer aborted")
+		r.reminderTimer = nil
+	}
+}
+
+func (r *surveyReminderLogic) processReminders() {
+	now := time.Now().UTC()
+	surveys, err := r.app.storage.GetScheduledSurveys(now)
+	if err != nil {
+		r.logger.Errorf("error loading the scheduled surveys - %s", err)
+		return
+	}
+
+	for _, survey := range surveys {
+		if !survey.IsOpen(now) {
+			continue
+		}
+		err = r.app.remindSurvey(survey, now)
+		if err != nil {
+			r.logger.Errorf("error reminding the survey %s - %s", survey.ID, err)
+		}
+	}
+}
+
+// newSurveyReminderLogic creates new surveyReminderLogic
+func newSurveyReminderLogic(app *Application, logger logs.Logger) *surveyReminderLogic {
+	timerDone := make(chan bool)
+	return &surveyReminderLogic{app: app, timerDone: timerDone, logger: logger}
+}
@@ -15,8 +15,16 @@
 package core
 
 import (
+	"crypto/rand"
+	"log"
+	"polls/core/model"
+	"strconv"
+	"sync"
+	"time"
+
 	cacheadapter "polls/driven/cache"
 	corebb "polls/driven/core"
+	"polls/driven/encryption"
 
 	"github.com/rokwire/core-auth-library-go/v3/tokenauth"
 
@@ -26,6 +34,9 @@ import (
 	"polls/driven/notifications"
 )
 
+// the time after which the survey responses which are not completed are removed
+const defaultSurveyProgressExpirationHours = 7 * 24
+
 // Application represents the core application code based on hexagonal architecture
 type Application struct {
 	version string
@@ -38,25 +49,58 @@ type Application struct {
 	cache         *cacheadapter.CacheAdapter
 	notifications *notifications.Adapter
 	groups        *groups.Adapter
+	encryption    *encryption.Adapter
 	sseServer     *SSEServer
 	tokenAuth     *tokenauth.TokenAuth
 
 	serviceID       string
 	corebb          *corebb.Adapter
 	deleteDataLogic deleteDataLogic
+	encryptionLogic *encryptionLogic
+	reminderLogic   *surveyReminderLogic
+
+	surveyProgressExpiration      time.Duration
+	sensitiveSurveyMinRespondents int
+	exportPseudonymKey            []byte
+
+	surveyStatsLock    sync.Mutex
+	surveyStatsPending map[string]bool
 }
 
 // Start starts the core part of the application
 func (app *Application) Start() {
 	app.storage.SetListener(app)
 	app.deleteDataLogic.start()
+	app.encryptionLogic.start()
+	app.reminderLogic.start()
 }
 
 // NewApplication creates new Application
 func NewApplication(version string, build string, storage Storage, cacheAdapter *cacheadapter.CacheAdapter,
-	notificationsAdapter *notifications.Adapter, groupsAdapter *groups.Adapter, serviceID string, coreBB *corebb.Adapter, logger *logs.Logger) *Application {
+	notificationsAdapter *notifications.Adapter, groupsAdapter *groups.Adapter, encryptionAdapter *encryption.Adapter, serviceID string, coreBB *corebb.Adapter, config *model.Config,
+	logger *logs.Logger) *Application {
 	deleteDataLogic := deleteDataLogic{logger: *logger, core: coreBB, serviceID: serviceID, storage: storage}
 
+	surveyProgressExpirationHours, err := strconv.Atoi(config.SurveyProgressExpirationHours)
+	if err != nil || surveyProgressExpirationHours <= 0 {
+		log.Printf("Set default survey progress expiration - %d hours", defaultSurveyProgressExpirationHours)
+		surveyProgressExpirationHours = defaultSurveyProgressExpirationHours
+	}
+	sensitiveSurveyMinRespondents, err := strconv.Atoi(config.SensitiveSurveyMinRespondents)
+	if err != nil || sensitiveSurveyMinRespondents <= 0 {
+		log.Printf("Set default sensitive survey min respondents - %d", defaultSensitiveSurveyMinRespondents)
+		sensitiveSurveyMinRespondents = defaultSensitiveSurveyMinRespondents
+	}
+	exportPseudonymKey := []byte(config.ExportPseudonymKey)
+	if len(exportPseudonymKey) == 0 {
+		log.Printf("Set random export pseudonym key - the pseudonyms in the exports change when the service restarts")
+		exportPseudonymKey = make([]byte, 32)
+		_, err = rand.Read(exportPseudonymKey)
+		if err != nil {
+			log.Fatalf("Error generating export pseudonym key: %s", err)
+		}
+	}
+
 	application := Application{
 		version:         version,
 		build:           build,
@@ -64,12 +108,22 @@ func NewApplication(version string, build string, storage Storage, cacheAdapter
 		cache:           cacheAdapter,
 		notifications:   notificationsAdapter,
 		groups:          groupsAdapter,
+		encryption:      encryptionAdapter,
 		sseServer:       NewSSEServer(),
 		serviceID:       serviceID,
 		corebb:          coreBB,
 		deleteDataLogic: deleteDataLogic,
+
+		surveyProgressExpiration:      time.Duration(surveyProgressExpirationHours) * time.Hour,
+		sensitiveSurveyMinRespondents: sensitiveSurveyMinRespondents,
+		exportPseudonymKey:            exportPseudonymKey,
+
+		surveyStatsPending: map[string]bool{},
 	}
 
+	application.encryptionLogic = newEncryptionLogic(&application, *logger)
+	application.reminderLogic = newSurveyReminderLogic(&application, *logger)
+
 	// add the drivers ports/interfaces
 	application.Services = &servicesImpl{app: &application}
 
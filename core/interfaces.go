@@ -15,6 +15,7 @@
 package core
 
 import (
+	"io"
 	"polls/core/model"
 	"polls/driven/groups"
 	"polls/driven/storage"
@@ -26,8 +27,10 @@ type Services interface {
 	GetVersion() string
 
 	// CRUD Polls
-	GetPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool) ([]model.Poll, error)
-	GetPoll(user *model.User, id string) (*model.Poll, error)
+	GetPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool, languages []string) ([]model.Poll, error)
+	GetPoll(user *model.User, id string, languages []string) (*model.Poll, error)
+	ExportPoll(user *model.User, id string, export model.PollsExport, w io.Writer) error
+	ExportPolls(user *model.User, filter model.PollsFilter, export model.PollsExport, w io.Writer) error
 	CreatePoll(user *model.User, poll model.Poll) (*model.Poll, error)
 	UpdatePoll(user *model.User, poll model.Poll) (*model.Poll, error)
 	DeletePoll(user *model.User, id string) error
@@ -39,19 +42,57 @@ type Services interface {
 	SubscribeToPoll(user *model.User, pollID string, resultChan chan map[string]interface{}) error
 
 	//CRUD Surveys
-	GetSurvey(user *model.User, id string) (*model.Survey, error)
+	GetSurvey(user *model.User, id string, admin bool, languages []string) (*model.Survey, error)
+	GetSurveys(user *model.User, filter model.SurveysFilter, admin bool) ([]model.Survey, error)
+	GetSurveysAssignedToUser(user *model.User, limit *int, offset *int) ([]model.Survey, error)
 	CreateSurvey(user *model.User, survey model.Survey, admin bool) (*model.Survey, error)
 	UpdateSurvey(user *model.User, survey model.Survey, id string, admin bool) error
 	DeleteSurvey(user *model.User, id string, admin bool) error
+	LintSurvey(survey model.Survey) model.SurveyLint
+	GetSurveyVersions(user *model.User, surveyID string) ([]model.SurveyVersion, error)
+	GetSurveyVersionsDiff(user *model.User, surveyID string, fromVersionID string, toVersionID string) (*model.SurveyVersionDiff, error)
+	RollbackSurvey(user *model.User, surveyID string, versionID string) (*model.Survey, error)
+	ExportSurveyBundle(user *model.User, id string, admin bool) (*model.SurveyBundle, error)
+	ImportSurveyBundle(user *model.User, bundle model.SurveyBundle, admin bool) (*model.Survey, error)
+	ImportSurveyJS(user *model.User, data []byte, admin bool) (*model.Survey, error)
+	CloneSurvey(user *model.User, id string, clone model.SurveyClone) (*model.Survey, error)
+	GetSurveyTranslations(user *model.User, id string, languages []string) (*model.SurveyTranslations, error)
+
+	//CRUD Survey Templates
+	GetSurveyTemplates(user *model.User, types []string) ([]model.SurveyTemplate, error)
+	GetSurveyTemplate(user *model.User, id string) (*model.SurveyTemplate, error)
+	CreateSurveyTemplate(user *model.User, template model.SurveyTemplate) (*model.SurveyTemplate, error)
+	UpdateSurveyTemplate(user *model.User, id string, template model.SurveyTemplate) error
+	DeleteSurveyTemplate(user *model.User, id string) error
+	CreateSurveyFromTemplate(user *model.User, id string) (*model.Survey, error)
+
+	//CRUD Survey Questions
+	GetSurveyQuestions(user *model.User) ([]model.SurveyQuestion, error)
+	GetSurveyQuestion(user *model.User, id string) (*model.SurveyQuestion, error)
+	CreateSurveyQuestion(user *model.User, question model.SurveyQuestion) (*model.SurveyQuestion, error)
+	UpdateSurveyQuestion(user *model.User, id string, question model.SurveyQuestion) error
+	DeleteSurveyQuestion(user *model.User, id string) error
+
+	SubscribeToSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{}) error
+	UnsubscribeFromSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{})
 
 	//CRUD Survey Response
+	ExportSurveyResponses(user *model.User, surveyID string, export model.SurveyResponsesExport, admin bool, w io.Writer) error
 	GetSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error)
 	GetSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time, limit *int, offset *int) ([]model.SurveyResponse, error)
 	CreateSurveyResponse(user *model.User, survey model.Survey) (*model.SurveyResponse, error)
 	UpdateSurveyResponse(user *model.User, id string, survey model.Survey) error
+	GetSurveyResults(user *model.User, surveyID string, filter model.SurveyResultsFilter, admin bool) (*model.SurveyResults, error)
+	GetSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error)
+	SaveSurveyResponseProgress(user *model.User, surveyID string, progress model.SurveyResponseProgress) (*model.SurveyResponse, error)
+	FinalizeSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error)
 	DeleteSurveyResponse(user *model.User, id string) error
 	DeleteSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time) error
 
+	//Survey Reminders
+	GetSurveyReminderSettings(user *model.User) (*model.SurveyReminderSettings, error)
+	UpdateSurveyReminderSettings(user *model.User, settings model.SurveyReminderSettings) (*model.SurveyReminderSettings, error)
+
 	//CRUD Survey Alerts
 	GetAlertContacts(user *model.User) ([]model.AlertContact, error)
 	GetAlertContact(user *model.User, id string) (*model.AlertContact, error)
@@ -61,6 +102,8 @@ type Services interface {
 	CreateSurveyAlert(user *model.User, surveyAlert model.SurveyAlert) error
 
 	GetUserData(user *model.User) (*model.UserDataResponse, error)
+
+	GetHealth() model.Health
 }
 
 type servicesImpl struct {
@@ -71,12 +114,20 @@ func (s *servicesImpl) GetVersion() string {
 	return s.app.getVersion()
 }
 
-func (s *servicesImpl) GetPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool) ([]model.Poll, error) {
-	return s.app.getPolls(user, filter, filterByToMembers)
+func (s *servicesImpl) GetPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool, languages []string) ([]model.Poll, error) {
+	return s.app.getPolls(user, filter, filterByToMembers, languages)
+}
+
+func (s *servicesImpl) GetPoll(user *model.User, id string, languages []string) (*model.Poll, error) {
+	return s.app.getPoll(user, id, languages)
 }
 
-func (s *servicesImpl) GetPoll(user *model.User, id string) (*model.Poll, error) {
-	return s.app.getPoll(user, id)
+func (s *servicesImpl) ExportPoll(user *model.User, id string, export model.PollsExport, w io.Writer) error {
+	return s.app.exportPoll(user, id, export, w)
+}
+
+func (s *servicesImpl) ExportPolls(user *model.User, filter model.PollsFilter, export model.PollsExport, w io.Writer) error {
+	return s.app.exportPolls(user, filter, export, w)
 }
 
 func (s *servicesImpl) CreatePoll(user *model.User, poll model.Poll) (*model.Poll, error) {
@@ -107,8 +158,16 @@ func (s *servicesImpl) SubscribeToPoll(user *model.User, pollID string, resultCh
 	return s.app.subscribeToPoll(user, pollID, resultChan)
 }
 
-func (s *servicesImpl) GetSurvey(user *model.User, id string) (*model.Survey, error) {
-	return s.app.getSurvey(user, id)
+func (s *servicesImpl) GetSurvey(user *model.User, id string, admin bool, languages []string) (*model.Survey, error) {
+	return s.app.getSurvey(user, id, admin, languages)
+}
+
+func (s *servicesImpl) GetSurveys(user *model.User, filter model.SurveysFilter, admin bool) ([]model.Survey, error) {
+	return s.app.getSurveys(user, filter, admin)
+}
+
+func (s *servicesImpl) GetSurveysAssignedToUser(user *model.User, limit *int, offset *int) ([]model.Survey, error) {
+	return s.app.getSurveysAssignedToUser(user, limit, offset)
 }
 
 func (s *servicesImpl) CreateSurvey(user *model.User, survey model.Survey, admin bool) (*model.Survey, error) {
@@ -123,10 +182,110 @@ func (s *servicesImpl) DeleteSurvey(user *model.User, id string, admin bool) err
 	return s.app.deleteSurvey(user, id, admin)
 }
 
+func (s *servicesImpl) LintSurvey(survey model.Survey) model.SurveyLint {
+	return s.app.lintSurvey(survey)
+}
+
+func (s *servicesImpl) GetSurveyVersions(user *model.User, surveyID string) ([]model.SurveyVersion, error) {
+	return s.app.getSurveyVersions(user, surveyID)
+}
+
+func (s *servicesImpl) GetSurveyVersionsDiff(user *model.User, surveyID string, fromVersionID string, toVersionID string) (*model.SurveyVersionDiff, error) {
+	return s.app.getSurveyVersionsDiff(user, surveyID, fromVersionID, toVersionID)
+}
+
+func (s *servicesImpl) RollbackSurvey(user *model.User, surveyID string, versionID string) (*model.Survey, error) {
+	return s.app.rollbackSurvey(user, surveyID, versionID)
+}
+
+func (s *servicesImpl) ExportSurveyBundle(user *model.User, id string, admin bool) (*model.SurveyBundle, error) {
+	return s.app.exportSurveyBundle(user, id, admin)
+}
+
+func (s *servicesImpl) ImportSurveyBundle(user *model.User, bundle model.SurveyBundle, admin bool) (*model.Survey, error) {
+	return s.app.importSurveyBundle(user, bundle, admin)
+}
+
+func (s *servicesImpl) ImportSurveyJS(user *model.User, data []byte, admin bool) (*model.Survey, error) {
+	return s.app.importSurveyJS(user, data, admin)
+}
+
+func (s *servicesImpl) CloneSurvey(user *model.User, id string, clone model.SurveyClone) (*model.Survey, error) {
+	return s.app.cloneSurvey(user, id, clone)
+}
+
+func (s *servicesImpl) GetSurveyTranslations(user *model.User, id string, languages []string) (*model.SurveyTranslations, error) {
+	return s.app.getSurveyTranslations(user, id, languages)
+}
+
+func (s *servicesImpl) GetSurveyTemplates(user *model.User, types []string) ([]model.SurveyTemplate, error) {
+	return s.app.getSurveyTemplates(user, types)
+}
+
+func (s *servicesImpl) GetSurveyTemplate(user *model.User, id string) (*model.SurveyTemplate, error) {
+	return s.app.getSurveyTemplate(user, id)
+}
+
+func (s *servicesImpl) CreateSurveyTemplate(user *model.User, template model.SurveyTemplate) (*model.SurveyTemplate, error) {
+	return s.app.createSurveyTemplate(user, template)
+}
+
+func (s *servicesImpl) UpdateSurveyTemplate(user *model.User, id string, template model.SurveyTemplate) error {
+	return s.app.updateSurveyTemplate(user, id, template)
+}
+
+func (s *servicesImpl) DeleteSurveyTemplate(user *model.User, id string) error {
+	return s.app.deleteSurveyTemplate(user, id)
+}
+
+func (s *servicesImpl) CreateSurveyFromTemplate(user *model.User, id string) (*model.Survey, error) {
+	return s.app.createSurveyFromTemplate(user, id)
+}
+
+func (s *servicesImpl) GetSurveyQuestions(user *model.User) ([]model.SurveyQuestion, error) {
+	return s.app.getSurveyQuestions(user)
+}
+
+func (s *servicesImpl) GetSurveyQuestion(user *model.User, id string) (*model.SurveyQuestion, error) {
+	return s.app.getSurveyQuestion(user, id)
+}
+
+func (s *servicesImpl) CreateSurveyQuestion(user *model.User, question model.SurveyQuestion) (*model.SurveyQuestion, error) {
+	return s.app.createSurveyQuestion(user, question)
+}
+
+func (s *servicesImpl) UpdateSurveyQuestion(user *model.User, id string, question model.SurveyQuestion) error {
+	return s.app.updateSurveyQuestion(user, id, question)
+}
+
+func (s *servicesImpl) DeleteSurveyQuestion(user *model.User, id string) error {
+	return s.app.deleteSurveyQuestion(user, id)
+}
+
+func (s *servicesImpl) SubscribeToSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{}) error {
+	return s.app.subscribeToSurveyStats(user, surveyID, resultChan)
+}
+
+func (s *servicesImpl) UnsubscribeFromSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{}) {
+	s.app.unsubscribeFromSurveyStats(user, surveyID, resultChan)
+}
+
 func (s *servicesImpl) DeleteSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time) error {
 	return s.app.deleteSurveyResponses(user, surveyIDs, surveyTypes, startDate, endDate)
 }
 
+func (s *servicesImpl) GetSurveyReminderSettings(user *model.User) (*model.SurveyReminderSettings, error) {
+	return s.app.getSurveyReminderSettings(user)
+}
+
+func (s *servicesImpl) UpdateSurveyReminderSettings(user *model.User, settings model.SurveyReminderSettings) (*model.SurveyReminderSettings, error) {
+	return s.app.updateSurveyReminderSettings(user, settings)
+}
+
+func (s *servicesImpl) ExportSurveyResponses(user *model.User, surveyID string, export model.SurveyResponsesExport, admin bool, w io.Writer) error {
+	return s.app.exportSurveyResponses(user, surveyID, export, admin, w)
+}
+
 func (s *servicesImpl) GetSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error) {
 	return s.app.getSurveyResponse(user, id)
 }
@@ -143,6 +302,22 @@ func (s *servicesImpl) UpdateSurveyResponse(user *model.User, id string, survey
 	return s.app.updateSurveyResponse(user, id, survey)
 }
 
+func (s *servicesImpl) GetSurveyResults(user *model.User, surveyID string, filter model.SurveyResultsFilter, admin bool) (*model.SurveyResults, error) {
+	return s.app.getSurveyResults(user, surveyID, filter, admin)
+}
+
+func (s *servicesImpl) GetSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error) {
+	return s.app.getSurveyResponseProgress(user, surveyID)
+}
+
+func (s *servicesImpl) SaveSurveyResponseProgress(user *model.User, surveyID string, progress model.SurveyResponseProgress) (*model.SurveyResponse, error) {
+	return s.app.saveSurveyResponseProgress(user, surveyID, progress)
+}
+
+func (s *servicesImpl) FinalizeSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error) {
+	return s.app.finalizeSurveyResponseProgress(user, surveyID)
+}
+
 func (s *servicesImpl) DeleteSurveyResponse(user *model.User, id string) error {
 	return s.app.deleteSurveyResponse(user, id)
 }
@@ -175,6 +350,10 @@ func (s *servicesImpl) GetUserData(user *model.User) (*model.UserDataResponse, e
 	return s.app.getUserData(user)
 }
 
+func (s *servicesImpl) GetHealth() model.Health {
+	return s.app.getHealth()
+}
+
 // Storage is used by core to storage data - DB storage adapter, file storage adapter etc
 type Storage interface {
 	GetPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool, membership *groups.GroupMembership) ([]model.Poll, error)
@@ -189,22 +368,68 @@ type Storage interface {
 	DeletePollsWithIDs(orgID string, accountsIDs []string) error
 
 	SetListener(listener storage.CollectionListener)
+	GetChangeStreamsStatus() []model.ChangeStreamStatus
 
 	GetSurvey(user *model.User, id string) (*model.Survey, error)
 	GetSurveysByUserID(user *model.User) ([]model.Survey, error)
+	GetSurveys(user *model.User, filter model.SurveysFilter, groupIDs []string, admin bool) ([]model.Survey, error)
+	GetSurveysAssignedToUser(user *model.User, groupIDs []string, limit *int, offset *int) ([]model.Survey, error)
 	CreateSurvey(survey model.Survey) (*model.Survey, error)
-	UpdateSurvey(user *model.User, survey model.Survey, admin bool) error
+	UpdateSurvey(user *model.User, survey model.Survey, version int, admin bool) error
 	DeleteSurvey(user *model.User, id string, admin bool) error
 	DeleteSurveysWithIDs(appID string, orgID string, accountsIDs []string) error
 
+	GetSurveyVersions(appID string, orgID string, surveyID string) ([]model.SurveyVersion, error)
+	GetSurveyVersion(appID string, orgID string, surveyID string, id string) (*model.SurveyVersion, error)
+	CreateSurveyVersion(version model.SurveyVersion) error
+	DeleteSurveyVersion(appID string, orgID string, id string) error
+	GetSurveyVersionResponsesCounts(appID string, orgID string, surveyID string) (map[string]int, error)
+
+	GetSurveyTemplates(appID string, orgID string, types []string) ([]model.SurveyTemplate, error)
+	GetSurveyTemplate(appID string, orgID string, id string) (*model.SurveyTemplate, error)
+	CreateSurveyTemplate(template model.SurveyTemplate) (*model.SurveyTemplate, error)
+	UpdateSurveyTemplate(appID string, orgID string, id string, template model.SurveyTemplate) error
+	DeleteSurveyTemplate(appID string, orgID string, id string) error
+
+	GetSurveyQuestions(appID string, orgID string) ([]model.SurveyQuestion, error)
+	GetSurveyQuestion(appID string, orgID string, id string) (*model.SurveyQuestion, error)
+	GetSurveyQuestionsByIDs(appID string, orgID string, ids []string) ([]model.SurveyQuestion, error)
+	CreateSurveyQuestion(question model.SurveyQuestion) (*model.SurveyQuestion, error)
+	UpdateSurveyQuestion(appID string, orgID string, id string, question model.SurveyQuestion) error
+	DeleteSurveyQuestion(appID string, orgID string, id string) error
+	GetSurveysByQuestion(appID string, orgID string, questionID string) ([]model.Survey, error)
+
 	GetSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error)
 	GetSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time, limit *int, offset *int) ([]model.SurveyResponse, error)
 	GetSurveyResponseByUserID(user *model.User) ([]model.SurveyResponse, error)
+	GetSurveyResponseCounts(appID string, orgID string, surveyID string) (*model.SurveyResponseCounts, error)
+	GetSurveyResponseAggregates(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (*model.SurveyResponseAggregates, error)
+	GetSurveyResponsesBySurvey(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) ([]model.SurveyResponse, error)
+	CountSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (int, error)
+	StreamSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter, handler func(surveyResponse model.SurveyResponse) error) error
 	CreateSurveyResponse(surveyResponse model.SurveyResponse) (*model.SurveyResponse, error)
-	UpdateSurveyResponse(user *model.User, id string, surveyResponse model.Survey) error
+	UpdateSurveyResponse(user *model.User, surveyResponse model.SurveyResponse) error
+	CompleteSurveyResponseProgress(user *model.User, surveyResponse model.SurveyResponse) error
+	GetSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error)
+	SaveSurveyResponseProgress(surveyResponse model.SurveyResponse) error
+	GetSurveyResponsesToEncrypt(activeKeyID string, afterID *string, limit int) ([]model.SurveyResponse, error)
+	UpdateSurveyResponseEncryption(previous model.SurveyResponse, surveyResponse model.SurveyResponse) (bool, error)
+	ReserveSurveyResponseAttempt(user *model.User, surveyID string, limits model.SurveyResponseLimits, now time.Time) (*model.SurveyResponseAttempts, error)
+	ReleaseSurveyResponseAttempt(previous model.SurveyResponseAttempts) error
+	ReserveSurveyQuotas(survey model.Survey, quotas []model.SurveyQuota) (*model.SurveyQuotaCounts, error)
+	ReleaseSurveyQuotas(surveyID string, quotas []model.SurveyQuota) error
+	CloseSurvey(appID string, orgID string, id string) (bool, error)
 	DeleteSurveyResponse(user *model.User, id string) error
 	DeleteSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time) error
 	DeleteSurveyResponsesWithIDs(appID string, orgID string, accountsIDs []string) error
+	GetScheduledSurveys(now time.Time) ([]model.Survey, error)
+	GetSurveyRespondents(appID string, orgID string, surveyID string) ([]string, error)
+	GetSurveyLastResponseDates(appID string, orgID string, surveyID string, userIDs []string, since time.Time) (map[string]time.Time, error)
+	GetSurveyReminderSettings(appID string, orgID string, userID string) (*model.SurveyReminderSettings, error)
+	FindSurveyReminderSettings(appID string, orgID string, userIDs []string) ([]model.SurveyReminderSettings, error)
+	GetSurveyReminderUserIDs(appID string, orgID string) ([]string, error)
+	SaveSurveyReminderSettings(settings model.SurveyReminderSettings) error
+	CreateSurveyReminder(reminder model.SurveyReminder) (bool, error)
 
 	GetAlertContacts(user *model.User) ([]model.AlertContact, error)
 	GetAlertContact(user *model.User, id string) (*model.AlertContact, error)
@@ -31,4 +31,9 @@ type Config struct {
 
 	NotificationsHost string
 	GroupsHost        string
+
+	SurveyProgressExpirationHours string
+	SensitiveSurveyMinRespondents string
+	EncryptionKeysFile            string
+	ExportPseudonymKey            string
 }
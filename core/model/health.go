@@ -0,0 +1,45 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+const (
+	// HealthStatusOK all the components are working as expected
+	HealthStatusOK = "ok"
+	// HealthStatusDegraded some of the components are not working as expected
+	HealthStatusDegraded = "degraded"
+	// HealthStatusStarting some of the components did not start yet and none of them failed
+	HealthStatusStarting = "starting"
+)
+
+// ChangeStreamStatus represents the state of a collection change stream watcher
+type ChangeStreamStatus struct {
+	Collection        string     `json:"collection"`
+	Healthy           bool       `json:"healthy"`
+	Starting          bool       `json:"starting"` // the watcher did not connect nor fail yet
+	Resumed           bool       `json:"resumed"`
+	ReconnectAttempts int        `json:"reconnect_attempts"`
+	ConnectedSince    *time.Time `json:"connected_since"`
+	LastEventAt       *time.Time `json:"last_event_at"`
+	LastError         *string    `json:"last_error"`
+	LastErrorAt       *time.Time `json:"last_error_at"`
+} // @name ChangeStreamStatus
+
+// Health wraps the health of the service
+type Health struct {
+	Status        string               `json:"status"`
+	ChangeStreams []ChangeStreamStatus `json:"change_streams"`
+} // @name Health
@@ -15,6 +15,8 @@
 package model
 
 import (
+	"errors"
+	"polls/utils"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -35,21 +37,24 @@ type PollsFilter struct {
 
 // PollData data stored for a poll
 type PollData struct {
-	UserID        string     `json:"userid" bson:"userid" validate:"required"`
-	UserName      string     `json:"username" bson:"username" validate:"required"`
-	ToMembersList ToMembers  `json:"to_members" bson:"to_members"` // nil or empty means everyone; non-empty means visible to those user ids
-	Question      string     `json:"question" bson:"question" validate:"required"`
-	Options       []string   `json:"options" bson:"options" validate:"required,min=2,dive,required"`
-	GroupID       *string    `json:"group_id,omitempty" bson:"group_id"`
-	Pin           int        `json:"pin,omitempty" bson:"pin" validate:"min=0,max=9999"`
-	MultiChoice   bool       `json:"multi_choice" bson:"multi_choice"`
-	Repeat        bool       `json:"repeat" bson:"repeat"`
-	ShowResults   bool       `json:"show_results" bson:"show_results"`
-	Stadium       string     `json:"stadium" bson:"stadium"`
-	Geo           bool       `json:"geo_fence" bson:"geo_fence"`
-	Status        string     `json:"status" bson:"status" validate:"required,oneof=created started"`
-	DateCreated   time.Time  `json:"date_created" bson:"date_created"`
-	DateUpdated   *time.Time `json:"date_updated" bson:"date_updated"`
+	UserID         string                     `json:"userid" bson:"userid" validate:"required"`
+	UserName       string                     `json:"username" bson:"username" validate:"required"`
+	ToMembersList  ToMembers                  `json:"to_members" bson:"to_members"` // nil or empty means everyone; non-empty means visible to those user ids
+	Question       string                     `json:"question" bson:"question" validate:"required"`
+	Options        []string                   `json:"options" bson:"options" validate:"required,min=2,dive,required"`
+	Language       string                     `json:"language,omitempty" bson:"language,omitempty"`         // the language of the question and the options, defaults to en
+	Translations   map[string]PollTranslation `json:"translations,omitempty" bson:"translations,omitempty"` // by language
+	GroupID        *string                    `json:"group_id,omitempty" bson:"group_id"`
+	Pin            int                        `json:"pin,omitempty" bson:"pin" validate:"min=0,max=9999"`
+	MultiChoice    bool                       `json:"multi_choice" bson:"multi_choice"`
+	ShuffleOptions bool                       `json:"shuffle_options" bson:"shuffle_options"` // the options are presented in a random order per user
+	Repeat         bool                       `json:"repeat" bson:"repeat"`
+	ShowResults    bool                       `json:"show_results" bson:"show_results"`
+	Stadium        string                     `json:"stadium" bson:"stadium"`
+	Geo            bool                       `json:"geo_fence" bson:"geo_fence"`
+	Status         string                     `json:"status" bson:"status" validate:"required,oneof=created started"`
+	DateCreated    time.Time                  `json:"date_created" bson:"date_created"`
+	DateUpdated    *time.Time                 `json:"date_updated" bson:"date_updated"`
 } // @name PollData
 
 // UserHasAccess Checks if the user has read and write access to the poll object
@@ -92,8 +97,15 @@ type ToMember struct {
 	ExternalID string `json:"external_id" bson:"external_id"`
 	Name       string `json:"name" bson:"name"`
 	Email      string `json:"email" bson:"email"`
+	Language   string `json:"language,omitempty" bson:"language,omitempty"` // the preferred language of the notifications, if known
 } //@name ToMember
 
+// PollTranslation is the question and the options of a poll in another language
+type PollTranslation struct {
+	Question string   `json:"question" bson:"question"`
+	Options  []string `json:"options" bson:"options"` // in the order of the poll options
+} // @name PollTranslation
+
 // PollNotification wraps the entire record
 type PollNotification struct {
 	PollData  `json:"poll" bson:"poll"`
@@ -215,9 +227,20 @@ func (poll *Poll) ToPollResult(currentUserID string) PollResult {
 		}
 	}
 
+	result.OptionOrder = poll.OptionOrder(currentUserID)
+
 	return result
 }
 
+// OptionOrder gives the order in which the options are presented to a user, or nil if the options are not shuffled.
+// The order is random per user and stable across reloads
+func (poll *Poll) OptionOrder(userID string) []int {
+	if !poll.ShuffleOptions || len(poll.Options) < 2 {
+		return nil
+	}
+	return utils.ShuffledOrder(len(poll.Options), userID, poll.ID.Hex())
+}
+
 // GetPollNotificationRecipients gets poll to members as notification recipients
 func (poll *Poll) GetPollNotificationRecipients(currentUserID string) []UserRef {
 	var recipients []UserRef
@@ -239,6 +262,9 @@ type PollVote struct {
 	UserID  string    `json:"userid" validate:"required"`
 	Answer  []int     `json:"answer" validate:"required,min=1"`
 	Created time.Time `json:"created"`
+
+	OptionOrder []int `json:"option_order,omitempty" bson:"option_order,omitempty"` // the order in which the options were presented to the user
+
 } // @name PollVote
 
 // PollResult wraps poll result
@@ -246,7 +272,57 @@ type PollResult struct {
 	PollData          `json:"poll" bson:""`
 	ID                primitive.ObjectID `json:"id"`
 	Voted             []int              `json:"voted,omitempty"`
+	OptionOrder       []int              `json:"option_order,omitempty"` // the order in which the options are presented to the user, as their indexes
 	Results           []int              `json:"results"`
 	UniqueVotersCount int                `json:"unique_voters_count"`
 	Total             int                `json:"total"`
 } // @name PollResult
+
+// ErrInvalidPollTranslation is returned when a translation of a poll does not translate its question or all its options
+var ErrInvalidPollTranslation = errors.New("invalid poll translation")
+
+// ErrNotPollCreator is returned when an action on a poll is allowed only to its creator and the admins of its group
+var ErrNotPollCreator = errors.New("only the creator of the poll or a group admin is allowed")
+
+const (
+	// PollsExportFormatCSV exports one row per poll option and one row per voter answer
+	PollsExportFormatCSV = "csv"
+	// PollsExportFormatJSON exports a JSON array of the poll outcomes
+	PollsExportFormatJSON = "json"
+)
+
+// PollsExport are the options of an export of poll outcomes
+type PollsExport struct {
+	Format string
+	Voters bool // include the answers of every voter where it is allowed
+}
+
+// PollOutcome is the outcome of a poll as it is exported
+type PollOutcome struct {
+	ID                string            `json:"id"`
+	Question          string            `json:"question"`
+	Status            string            `json:"status"`
+	GroupID           *string           `json:"group_id"`
+	MultiChoice       bool              `json:"multi_choice"`
+	Options           []PollOptionCount `json:"options"`
+	UniqueVotersCount int               `json:"unique_voters_count"`
+	Total             int               `json:"total"`
+	DateCreated       time.Time         `json:"date_created"`
+	DateUpdated       *time.Time        `json:"date_updated"`
+	DateFirstVote     *time.Time        `json:"date_first_vote"`
+	DateLastVote      *time.Time        `json:"date_last_vote"`
+	Voters            []PollVoterAnswer `json:"voters,omitempty"` // only for the polls of a group or to a list of members
+} // @name PollOutcome
+
+// PollOptionCount is the number of votes for a poll option
+type PollOptionCount struct {
+	Option string `json:"option"`
+	Count  int    `json:"count"`
+} // @name PollOptionCount
+
+// PollVoterAnswer is the answer of a voter to a poll
+type PollVoterAnswer struct {
+	UserID  string    `json:"user_id"`
+	Options []string  `json:"options"`
+	Created time.Time `json:"created"`
+} // @name PollVoterAnswer
@@ -15,6 +15,12 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -24,6 +30,14 @@ type SurveyAlert struct {
 	Content    map[string]interface{} `json:"content" bson:"content"`
 }
 
+// SurveyAlertRule sends a survey alert to the alert contacts of a key when a response to the survey meets the condition of the rule
+type SurveyAlertRule struct {
+	ContactKey string `json:"contact_key" bson:"contact_key"`
+	Condition  string `json:"condition" bson:"condition"` // JSON encoded condition of the survey rules, for example {"operator": ">=", "data_key": "stats.scores", "compare_to": 15}
+	Subject    string `json:"subject" bson:"subject"`
+	Body       string `json:"body" bson:"body"` // {{<key>}} is replaced with the value of a key of the survey rules, or of survey.id, survey.title or survey.result
+} // @name SurveyAlertRule
+
 // AlertContact is what will be used to identify where to send survey alerts
 type AlertContact struct {
 	ID          string                 `json:"id" bson:"_id"`
@@ -46,8 +60,81 @@ type SurveyResponse struct {
 	Survey      Survey     `json:"survey" bson:"survey"`
 	DateCreated time.Time  `json:"date_created" bson:"date_created"`
 	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
+
+	SurveyVersionID string `json:"survey_version_id" bson:"survey_version_id"`
+
+	Status         string     `json:"status" bson:"status"`
+	CurrentDataKey *string    `json:"current_data_key" bson:"current_data_key"`
+	DateExpires    *time.Time `json:"date_expires" bson:"date_expires"`
+
+	// the order in which the randomized survey was presented to the user
+	Presentation *SurveyPresentation `json:"presentation,omitempty" bson:"presentation,omitempty"`
+
+	// the answers of the responses to the sensitive surveys are stored encrypted and they are removed from the survey
+	Encrypted *EncryptedData `json:"-" bson:"encrypted,omitempty"`
+}
+
+const (
+	// SurveyResponseStatusInProgress the response is saved while the survey is answered and it expires if it is not completed
+	SurveyResponseStatusInProgress = "in_progress"
+	// SurveyResponseStatusCompleted the response is final. Responses without a status are completed
+	SurveyResponseStatusCompleted = "completed"
+)
+
+// EncryptedData is data encrypted with its own data key. The data key is stored encrypted with the key of the service identified by KeyID
+type EncryptedData struct {
+	KeyID        string `bson:"key_id"`
+	EncryptedKey []byte `bson:"encrypted_key"`
+	Data         []byte `bson:"data"`
 }
 
+// SurveyResponseProgress is the partial answer of a survey saved by the user to be resumed later
+type SurveyResponseProgress struct {
+	Survey         Survey  `json:"survey"`
+	CurrentDataKey *string `json:"current_data_key"`
+} // @name SurveyResponseProgress
+
+// ErrSurveyResponseProgressNotFound is returned when the user has no survey response in progress
+var ErrSurveyResponseProgressNotFound = errors.New("no survey response in progress")
+
+// ErrSurveyResponseInProgress is returned when a survey response in progress is updated instead of finalized
+var ErrSurveyResponseInProgress = errors.New("the survey response is in progress and must be finalized")
+
+// ErrSurveyResponseSurveyMismatch is returned when a survey response is updated with the responses to another survey
+var ErrSurveyResponseSurveyMismatch = errors.New("the survey response belongs to another survey")
+
+const (
+	// SurveyStatusDraft the survey is visible only to its creator and the admins
+	SurveyStatusDraft = "draft"
+	// SurveyStatusPublished the survey accepts responses within its start and end dates
+	SurveyStatusPublished = "published"
+	// SurveyStatusClosed the survey does not accept responses anymore
+	SurveyStatusClosed = "closed"
+	// SurveyStatusArchived the survey is kept only for its history
+	SurveyStatusArchived = "archived"
+)
+
+// ErrSurveyNotOpen is returned when a survey does not accept responses
+var ErrSurveyNotOpen = errors.New("the survey is not open for responses")
+
+// ErrSurveyResponseLimitReached is returned when the response limits of a survey do not allow another response from the user
+var ErrSurveyResponseLimitReached = errors.New("the survey response limit is reached")
+
+// ErrSurveyQuotaReached is returned when a quota which applies to the user is full
+var ErrSurveyQuotaReached = errors.New("the survey quota is reached")
+
+// ErrNotSurveyCreator is returned when an action on a survey is allowed only to its creator and the admins
+var ErrNotSurveyCreator = errors.New("only the creator of the survey is allowed")
+
+// ErrSurveyTooFewRespondents is returned when the responses to a sensitive survey are requested while it has less respondents than the minimum
+var ErrSurveyTooFewRespondents = errors.New("the survey has too few respondents")
+
+// ErrSensitiveSurveyExport is returned when the responses to a sensitive survey are exported by someone who is not an admin
+var ErrSensitiveSurveyExport = errors.New("the responses to a sensitive survey are exported only to the admins")
+
+// ErrSurveyNotAssigned is returned when a survey is not targeted at the user
+var ErrSurveyNotAssigned = errors.New("the survey is not assigned to the user")
+
 // Survey wraps the entire record
 type Survey struct {
 	ID                 string                 `json:"id" bson:"_id"`
@@ -66,13 +153,273 @@ type Survey struct {
 	DefaultDataKey     *string                `json:"default_data_key" bson:"default_data_key"`
 	DefaultDataKeyRule *string                `json:"default_data_key_rule" bson:"default_data_key_rule"`
 	Constants          map[string]interface{} `json:"constants" bson:"constants"`
-	Strings            map[string]interface{} `json:"strings" bson:"strings"`
+	Strings            map[string]interface{} `json:"strings" bson:"strings"` // per language string tables: {"<language>": {"<key>": "<text>"}}
 	SubRules           map[string]interface{} `json:"sub_rules" bson:"sub_rules"`
 	ResponseKeys       []string               `json:"response_keys" bson:"response_keys"`
+	VersionID          string                 `json:"version_id" bson:"version_id"`
+	Version            int                    `json:"version" bson:"version"`
+	GroupIDs           []string               `json:"group_ids" bson:"group_ids"`   // non-empty means visible to the members of those groups; the survey is for everyone only if both lists are empty
+	ToMembersList      ToMembers              `json:"to_members" bson:"to_members"` // non-empty means visible to those user ids; the survey is for everyone only if both lists are empty
+	Status             string                 `json:"status" bson:"status"`
+	StartDate          *time.Time             `json:"start_date" bson:"start_date"`
+	EndDate            *time.Time             `json:"end_date" bson:"end_date"`
+	ResponseLimits     *SurveyResponseLimits  `json:"response_limits" bson:"response_limits"`
+	Randomization      *SurveyRandomization   `json:"randomization" bson:"randomization"`
+	Schedule           *SurveySchedule        `json:"schedule" bson:"schedule"`
+	AlertRules         []SurveyAlertRule      `json:"alert_rules" bson:"alert_rules"`
+	Quotas             []SurveyQuota          `json:"quotas" bson:"quotas"`
 	DateCreated        time.Time              `json:"date_created" bson:"date_created"`
 	DateUpdated        *time.Time             `json:"date_updated" bson:"date_updated"`
+
+	duplicateDataKeys []string
+}
+
+// IsOpen checks if the survey accepts responses at the given time
+func (s Survey) IsOpen(now time.Time) bool {
+	if len(s.Status) > 0 && s.Status != SurveyStatusPublished {
+		return false
+	}
+	if s.StartDate != nil && now.Before(*s.StartDate) {
+		return false
+	}
+	if s.EndDate != nil && now.After(*s.EndDate) {
+		return false
+	}
+	return true
+}
+
+// IsForEveryone checks if the survey is not targeted at specific groups or members
+func (s Survey) IsForEveryone() bool {
+	return len(s.GroupIDs) == 0 && len(s.ToMembersList) == 0
+}
+
+// IsAssignedTo checks if the survey is targeted at a user directly or through one of the given groups
+func (s Survey) IsAssignedTo(userID string, groupIDs []string) bool {
+	for _, member := range s.ToMembersList {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	for _, groupID := range s.GroupIDs {
+		for _, userGroupID := range groupIDs {
+			if groupID == userGroupID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON decodes a survey and keeps the data keys which are duplicated in the JSON, as they are lost in the data map
+func (s *Survey) UnmarshalJSON(data []byte) error {
+	type survey Survey
+	var decoded survey
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*s = Survey(decoded)
+	s.duplicateDataKeys = findDuplicateKeys(data, "data")
+	return nil
 }
 
+// DuplicateDataKeys gives the data keys which are defined more than once in the decoded JSON
+func (s Survey) DuplicateDataKeys() []string {
+	return s.duplicateDataKeys
+}
+
+// findDuplicateKeys gives the duplicated keys of the object stored under field in a JSON object
+func findDuplicateKeys(data []byte, field string) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		if key, _ := token.(string); key != field {
+			var skipped json.RawMessage
+			if decoder.Decode(&skipped) != nil {
+				return nil
+			}
+			continue
+		}
+
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return nil
+		}
+		var duplicates []string
+		keys := map[string]bool{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return duplicates
+			}
+			key, _ := token.(string)
+			if keys[key] {
+				duplicates = append(duplicates, key)
+			}
+			keys[key] = true
+
+			var skipped json.RawMessage
+			if decoder.Decode(&skipped) != nil {
+				return duplicates
+			}
+		}
+		return duplicates
+	}
+	return nil
+}
+
+// SurveyRandomization randomizes the order in which a survey is presented. The order is random per user and stable across reloads
+type SurveyRandomization struct {
+	ShuffleOptions   bool `json:"shuffle_options" bson:"shuffle_options"`     // the options of the multiple choice data
+	ShuffleQuestions bool `json:"shuffle_questions" bson:"shuffle_questions"` // the data keys of the pages
+} // @name SurveyRandomization
+
+// SurveyPresentation is the order in which a randomized survey is presented to a user
+type SurveyPresentation struct {
+	DataKeys map[string][]string `json:"data_keys,omitempty" bson:"data_keys,omitempty"` // the presented data keys of the pages, by page key
+	Options  map[string][]int    `json:"options,omitempty" bson:"options,omitempty"`     // the presented options as indexes of the defined options, by data key
+} // @name SurveyPresentation
+
+const (
+	// SurveyScheduleFrequencyDaily the survey recurs every day
+	SurveyScheduleFrequencyDaily = "daily"
+	// SurveyScheduleFrequencyWeekly the survey recurs on the weekdays of the schedule
+	SurveyScheduleFrequencyWeekly = "weekly"
+)
+
+// SurveySchedule makes a survey recurring. A period starts on every scheduled day, and the users who did not respond in the current period
+// are reminded at the scheduled time of the day in their timezone
+type SurveySchedule struct {
+	Frequency    string  `json:"frequency" bson:"frequency"`                   // daily or weekly
+	Weekdays     []int   `json:"weekdays,omitempty" bson:"weekdays,omitempty"` // the days of the weekly schedules, from 0 for Sunday to 6 for Saturday
+	Hour         int     `json:"hour" bson:"hour"`
+	Minute       int     `json:"minute" bson:"minute"`
+	Timezone     *string `json:"timezone" bson:"timezone"`           // the IANA timezone of the users who did not set theirs, defaults to America/Chicago
+	ReminderText *string `json:"reminder_text" bson:"reminder_text"` // localizable, defaults to a reminder with the survey title
+} // @name SurveySchedule
+
+// SurveyReminderSettings are the settings of a user for the reminders of the scheduled surveys
+type SurveyReminderSettings struct {
+	ID              string     `json:"id" bson:"_id"`
+	OrgID           string     `json:"org_id" bson:"org_id"`
+	AppID           string     `json:"app_id" bson:"app_id"`
+	UserID          string     `json:"user_id" bson:"user_id"`
+	Timezone        *string    `json:"timezone" bson:"timezone"`                     // the IANA timezone of the user
+	Language        *string    `json:"language" bson:"language"`                     // the preferred language of the reminders
+	OptOut          bool       `json:"opt_out" bson:"opt_out"`                       // no reminders for any survey
+	OptOutSurveyIDs []string   `json:"opt_out_survey_ids" bson:"opt_out_survey_ids"` // no reminders for these surveys
+	DateCreated     time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated     *time.Time `json:"date_updated" bson:"date_updated"`
+} // @name SurveyReminderSettings
+
+// SurveyReminder records the reminder sent to a user in a period of a scheduled survey, so that only one reminder is sent per period
+type SurveyReminder struct {
+	ID          string    `bson:"_id"` // the survey, the user and the period
+	OrgID       string    `bson:"org_id"`
+	AppID       string    `bson:"app_id"`
+	SurveyID    string    `bson:"survey_id"`
+	UserID      string    `bson:"user_id"`
+	PeriodStart time.Time `bson:"period_start"`
+	DateCreated time.Time `bson:"date_created"`
+}
+
+// ErrInvalidSurveyReminderSettings is returned when the survey reminder settings of a user have an unknown timezone
+var ErrInvalidSurveyReminderSettings = errors.New("invalid survey reminder settings")
+
+// SurveyResponseLimits limits how often a user can respond to a survey
+type SurveyResponseLimits struct {
+	MaxAttempts     *int `json:"max_attempts" bson:"max_attempts"`         // nil means unlimited; 1 means one response per user
+	CooldownSeconds *int `json:"cooldown_seconds" bson:"cooldown_seconds"` // the minimum time between two responses of a user
+} // @name SurveyResponseLimits
+
+// IsEmpty checks if the limits do not restrict the responses
+func (l SurveyResponseLimits) IsEmpty() bool {
+	return l.MaxAttempts == nil && (l.CooldownSeconds == nil || *l.CooldownSeconds <= 0)
+}
+
+// SurveyResponseAttempts counts the responses of a user to a survey to enforce the response limits of the survey
+type SurveyResponseAttempts struct {
+	ID              string     `json:"id" bson:"_id"`
+	SurveyID        string     `json:"survey_id" bson:"survey_id"`
+	UserID          string     `json:"user_id" bson:"user_id"`
+	OrgID           string     `json:"org_id" bson:"org_id"`
+	AppID           string     `json:"app_id" bson:"app_id"`
+	Count           int        `json:"count" bson:"count"`
+	DateLastAttempt *time.Time `json:"date_last_attempt" bson:"date_last_attempt"`
+}
+
+// SurveyQuota limits the number of the completed responses to a survey, overall or for the members of a group
+type SurveyQuota struct {
+	Key     string  `json:"key" bson:"key"`
+	Limit   int     `json:"limit" bson:"limit"`
+	GroupID *string `json:"group_id" bson:"group_id"` // nil counts all the responses; set counts only the responses of the group members
+} // @name SurveyQuota
+
+// SurveyQuotaCounts counts the completed responses to a survey by quota key
+type SurveyQuotaCounts struct {
+	ID     string         `json:"id" bson:"_id"` // the survey ID
+	OrgID  string         `json:"org_id" bson:"org_id"`
+	AppID  string         `json:"app_id" bson:"app_id"`
+	Counts map[string]int `json:"counts" bson:"counts"`
+}
+
+// SurveysFilter wraps all the filters which could be used for retrieving surveys
+type SurveysFilter struct {
+	Types      []string   `json:"types,omitempty"`
+	CreatorIDs []string   `json:"creator_ids,omitempty"`
+	Statuses   []string   `json:"statuses,omitempty"`
+	Title      *string    `json:"title,omitempty"`
+	StartDate  *time.Time `json:"start_date,omitempty"`
+	EndDate    *time.Time `json:"end_date,omitempty"`
+	Offset     *int64     `json:"offset,omitempty"`
+	Limit      *int64     `json:"limit,omitempty"`
+} // @name SurveysFilter
+
+// SurveyVersion is an immutable revision of a survey. A new version is stored on every change of the survey
+type SurveyVersion struct {
+	ID             string    `json:"id" bson:"_id"`
+	SurveyID       string    `json:"survey_id" bson:"survey_id"`
+	OrgID          string    `json:"org_id" bson:"org_id"`
+	AppID          string    `json:"app_id" bson:"app_id"`
+	Version        int       `json:"version" bson:"version"`
+	CreatorID      string    `json:"creator_id" bson:"creator_id"`
+	RolledBackFrom *string   `json:"rolled_back_from" bson:"rolled_back_from"`
+	Survey         Survey    `json:"survey" bson:"survey"`
+	ResponsesCount *int      `json:"responses_count" bson:"-"` // null for the sensitive surveys when it comes from less respondents than the minimum
+	DateCreated    time.Time `json:"date_created" bson:"date_created"`
+} // @name SurveyVersion
+
+const (
+	// SurveyVersionChangeAdded the value exists only in the newer version
+	SurveyVersionChangeAdded = "added"
+	// SurveyVersionChangeRemoved the value exists only in the older version
+	SurveyVersionChangeRemoved = "removed"
+	// SurveyVersionChangeChanged the value is different in both versions
+	SurveyVersionChangeChanged = "changed"
+)
+
+// SurveyVersionChange is a single difference between two survey versions
+type SurveyVersionChange struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+} // @name SurveyVersionChange
+
+// SurveyVersionDiff lists the differences between two survey versions
+type SurveyVersionDiff struct {
+	FromVersionID string                `json:"from_version_id"`
+	ToVersionID   string                `json:"to_version_id"`
+	Changes       []SurveyVersionChange `json:"changes"`
+} // @name SurveyVersionDiff
+
 // SurveyStats are stats of a Survey
 type SurveyStats struct {
 	Total         int                    `json:"total" bson:"total"`
@@ -83,8 +430,309 @@ type SurveyStats struct {
 	ResponseData  map[string]interface{} `json:"response_data" bson:"response_data"`
 }
 
+// SurveyLiveStats are the aggregated counts of the responses to a survey. They never contain individual responses
+type SurveyLiveStats struct {
+	SurveyID       string                         `json:"survey_id"`
+	Total          int                            `json:"total"`
+	Questions      map[string]SurveyQuestionStats `json:"questions"`
+	MinRespondents *int                           `json:"min_respondents,omitempty"` // set for the sensitive surveys
+	Suppressed     bool                           `json:"suppressed,omitempty"`      // the survey has less respondents than the minimum
+	DateUpdated    time.Time                      `json:"date_updated"`
+} // @name SurveyLiveStats
+
+// SurveyQuestionStats are the aggregated counts of the responses to a survey question.
+// The counts of the sensitive surveys with less respondents than the minimum are suppressed as null
+type SurveyQuestionStats struct {
+	Answered   *int            `json:"answered"`
+	Options    map[string]*int `json:"options,omitempty"`
+	Suppressed bool            `json:"suppressed,omitempty"`
+} // @name SurveyQuestionStats
+
+// SurveyResponseCounts are the counts of the responses to a survey as they are aggregated in the storage
+type SurveyResponseCounts struct {
+	Total    int
+	Answered map[string]int
+	Values   map[string][]SurveyResponseValueCount
+}
+
+// SurveyResponseValueCount is the number of the responses with a specific value for a survey question
+type SurveyResponseValueCount struct {
+	Value interface{}
+	Count int
+}
+
+// SurveyResultsFilter wraps the filters of the aggregated survey results
+type SurveyResultsFilter struct {
+	StartDate  *time.Time `json:"start_date,omitempty"`
+	EndDate    *time.Time `json:"end_date,omitempty"`
+	VersionIDs []string   `json:"version_ids,omitempty"`
+} // @name SurveyResultsFilter
+
+const (
+	// SurveyResponsesExportFormatCSV exports one row per response with one column per question
+	SurveyResponsesExportFormatCSV = "csv"
+	// SurveyResponsesExportFormatJSONL exports one JSON object per line for every response
+	SurveyResponsesExportFormatJSONL = "jsonl"
+)
+
+// SurveyResponsesExport are the options of an export of the completed responses to a survey
+type SurveyResponsesExport struct {
+	Format       string
+	Filter       SurveyResultsFilter
+	Pseudonymize bool // the user ids are replaced by pseudonyms which are stable for the survey. Always on for the sensitive surveys
+}
+
+// SurveyResponseAggregates are the aggregated values of the responses to a survey as they are computed in the storage.
+// The numbers and the scores are sorted in ascending order
+type SurveyResponseAggregates struct {
+	Completed  int
+	InProgress int
+	Answered   map[string]int
+	Values     map[string][]SurveyResponseValueCount
+	Numbers    map[string][]float64
+	Scores     map[string][]float64
+}
+
+// SurveyResults are the aggregated results of the completed responses to a survey
+type SurveyResults struct {
+	SurveyID       string                           `json:"survey_id"`
+	Filter         SurveyResultsFilter              `json:"filter"`
+	Total          int                              `json:"total"`
+	InProgress     int                              `json:"in_progress"`
+	CompletionRate *float64                         `json:"completion_rate"` // the completed responses out of all the started ones
+	Questions      map[string]SurveyQuestionResults `json:"questions"`
+	Scores         map[string]SurveyDistribution    `json:"scores,omitempty"`          // by score section
+	MinRespondents *int                             `json:"min_respondents,omitempty"` // set for the sensitive surveys
+	Suppressed     bool                             `json:"suppressed,omitempty"`      // the survey has less respondents than the minimum
+	DateCreated    time.Time                        `json:"date_created"`
+} // @name SurveyResults
+
+// SurveyQuestionResults are the aggregated results of a survey question.
+// The counts of the sensitive surveys with less respondents than the minimum are suppressed as null
+type SurveyQuestionResults struct {
+	Answered   *int                `json:"answered"`
+	AnswerRate *float64            `json:"answer_rate"` // the responses which answered the question out of all the completed ones
+	Options    map[string]*int     `json:"options,omitempty"`
+	Numeric    *SurveyDistribution `json:"numeric,omitempty"`
+	Suppressed bool                `json:"suppressed,omitempty"`
+} // @name SurveyQuestionResults
+
+// SurveyDistribution describes the distribution of numeric responses or scores.
+// The min and the max are individual responses, so they are not given for the sensitive surveys
+type SurveyDistribution struct {
+	Count       int                `json:"count"`
+	Min         *float64           `json:"min"`
+	Max         *float64           `json:"max"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles"`
+} // @name SurveyDistribution
+
+// SurveyResponseValidationError contains the validation errors of a survey response by survey data key
+type SurveyResponseValidationError struct {
+	Errors map[string]string `json:"errors"`
+} // @name SurveyResponseValidationError
+
+func (e *SurveyResponseValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = fmt.Sprintf("%s: %s", key, e.Errors[key])
+	}
+	return fmt.Sprintf("invalid survey response - %s", strings.Join(messages, ", "))
+}
+
+const (
+	// SurveyLintSeverityError the survey can not be saved
+	SurveyLintSeverityError = "error"
+	// SurveyLintSeverityWarning the survey can be saved, but it is probably not what was intended
+	SurveyLintSeverityWarning = "warning"
+)
+
+// SurveyLintIssue is a problem found in a survey definition
+type SurveyLintIssue struct {
+	Severity string `json:"severity"`
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	Message  string `json:"message"`
+} // @name SurveyLintIssue
+
+// SurveyLint is the result of a survey definition lint
+type SurveyLint struct {
+	Valid  bool              `json:"valid"`
+	Issues []SurveyLintIssue `json:"issues"`
+} // @name SurveyLint
+
+// SurveyLintError is returned when a survey definition has errors
+type SurveyLintError struct {
+	SurveyLint
+}
+
+func (e *SurveyLintError) Error() string {
+	messages := []string{}
+	for _, issue := range e.Issues {
+		if issue.Severity == SurveyLintSeverityError {
+			messages = append(messages, fmt.Sprintf("%s: %s", issue.Key, issue.Message))
+		}
+	}
+	return fmt.Sprintf("invalid survey - %s", strings.Join(messages, ", "))
+}
+
+// SurveyBundleSchemaVersion is the version of the survey bundle format exported by the service
+const SurveyBundleSchemaVersion = 1
+
+// SurveyBundle is a self-contained survey definition which can be imported to another app, org or environment.
+// It has no ids, tenant, audience or publication fields
+type SurveyBundle struct {
+	SchemaVersion      int                    `json:"schema_version"`
+	Title              string                 `json:"title"`
+	MoreInfo           *string                `json:"more_info"`
+	Data               map[string]SurveyData  `json:"data"`
+	Scored             bool                   `json:"scored"`
+	ResultRules        string                 `json:"result_rules"`
+	Type               string                 `json:"type"`
+	Sensitive          bool                   `json:"sensitive"`
+	DefaultDataKey     *string                `json:"default_data_key"`
+	DefaultDataKeyRule *string                `json:"default_data_key_rule"`
+	Constants          map[string]interface{} `json:"constants"`
+	Strings            map[string]interface{} `json:"strings"`
+	SubRules           map[string]interface{} `json:"sub_rules"`
+	ResponseKeys       []string               `json:"response_keys"`
+	ResponseLimits     *SurveyResponseLimits  `json:"response_limits"`
+	Randomization      *SurveyRandomization   `json:"randomization"`
+	Schedule           *SurveySchedule        `json:"schedule"`
+	AlertRules         []SurveyAlertRule      `json:"alert_rules"`
+	Quotas             []SurveyQuota          `json:"quotas"` // only the overall quotas, as the groups belong to the tenant
+	DateExported       *time.Time             `json:"date_exported"`
+
+	duplicateDataKeys []string
+} // @name SurveyBundle
+
+// UnmarshalJSON decodes a survey bundle and keeps track of the data keys defined more than once
+func (b *SurveyBundle) UnmarshalJSON(data []byte) error {
+	type bundle SurveyBundle
+	var decoded bundle
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*b = SurveyBundle(decoded)
+	b.duplicateDataKeys = findDuplicateKeys(data, "data")
+	return nil
+}
+
+// NewSurveyBundle gives the portable definition of a survey. The responses stored in the survey data are not exported,
+// and the questions of the question bank are exported with their definitions instead of their references
+func NewSurveyBundle(survey Survey, dateExported time.Time) SurveyBundle {
+	bundle := SurveyBundle{SchemaVersion: SurveyBundleSchemaVersion, Title: survey.Title, MoreInfo: survey.MoreInfo, Scored: survey.Scored,
+		ResultRules: survey.ResultRules, Type: survey.Type, Sensitive: survey.Sensitive, DefaultDataKey: survey.DefaultDataKey,
+		DefaultDataKeyRule: survey.DefaultDataKeyRule, Constants: survey.Constants, Strings: survey.Strings, SubRules: survey.SubRules,
+		ResponseKeys: survey.ResponseKeys, ResponseLimits: survey.ResponseLimits, Randomization: survey.Randomization, Schedule: survey.Schedule,
+		AlertRules: survey.AlertRules, DateExported: &dateExported}
+
+	bundle.Data = make(map[string]SurveyData, len(survey.Data))
+	for key, data := range survey.Data {
+		data.Response = nil
+		data.QuestionID = nil
+		bundle.Data[key] = data
+	}
+	for _, quota := range survey.Quotas {
+		if quota.GroupID == nil {
+			bundle.Quotas = append(bundle.Quotas, quota)
+		}
+	}
+	return bundle
+}
+
+// ToSurvey converts to a Survey without ids and tenant
+func (b SurveyBundle) ToSurvey() Survey {
+	return Survey{Title: b.Title, MoreInfo: b.MoreInfo, Data: b.Data, Scored: b.Scored, ResultRules: b.ResultRules, Type: b.Type,
+		Sensitive: b.Sensitive, DefaultDataKey: b.DefaultDataKey, DefaultDataKeyRule: b.DefaultDataKeyRule, Constants: b.Constants,
+		Strings: b.Strings, SubRules: b.SubRules, ResponseKeys: b.ResponseKeys, ResponseLimits: b.ResponseLimits, Randomization: b.Randomization,
+		Schedule: b.Schedule, AlertRules: b.AlertRules, Quotas: b.Quotas, duplicateDataKeys: b.duplicateDataKeys}
+}
+
+// SurveyImportError contains the errors of an imported survey definition which can not be converted to a survey
+type SurveyImportError struct {
+	Errors map[string]string `json:"errors"`
+} // @name SurveyImportError
+
+func (e *SurveyImportError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = fmt.Sprintf("%s: %s", key, e.Errors[key])
+	}
+	return fmt.Sprintf("invalid survey import - %s", strings.Join(messages, ", "))
+}
+
+// SurveyQuestionPropagationError is returned when a question of the question bank is updated but some of the surveys which reference it
+// can not be stored as a new version with the new definition. These surveys get the new definition when they are retrieved
+type SurveyQuestionPropagationError struct {
+	SurveyIDs []string `json:"survey_ids"`
+} // @name SurveyQuestionPropagationError
+
+func (e *SurveyQuestionPropagationError) Error() string {
+	return fmt.Sprintf("the question is not updated in the surveys %s", strings.Join(e.SurveyIDs, ", "))
+}
+
+// ErrSurveyQuestionInUse is returned when a question of the question bank is removed while surveys reference it
+var ErrSurveyQuestionInUse = errors.New("the question is used by surveys")
+
+// ErrInvalidSurveyQuestion is returned when a question of the question bank has no valid survey data
+var ErrInvalidSurveyQuestion = errors.New("invalid question")
+
+// SurveyQuestion is a reusable survey data definition of the question bank. The survey data reference it by its id and get its definition,
+// while their flow stays defined by the survey
+type SurveyQuestion struct {
+	ID          string     `json:"id" bson:"_id"`
+	OrgID       string     `json:"org_id" bson:"org_id"`
+	AppID       string     `json:"app_id" bson:"app_id"`
+	Name        string     `json:"name" bson:"name"`
+	Data        SurveyData `json:"data" bson:"data"`
+	Version     int        `json:"version" bson:"version"`
+	CreatorID   string     `json:"creator_id" bson:"creator_id"`
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
+} // @name SurveyQuestion
+
+// ErrSurveyCloneNotAllowed is returned when a survey is cloned to another app or org by an admin who can not access it
+var ErrSurveyCloneNotAllowed = errors.New("the survey can not be cloned to another app or org")
+
+// SurveyClone are the options of a survey clone
+type SurveyClone struct {
+	Title *string `json:"title"`  // defaults to the title of the survey
+	AppID *string `json:"app_id"` // defaults to the app of the survey
+	OrgID *string `json:"org_id"` // defaults to the org of the survey
+} // @name SurveyClone
+
+// SurveyTemplate is a survey definition curated by the admins, which the users can instantiate into new surveys
+type SurveyTemplate struct {
+	ID          string     `json:"id" bson:"_id"`
+	OrgID       string     `json:"org_id" bson:"org_id"`
+	AppID       string     `json:"app_id" bson:"app_id"`
+	Name        string     `json:"name" bson:"name"`
+	Description *string    `json:"description" bson:"description"`
+	Type        string     `json:"type" bson:"type"` // the type of the surveys created from the template
+	Survey      Survey     `json:"survey" bson:"survey"`
+	CreatorID   string     `json:"creator_id" bson:"creator_id"`
+	DateCreated time.Time  `json:"date_created" bson:"date_created"`
+	DateUpdated *time.Time `json:"date_updated" bson:"date_updated"`
+} // @name SurveyTemplate
+
 // SurveyData is data stored for a Survey
 type SurveyData struct {
+	QuestionID          *string     `json:"question_id,omitempty" bson:"question_id,omitempty"` // the question of the question bank which defines the data
 	Section             *string     `json:"section" bson:"section"`
 	AllowSkip           bool        `json:"allow_skip" bson:"allow_skip"`
 	Text                string      `json:"text" bson:"text"`
@@ -146,3 +794,16 @@ type OptionData struct {
 	Score    *float64    `json:"score" bson:"score"`
 	Selected bool        `json:"selected" bson:"selected"`
 }
+
+// SurveyUntranslatedString is a localizable text of a survey which has no translation
+type SurveyUntranslatedString struct {
+	Path string `json:"path"` // the location of the text in the survey, as title, more_info, data.<key>.text, data.<key>.options.<index>.title or schedule.reminder_text
+	Text string `json:"text"`
+} // @name SurveyUntranslatedString
+
+// SurveyTranslations reports the untranslated texts of a survey per language
+type SurveyTranslations struct {
+	SurveyID     string                                `json:"survey_id"`
+	Languages    []string                              `json:"languages"`
+	Untranslated map[string][]SurveyUntranslatedString `json:"untranslated"`
+} // @name SurveyTranslations
@@ -0,0 +1,155 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"polls/core/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the columns of the polls CSV export. The option rows have a count and the voter rows have a voter
+var pollsExportColumns = []string{"poll_id", "question", "status", "group_id", "date_created", "date_updated", "date_first_vote", "date_last_vote",
+	"unique_voters_count", "total", "option", "count", "voter_id", "date_voted"}
+
+// exportPoll writes the outcome of a poll to w for its creator or the admins of its group
+func (app *Application) exportPoll(user *model.User, id string, export model.PollsExport, w io.Writer) error {
+	poll, err := app.getPoll(user, id, nil)
+	if err != nil {
+		return err
+	}
+	if poll == nil {
+		return fmt.Errorf("error on Application.exportPoll(%s) - poll not found", id)
+	}
+	err = app.checkPollPermission(user, poll, "export")
+	if err != nil {
+		return fmt.Errorf("error on Application.exportPoll(%s) - %w: %s", id, model.ErrNotPollCreator, err)
+	}
+
+	return writePollOutcomes([]model.PollOutcome{pollOutcome(*poll, export.Voters)}, export.Format, w)
+}
+
+// exportPolls writes the outcomes of the polls matching the filter to w. Only the polls created by the user
+// and the polls of the groups administered by the user are exported
+func (app *Application) exportPolls(user *model.User, filter model.PollsFilter, export model.PollsExport, w io.Writer) error {
+	polls, err := app.getPolls(user, filter, true, nil)
+	if err != nil {
+		return err
+	}
+
+	outcomes := []model.PollOutcome{}
+	groupAdmin := map[string]bool{}
+	for _, poll := range polls {
+		allowed := poll.UserID == user.Claims.Subject
+		if !allowed && poll.GroupID != nil && len(*poll.GroupID) > 0 {
+			var checked bool
+			allowed, checked = groupAdmin[*poll.GroupID]
+			if !checked {
+				allowed = app.checkPollPermission(user, &poll, "export") == nil
+				groupAdmin[*poll.GroupID] = allowed
+			}
+		}
+		if allowed {
+			outcomes = append(outcomes, pollOutcome(poll, export.Voters))
+		}
+	}
+
+	return writePollOutcomes(outcomes, export.Format, w)
+}
+
+// pollOutcome counts the votes of a poll. The answers of the voters are given only for the polls of a group
+// or to a list of members, as the voters of the public polls have not agreed to be known
+func pollOutcome(poll model.Poll, voters bool) model.PollOutcome {
+	result := poll.ToPollResult("")
+	outcome := model.PollOutcome{ID: poll.ID.Hex(), Question: poll.Question, Status: poll.Status, GroupID: poll.GroupID, MultiChoice: poll.MultiChoice,
+		Options: make([]model.PollOptionCount, len(poll.Options)), UniqueVotersCount: result.UniqueVotersCount, Total: result.Total,
+		DateCreated: poll.DateCreated, DateUpdated: poll.DateUpdated}
+	for i, option := range poll.Options {
+		outcome.Options[i] = model.PollOptionCount{Option: option, Count: result.Results[i]}
+	}
+
+	votersAllowed := voters && ((poll.GroupID != nil && len(*poll.GroupID) > 0) || len(poll.ToMembersList) > 0)
+	for _, vote := range poll.Responses {
+		created := vote.Created
+		if outcome.DateFirstVote == nil || created.Before(*outcome.DateFirstVote) {
+			outcome.DateFirstVote = &created
+		}
+		if outcome.DateLastVote == nil || created.After(*outcome.DateLastVote) {
+			outcome.DateLastVote = &created
+		}
+
+		if votersAllowed {
+			answer := model.PollVoterAnswer{UserID: vote.UserID, Options: []string{}, Created: vote.Created}
+			for _, index := range vote.Answer {
+				if index >= 0 && index < len(poll.Options) {
+					answer.Options = append(answer.Options, poll.Options[index])
+				}
+			}
+			outcome.Voters = append(outcome.Voters, answer)
+		}
+	}
+	return outcome
+}
+
+// writePollOutcomes writes the poll outcomes as a JSON array, or as CSV with one row per option and one row per voter answer.
+// The texts of the CSV are quoted when spreadsheets would evaluate them as formulas
+func writePollOutcomes(outcomes []model.PollOutcome, format string, w io.Writer) error {
+	if format == model.PollsExportFormatJSON {
+		return json.NewEncoder(w).Encode(outcomes)
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(pollsExportColumns)
+	if err != nil {
+		return err
+	}
+	for _, outcome := range outcomes {
+		groupID := ""
+		if outcome.GroupID != nil {
+			groupID = *outcome.GroupID
+		}
+		poll := []string{outcome.ID, csvText(outcome.Question), outcome.Status, groupID, outcome.DateCreated.Format(time.RFC3339),
+			formatExportDate(outcome.DateUpdated), formatExportDate(outcome.DateFirstVote), formatExportDate(outcome.DateLastVote),
+			strconv.Itoa(outcome.UniqueVotersCount), strconv.Itoa(outcome.Total)}
+
+		for _, option := range outcome.Options {
+			err = writer.Write(append(poll[:len(poll):len(poll)], csvText(option.Option), strconv.Itoa(option.Count), "", ""))
+			if err != nil {
+				return err
+			}
+		}
+		for _, voter := range outcome.Voters {
+			created := voter.Created
+			err = writer.Write(append(poll[:len(poll):len(poll)], csvText(strings.Join(voter.Options, "; ")), "", voter.UserID, formatExportDate(&created)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatExportDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(time.RFC3339)
+}
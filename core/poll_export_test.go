@@ -0,0 +1,98 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"polls/core/model"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func exportTestPoll(groupID *string, toMembers model.ToMembers) model.Poll {
+	created := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	id, _ := primitive.ObjectIDFromHex("6530f1a2b3c4d5e6f7a8b9c0")
+	return model.Poll{ID: id, PollData: model.PollData{UserID: "creator", Question: "=Lunch?", Options: []string{"Pizza", "-Salad"}, GroupID: groupID,
+		ToMembersList: toMembers, MultiChoice: true, Status: "started", DateCreated: created},
+		Responses: []model.PollVote{
+			{UserID: "u1", Answer: []int{0, 1}, Created: created.Add(2 * time.Hour)},
+			{UserID: "u2", Answer: []int{1, 5}, Created: created.Add(time.Hour)},
+		}}
+}
+
+func TestPollOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		poll       model.Poll
+		voters     bool
+		wantVoters []model.PollVoterAnswer
+	}{
+		{"public poll", exportTestPoll(nil, nil), true, nil},
+		{"group poll without voters", exportTestPoll(stringPtr("group1"), nil), false, nil},
+		{"group poll", exportTestPoll(stringPtr("group1"), nil), true, []model.PollVoterAnswer{
+			{UserID: "u1", Options: []string{"Pizza", "-Salad"}, Created: time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC)},
+			{UserID: "u2", Options: []string{"-Salad"}, Created: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)}}},
+		{"poll to members", exportTestPoll(nil, model.ToMembers{{UserID: "u1"}, {UserID: "u2"}}), true, []model.PollVoterAnswer{
+			{UserID: "u1", Options: []string{"Pizza", "-Salad"}, Created: time.Date(2026, time.October, 19, 11, 0, 0, 0, time.UTC)},
+			{UserID: "u2", Options: []string{"-Salad"}, Created: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := pollOutcome(tt.poll, tt.voters)
+			wantOptions := []model.PollOptionCount{{Option: "Pizza", Count: 1}, {Option: "-Salad", Count: 2}}
+			if !reflect.DeepEqual(outcome.Options, wantOptions) || outcome.UniqueVotersCount != 2 {
+				t.Errorf("pollOutcome() options = %+v, unique voters = %d", outcome.Options, outcome.UniqueVotersCount)
+			}
+			if !outcome.DateFirstVote.Equal(tt.poll.DateCreated.Add(time.Hour)) || !outcome.DateLastVote.Equal(tt.poll.DateCreated.Add(2*time.Hour)) {
+				t.Errorf("pollOutcome() first vote = %v, last vote = %v", outcome.DateFirstVote, outcome.DateLastVote)
+			}
+			if !reflect.DeepEqual(outcome.Voters, tt.wantVoters) {
+				t.Errorf("pollOutcome() voters = %+v, want %+v", outcome.Voters, tt.wantVoters)
+			}
+		})
+	}
+}
+
+func TestWritePollOutcomes(t *testing.T) {
+	outcome := pollOutcome(exportTestPoll(stringPtr("group1"), nil), true)
+
+	var csvOut bytes.Buffer
+	if err := writePollOutcomes([]model.PollOutcome{outcome}, model.PollsExportFormatCSV, &csvOut); err != nil {
+		t.Fatalf("writePollOutcomes() error = %v", err)
+	}
+	poll := "6530f1a2b3c4d5e6f7a8b9c0,'=Lunch?,started,group1,2026-10-19T09:00:00Z,,2026-10-19T10:00:00Z,2026-10-19T11:00:00Z,2,3,"
+	want := "poll_id,question,status,group_id,date_created,date_updated,date_first_vote,date_last_vote,unique_voters_count,total,option,count,voter_id,date_voted\n" +
+		poll + "Pizza,1,,\n" +
+		poll + "'-Salad,2,,\n" +
+		poll + "Pizza; -Salad,,u1,2026-10-19T11:00:00Z\n" +
+		poll + "'-Salad,,u2,2026-10-19T10:00:00Z\n"
+	if got := csvOut.String(); got != want {
+		t.Errorf("writePollOutcomes() csv = %q, want %q", got, want)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := writePollOutcomes([]model.PollOutcome{outcome}, model.PollsExportFormatJSON, &jsonOut); err != nil {
+		t.Fatalf("writePollOutcomes() error = %v", err)
+	}
+	var outcomes []model.PollOutcome
+	if err := json.Unmarshal(jsonOut.Bytes(), &outcomes); err != nil || len(outcomes) != 1 || outcomes[0].Question != "=Lunch?" {
+		t.Errorf("writePollOutcomes() json = %s, want the outcomes as they are", jsonOut.String())
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"polls/core/model"
+	"strings"
+)
+
+// checkPollTranslations checks that the translations of a poll translate its question and all its options, and gives the languages
+// of the poll in lower case
+func checkPollTranslations(poll *model.Poll) error {
+	poll.Language = strings.ToLower(strings.TrimSpace(poll.Language))
+	if len(poll.Translations) == 0 {
+		return nil
+	}
+
+	translations := make(map[string]model.PollTranslation, len(poll.Translations))
+	for language, translation := range poll.Translations {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if len(language) == 0 {
+			return fmt.Errorf("%w - the language is missing", model.ErrInvalidPollTranslation)
+		}
+		if len(translation.Question) == 0 {
+			return fmt.Errorf("%w - the %s question is missing", model.ErrInvalidPollTranslation, language)
+		}
+		if len(translation.Options) != len(poll.Options) {
+			return fmt.Errorf("%w - the %s translation has %d options instead of %d", model.ErrInvalidPollTranslation, language, len(translation.Options), len(poll.Options))
+		}
+		translations[language] = translation
+	}
+	poll.Translations = translations
+	return nil
+}
+
+// localizePoll replaces the question and the options of a poll with their translation in the first of the languages which the poll has.
+// The languages fall back to their base languages and then to the default language
+func localizePoll(poll *model.Poll, languages []string) {
+	if len(poll.Translations) == 0 || len(languages) == 0 {
+		return
+	}
+
+	question, options := pollText(*poll, languages)
+	poll.Question = question
+	poll.Options = options
+}
+
+// pollText gives the question and the options of a poll in the first of the languages which the poll has
+func pollText(poll model.Poll, languages []string) (string, []string) {
+	pollLanguage := poll.Language
+	if len(pollLanguage) == 0 {
+		pollLanguage = defaultLanguage
+	}
+	for _, language := range languageCandidates(languages) {
+		if language == pollLanguage {
+			break
+		}
+		if translation, ok := poll.Translations[language]; ok {
+			return translation.Question, translation.Options
+		}
+	}
+	return poll.Question, poll.Options
+}
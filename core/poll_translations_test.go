@@ -0,0 +1,141 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+func translatedTestPoll(language string) model.Poll {
+	return model.Poll{PollData: model.PollData{Language: language, Question: "Coffee or tea?", Options: []string{"Coffee", "Tea"},
+		Translations: map[string]model.PollTranslation{
+			"es":    {Question: "¿Café o té?", Options: []string{"Café", "Té"}},
+			"fr-ca": {Question: "Café ou thé?", Options: []string{"Café", "Thé"}},
+			"en":    {Question: "Coffee or tea, mate?", Options: []string{"Coffee", "Tea"}},
+		},
+	}}
+}
+
+func TestPollText(t *testing.T) {
+	tests := []struct {
+		name         string
+		pollLanguage string
+		languages    []string
+		wantQuestion string
+		wantOptions  []string
+	}{
+		{"translated language", "", []string{"es"}, "¿Café o té?", []string{"Café", "Té"}},
+		{"base language fallback", "", []string{"es-MX"}, "¿Café o té?", []string{"Café", "Té"}},
+		{"regional translation", "", []string{"FR-CA"}, "Café ou thé?", []string{"Café", "Thé"}},
+		{"no base translation", "", []string{"fr"}, "Coffee or tea?", []string{"Coffee", "Tea"}},
+		{"first translated language", "", []string{"de", "es"}, "¿Café o té?", []string{"Café", "Té"}},
+		{"default language fallback", "de", []string{"it"}, "Coffee or tea, mate?", []string{"Coffee", "Tea"}},
+		{"poll in its own language", "de", []string{"de", "es"}, "Coffee or tea?", []string{"Coffee", "Tea"}},
+		{"poll in the default language", "", []string{"en-GB", "es"}, "Coffee or tea?", []string{"Coffee", "Tea"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question, options := pollText(translatedTestPoll(tt.pollLanguage), tt.languages)
+			if question != tt.wantQuestion || !reflect.DeepEqual(options, tt.wantOptions) {
+				t.Errorf("pollText() = %s, %v, want %s, %v", question, options, tt.wantQuestion, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func TestLocalizePoll(t *testing.T) {
+	poll := translatedTestPoll("")
+	localizePoll(&poll, nil)
+	if poll.Question != "Coffee or tea?" {
+		t.Errorf("localizePoll() without languages = %s", poll.Question)
+	}
+
+	localizePoll(&poll, []string{"es"})
+	if poll.Question != "¿Café o té?" || !reflect.DeepEqual(poll.Options, []string{"Café", "Té"}) {
+		t.Errorf("localizePoll() = %s, %v", poll.Question, poll.Options)
+	}
+}
+
+func TestCheckPollTranslations(t *testing.T) {
+	tests := []struct {
+		name             string
+		translations     map[string]model.PollTranslation
+		wantErr          bool
+		wantTranslations []string
+	}{
+		{"no translations", nil, false, nil},
+		{"valid translations", map[string]model.PollTranslation{" ES ": {Question: "¿Café o té?", Options: []string{"Café", "Té"}}}, false, []string{"es"}},
+		{"missing language", map[string]model.PollTranslation{" ": {Question: "¿Café o té?", Options: []string{"Café", "Té"}}}, true, nil},
+		{"missing question", map[string]model.PollTranslation{"es": {Options: []string{"Café", "Té"}}}, true, nil},
+		{"missing option", map[string]model.PollTranslation{"es": {Question: "¿Café o té?", Options: []string{"Café"}}}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := model.Poll{PollData: model.PollData{Language: " DE ", Question: "Kaffee oder Tee?", Options: []string{"Kaffee", "Tee"}, Translations: tt.translations}}
+			err := checkPollTranslations(&poll)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalidPollTranslation) {
+					t.Errorf("checkPollTranslations() error = %v, want %v", err, model.ErrInvalidPollTranslation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkPollTranslations() error = %v", err)
+			}
+			if poll.Language != "de" {
+				t.Errorf("checkPollTranslations() language = %q, want de", poll.Language)
+			}
+			for _, language := range tt.wantTranslations {
+				if _, ok := poll.Translations[language]; !ok {
+					t.Errorf("checkPollTranslations() translations = %v, want %s", poll.Translations, language)
+				}
+			}
+		})
+	}
+}
+
+func TestPollRecipientsByLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		members model.ToMembers
+		want    [][]string
+	}{
+		{"all recipients", nil, [][]string{{}}},
+		{"single language", model.ToMembers{{UserID: "1", Language: "es"}, {UserID: "2", Language: " ES "}}, [][]string{{"1", "2"}}},
+		{"several languages", model.ToMembers{{UserID: "1", Language: "es"}, {UserID: "2"}, {UserID: "3", Language: "fr"}, {UserID: "4", Language: "es"}, {UserID: "5"}},
+			[][]string{{"1", "4"}, {"2", "5"}, {"3"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := [][]string{}
+			for _, members := range pollRecipientsByLanguage(tt.members) {
+				userIDs := []string{}
+				for _, member := range members {
+					userIDs = append(userIDs, member.UserID)
+				}
+				got = append(got, userIDs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pollRecipientsByLanguage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,17 +21,20 @@ import (
 	"polls/core/model"
 	"polls/driven/groups"
 	"polls/driven/storage"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const surveyStatsUpdateInterval = time.Second
+
 func (app *Application) getVersion() string {
 	return app.version
 }
 
-func (app *Application) getPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool) ([]model.Poll, error) {
+func (app *Application) getPolls(user *model.User, filter model.PollsFilter, filterByToMembers bool, languages []string) ([]model.Poll, error) {
 
 	var membership *groups.GroupMembership
 	if len(filter.GroupIDs) > 0 {
@@ -44,26 +47,45 @@ func (app *Application) getPolls(user *model.User, filter model.PollsFilter, fil
 		membership = groupMembership
 	}
 
-	return app.storage.GetPolls(user, filter, filterByToMembers, membership)
+	polls, err := app.storage.GetPolls(user, filter, filterByToMembers, membership)
+	if err != nil {
+		return nil, err
+	}
+	for i := range polls {
+		localizePoll(&polls[i], languages)
+	}
+	return polls, nil
 }
 
-func (app *Application) getPoll(user *model.User, id string) (*model.Poll, error) {
+func (app *Application) getPoll(user *model.User, id string, languages []string) (*model.Poll, error) {
 	groupMembership, err := app.groups.GetGroupsMembership(user.Token)
 	if err != nil {
 		log.Printf("error app.getPoll() - unable to retrieve user groups - %s", err)
 		return nil, fmt.Errorf("error app.getPoll() - unable to retrieve user groups - %s", err)
 	}
 
-	return app.storage.GetPoll(user, id, true, groupMembership)
+	poll, err := app.storage.GetPoll(user, id, true, groupMembership)
+	if err != nil {
+		return nil, err
+	}
+	if poll != nil {
+		localizePoll(poll, languages)
+	}
+	return poll, nil
 }
 
 func (app *Application) createPoll(user *model.User, poll model.Poll) (*model.Poll, error) {
+	err := checkPollTranslations(&poll)
+	if err != nil {
+		return nil, err
+	}
+
 	createdPoll, err := app.storage.CreatePoll(user, poll)
 	if err != nil {
 		return nil, err
 	}
 
-	app.notifyNotificationsBBForPoll(user, createdPoll, "polls", "poll_created", fmt.Sprintf("Poll '%s' has been created", createdPoll.Question))
+	app.notifyNotificationsBBForPoll(user, createdPoll, "polls", "poll_created", "Poll '%s' has been created")
 
 	if poll.GroupID != nil {
 		go app.groups.UpdateGroupDateUpdated(*poll.GroupID)
@@ -83,6 +105,11 @@ func (app *Application) updatePoll(user *model.User, poll model.Poll) (*model.Po
 		return nil, err
 	}
 
+	err = checkPollTranslations(&poll)
+	if err != nil {
+		return nil, err
+	}
+
 	updatedPoll, err := app.storage.UpdatePoll(user, poll)
 	if err != nil {
 		return nil, err
@@ -136,7 +163,7 @@ func (app *Application) startPoll(user *model.User, pollID string) error {
 		return fmt.Errorf("error app.startPoll() - poll not found: %s", pollID)
 	}
 
-	app.notifyNotificationsBBForPoll(user, poll, "polls", "poll_started", fmt.Sprintf("Poll '%s' has been started", poll.Question))
+	app.notifyNotificationsBBForPoll(user, poll, "polls", "poll_started", "Poll '%s' has been started")
 
 	app.sseServer.NotifyPollForEvent(pollID, "poll_started")
 
@@ -170,7 +197,7 @@ func (app *Application) endPoll(user *model.User, pollID string) error {
 		return fmt.Errorf("error app.startPoll() - poll not found: %s", pollID)
 	}
 
-	app.notifyNotificationsBBForPoll(user, poll, "polls", "poll_ended", fmt.Sprintf("Poll '%s' has ended.", poll.Question))
+	app.notifyNotificationsBBForPoll(user, poll, "polls", "poll_ended", "Poll '%s' has ended.")
 
 	app.sseServer.NotifyPollForEvent(pollID, "poll_end")
 	app.sseServer.ClosePoll(pollID)
@@ -182,42 +209,27 @@ func (app *Application) endPoll(user *model.User, pollID string) error {
 	return nil
 }
 
-func (app *Application) notifyNotificationsBBForPoll(user *model.User, poll *model.Poll, topic string, operation string, message string) {
+// notifyNotificationsBBForPoll notifies the recipients of a poll. The message format gets the poll question, which is localized
+// for the recipients whose language is known
+func (app *Application) notifyNotificationsBBForPoll(user *model.User, poll *model.Poll, topic string, operation string, messageFormat string) {
 	subject := "Illinois"
 	if poll.GroupID != nil {
-
 		group, _ := app.groups.GetGroupDetails(user.Token, *poll.GroupID)
 		if group != nil {
 			subject = fmt.Sprintf("Group - %s", group.Title)
 		}
+	}
 
-		app.groups.SendGroupNotification(*poll.GroupID, model.GroupNotification{
-			Members: poll.ToMembersList.ToNotificationRecipients(),
-			Sender: &model.Sender{
-				Type: "user",
-				User: &model.UserRef{
-					UserID: user.Claims.Subject,
-					Name:   user.Claims.Name,
-				},
-			},
-			Topic:   &topic,
-			Subject: subject,
-			Body:    message,
-			Data: map[string]string{
-				"group_id":    *poll.GroupID,
-				"type":        "poll",
-				"operation":   operation,
-				"entity_type": "poll",
-				"entity_id":   poll.ID.Hex(),
-				"entity_name": poll.Question,
-			},
-		})
-	} else {
-		app.notifications.SendNotification(model.NotificationMessage{
-			Message: model.InnerMessage{
-				AppID:      user.Claims.AppID,
-				OrgID:      user.Claims.OrgID,
-				Recipients: poll.ToMembersList.ToNotificationRecipients(),
+	for _, recipients := range pollRecipientsByLanguage(poll.ToMembersList) {
+		question := poll.Question
+		if len(recipients) > 0 && len(recipients[0].Language) > 0 {
+			question, _ = pollText(*poll, []string{recipients[0].Language})
+		}
+		message := fmt.Sprintf(messageFormat, question)
+
+		if poll.GroupID != nil {
+			app.groups.SendGroupNotification(*poll.GroupID, model.GroupNotification{
+				Members: recipients.ToNotificationRecipients(),
 				Sender: &model.Sender{
 					Type: "user",
 					User: &model.UserRef{
@@ -229,18 +241,76 @@ func (app *Application) notifyNotificationsBBForPoll(user *model.User, poll *mod
 				Subject: subject,
 				Body:    message,
 				Data: map[string]string{
+					"group_id":    *poll.GroupID,
 					"type":        "poll",
 					"operation":   operation,
 					"entity_type": "poll",
 					"entity_id":   poll.ID.Hex(),
-					"entity_name": poll.Question,
+					"entity_name": question,
 				},
-			},
-		})
+			})
+		} else {
+			app.notifications.SendNotification(model.NotificationMessage{
+				Message: model.InnerMessage{
+					AppID:      user.Claims.AppID,
+					OrgID:      user.Claims.OrgID,
+					Recipients: recipients.ToNotificationRecipients(),
+					Sender: &model.Sender{
+						Type: "user",
+						User: &model.UserRef{
+							UserID: user.Claims.Subject,
+							Name:   user.Claims.Name,
+						},
+					},
+					Topic:   &topic,
+					Subject: subject,
+					Body:    message,
+					Data: map[string]string{
+						"type":        "poll",
+						"operation":   operation,
+						"entity_type": "poll",
+						"entity_id":   poll.ID.Hex(),
+						"entity_name": question,
+					},
+				},
+			})
+		}
+	}
+}
+
+// pollRecipientsByLanguage splits the recipients of a poll by their language. The recipients whose language is not known stay together,
+// and no recipients mean all the recipients of the poll
+func pollRecipientsByLanguage(members model.ToMembers) []model.ToMembers {
+	if len(members) == 0 {
+		return []model.ToMembers{members}
+	}
+
+	languages := []string{}
+	byLanguage := map[string]model.ToMembers{}
+	for _, member := range members {
+		language := strings.ToLower(strings.TrimSpace(member.Language))
+		if _, ok := byLanguage[language]; !ok {
+			languages = append(languages, language)
+		}
+		byLanguage[language] = append(byLanguage[language], member)
+	}
+
+	split := make([]model.ToMembers, len(languages))
+	for i, language := range languages {
+		split[i] = byLanguage[language]
 	}
+	return split
 }
 
 func (app *Application) votePoll(user *model.User, pollID string, vote model.PollVote) error {
+	poll, err := app.storage.GetPoll(user, pollID, false, nil)
+	if err != nil {
+		return err
+	}
+	if poll != nil {
+		// the presented order is recorded with the vote for the analysis of the order bias
+		vote.OptionOrder = poll.OptionOrder(user.Claims.Subject)
+	}
 	return app.storage.VotePoll(user, pollID, vote)
 }
 
@@ -294,56 +364,789 @@ func (app *Application) OnCollectionUpdated(collection string, record map[string
 			app.sseServer.NotifyPollUpdate(poll.ID.Hex(), poll)
 		}
 	}
+
+	if "surveyresponses" == collection && record != nil {
+		// the responses in progress are not part of the stats
+		if status, _ := record["status"].(string); status == model.SurveyResponseStatusInProgress {
+			return
+		}
+		survey, ok := record["survey"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		surveyID, ok := survey["_id"].(string)
+		if !ok {
+			return
+		}
+
+		if app.sseServer.GetSurveyForStats(surveyID) != nil {
+			app.scheduleSurveyStatsUpdate(surveyID)
+		}
+	}
+}
+
+// scheduleSurveyStatsUpdate sends the survey stats to the subscribers after surveyStatsUpdateInterval so that bursts of responses are aggregated once
+func (app *Application) scheduleSurveyStatsUpdate(surveyID string) {
+	app.surveyStatsLock.Lock()
+	defer app.surveyStatsLock.Unlock()
+
+	if app.surveyStatsPending[surveyID] {
+		return
+	}
+	app.surveyStatsPending[surveyID] = true
+
+	time.AfterFunc(surveyStatsUpdateInterval, func() {
+		app.surveyStatsLock.Lock()
+		delete(app.surveyStatsPending, surveyID)
+		app.surveyStatsLock.Unlock()
+
+		survey := app.sseServer.GetSurveyForStats(surveyID)
+		if survey == nil {
+			return
+		}
+
+		stats, _, err := app.getSurveyLiveStats(*survey)
+		if err != nil {
+			log.Printf("Error on Application.scheduleSurveyStatsUpdate(%s): %s", surveyID, err)
+			return
+		}
+		app.sseServer.NotifySurveyStatsUpdate(surveyID, *stats)
+	})
+}
+
+func (app *Application) getSurvey(user *model.User, id string, admin bool, languages []string) (*model.Survey, error) {
+	survey, err := app.storage.GetSurvey(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// drafts are visible only to their creators and the admins
+	if survey.Status == model.SurveyStatusDraft && !admin && survey.CreatorID != user.Claims.Subject {
+		return nil, nil
+	}
+
+	hasAccess, err := app.hasSurveyAccess(user, *survey, admin)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, nil
+	}
+
+	// the survey data which reference the question bank get the current definitions of their questions, also when storing
+	// the survey again failed on a question update
+	_, err = app.resolveSurveyQuestions(survey)
+	if err != nil {
+		return nil, err
+	}
+	// the users get the survey texts at least in the default language. The admins get the string table keys unless they
+	// request languages, so that the survey can be edited
+	if !admin || len(languages) > 0 {
+		localizeSurvey(survey, languages)
+	}
+	// the admins see the survey as it is defined
+	if !admin {
+		presentSurvey(survey, user.Claims.Subject)
+	}
+	return survey, nil
+}
+
+func (app *Application) getSurveys(user *model.User, filter model.SurveysFilter, admin bool) ([]model.Survey, error) {
+	var groupIDs []string
+	if !admin {
+		userGroupIDs, err := app.getUserGroupIDs(user)
+		if err != nil {
+			return nil, err
+		}
+		groupIDs = userGroupIDs
+	}
+	return app.storage.GetSurveys(user, filter, groupIDs, admin)
+}
+
+func (app *Application) getSurveysAssignedToUser(user *model.User, limit *int, offset *int) ([]model.Survey, error) {
+	groupIDs, err := app.getUserGroupIDs(user)
+	if err != nil {
+		return nil, err
+	}
+	return app.storage.GetSurveysAssignedToUser(user, groupIDs, limit, offset)
+}
+
+// hasSurveyAccess checks if a survey is targeted at the user. The groups of the user are loaded only when they are needed
+func (app *Application) hasSurveyAccess(user *model.User, survey model.Survey, admin bool) (bool, error) {
+	if admin || survey.CreatorID == user.Claims.Subject || survey.IsForEveryone() || survey.IsAssignedTo(user.Claims.Subject, nil) {
+		return true, nil
+	}
+	if len(survey.GroupIDs) == 0 {
+		return false, nil
+	}
+
+	groupIDs, err := app.getUserGroupIDs(user)
+	if err != nil {
+		return false, err
+	}
+	return survey.IsAssignedTo(user.Claims.Subject, groupIDs), nil
 }
 
-func (app *Application) getSurvey(user *model.User, id string) (*model.Survey, error) {
-	return app.storage.GetSurvey(user, id)
+func (app *Application) getUserGroupIDs(user *model.User) ([]string, error) {
+	membership, err := app.groups.GetGroupsMembership(user.Token)
+	if err != nil {
+		log.Printf("error app.getUserGroupIDs() - unable to retrieve user groups - %s", err)
+		return nil, fmt.Errorf("error app.getUserGroupIDs() - unable to retrieve user groups - %s", err)
+	}
+	if membership == nil {
+		return nil, nil
+	}
+
+	groupIDs := append([]string{}, membership.GroupIDsAsAdmin...)
+	return append(groupIDs, membership.GroupIDsAsMember...), nil
 }
 
 func (app *Application) createSurvey(user *model.User, survey model.Survey, admin bool) (*model.Survey, error) {
-	survey.ID = uuid.NewString()
-	survey.CreatorID = user.Claims.Subject
-	survey.DateCreated = time.Now().UTC()
 	survey.AppID = user.Claims.AppID
 	survey.OrgID = user.Claims.OrgID
 	if !admin {
 		survey.Type = "user"
 	}
-	return app.storage.CreateSurvey(survey)
+	return app.insertSurvey(user, survey)
+}
+
+// insertSurvey stores a new survey of the user in the app and org of the survey, with its first version
+func (app *Application) insertSurvey(user *model.User, survey model.Survey) (*model.Survey, error) {
+	survey.ID = uuid.NewString()
+	survey.CreatorID = user.Claims.Subject
+	survey.DateCreated = time.Now().UTC()
+	survey.VersionID = uuid.NewString()
+	survey.Version = 1
+	if len(survey.Status) == 0 {
+		survey.Status = model.SurveyStatusPublished
+	}
+
+	err := app.checkSurveyDefinition(&survey)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := app.storage.CreateSurvey(survey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.createSurveyVersion(user, *created, nil)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 func (app *Application) updateSurvey(user *model.User, survey model.Survey, id string, admin bool) error {
+	_, err := app.saveSurveyRevision(user, survey, id, admin, nil)
+	return err
+}
+
+// saveSurveyRevision updates a survey and stores the result as a new immutable version. The version is stored first and the survey is
+// updated only if nobody updated it meanwhile, so a survey never points to a version which does not exist
+func (app *Application) saveSurveyRevision(user *model.User, survey model.Survey, id string, admin bool, rolledBackFrom *string) (*model.Survey, error) {
+	stored, err := app.storage.GetSurvey(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && stored.CreatorID != user.Claims.Subject {
+		return nil, fmt.Errorf("only the creator of a survey can update it")
+	}
+
+	// surveys created before the versioning keep their last state as the first version
+	storedVersion := stored.Version
+	if len(stored.VersionID) == 0 {
+		stored.VersionID = uuid.NewString()
+		stored.Version = 1
+		err = app.createSurveyVersion(user, *stored, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
 	survey.ID = id
+	survey.CreatorID = stored.CreatorID
+	survey.AppID = stored.AppID
+	survey.OrgID = stored.OrgID
+	survey.ResponseKeys = stored.ResponseKeys
+	survey.DateCreated = stored.DateCreated
+	survey.DateUpdated = &now
+	survey.VersionID = uuid.NewString()
+	survey.Version = stored.Version + 1
+	if len(survey.Status) == 0 {
+		survey.Status = stored.Status
+	}
+	if len(survey.Status) == 0 {
+		survey.Status = model.SurveyStatusPublished
+	}
 	if !admin {
 		survey.Type = "user"
 	}
-	return app.storage.UpdateSurvey(user, survey, admin)
+
+	err = app.checkSurveyDefinition(&survey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.createSurveyVersion(user, survey, rolledBackFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.storage.UpdateSurvey(user, survey, storedVersion, admin)
+	if err != nil {
+		deleteErr := app.storage.DeleteSurveyVersion(survey.AppID, survey.OrgID, survey.VersionID)
+		if deleteErr != nil {
+			log.Printf("error on Application.saveSurveyRevision(%s) - %s", id, deleteErr)
+		}
+		return nil, err
+	}
+
+	// the stats subscribers get the stats of the updated definition
+	if app.sseServer.UpdateSurveyForStats(survey) {
+		app.scheduleSurveyStatsUpdate(id)
+	}
+	return &survey, nil
+}
+
+func (app *Application) createSurveyVersion(user *model.User, survey model.Survey, rolledBackFrom *string) error {
+	version := model.SurveyVersion{ID: survey.VersionID, SurveyID: survey.ID, OrgID: survey.OrgID, AppID: survey.AppID,
+		Version: survey.Version, CreatorID: user.Claims.Subject, RolledBackFrom: rolledBackFrom, Survey: survey, DateCreated: time.Now().UTC()}
+	return app.storage.CreateSurveyVersion(version)
+}
+
+func (app *Application) getSurveyVersions(user *model.User, surveyID string) ([]model.SurveyVersion, error) {
+	versions, err := app.storage.GetSurveyVersions(user.Claims.AppID, user.Claims.OrgID, surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := app.storage.GetSurveyVersionResponsesCounts(user.Claims.AppID, user.Claims.OrgID, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	sensitive := false
+	for i := range versions {
+		count := counts[versions[i].ID]
+		versions[i].ResponsesCount = &count
+		sensitive = sensitive || versions[i].Survey.Sensitive
+	}
+	if sensitive {
+		suppressed := suppressSurveyVersionsResponsesCounts(versions, app.sensitiveSurveyMinRespondents)
+		if suppressed > 0 {
+			log.Printf("Suppressed %d version responses counts of the sensitive survey %s requested by %s with less than %d respondents",
+				suppressed, surveyID, user.Claims.Subject, app.sensitiveSurveyMinRespondents)
+		}
+	}
+	return versions, nil
+}
+
+func (app *Application) getSurveyVersionsDiff(user *model.User, surveyID string, fromVersionID string, toVersionID string) (*model.SurveyVersionDiff, error) {
+	from, err := app.storage.GetSurveyVersion(user.Claims.AppID, user.Claims.OrgID, surveyID, fromVersionID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := app.storage.GetSurveyVersion(user.Claims.AppID, user.Claims.OrgID, surveyID, toVersionID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := diffSurveys(from.Survey, to.Survey)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SurveyVersionDiff{FromVersionID: fromVersionID, ToVersionID: toVersionID, Changes: changes}, nil
+}
+
+func (app *Application) rollbackSurvey(user *model.User, surveyID string, versionID string) (*model.Survey, error) {
+	version, err := app.storage.GetSurveyVersion(user.Claims.AppID, user.Claims.OrgID, surveyID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := app.storage.GetSurvey(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rollback restores the definition, but keeps the current publication state
+	survey := version.Survey
+	survey.Status = current.Status
+	survey.StartDate = current.StartDate
+	survey.EndDate = current.EndDate
+
+	// the rollback is stored as a new version, so the history stays immutable
+	return app.saveSurveyRevision(user, survey, surveyID, true, &version.ID)
+}
+
+func (app *Application) lintSurvey(survey model.Survey) model.SurveyLint {
+	return lintSurvey(survey)
 }
 
 func (app *Application) deleteSurvey(user *model.User, id string, admin bool) error {
 	return app.storage.DeleteSurvey(user, id, admin)
 }
 
+func (app *Application) subscribeToSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{}) error {
+	survey, err := app.storage.GetSurvey(user, surveyID)
+	if err != nil {
+		return err
+	}
+	if survey.CreatorID != user.Claims.Subject {
+		return fmt.Errorf("only the creator of a survey can subscribe to its stats")
+	}
+
+	stats, suppressed, err := app.getSurveyLiveStats(*survey)
+	if err != nil {
+		return err
+	}
+	if suppressed > 0 {
+		log.Printf("Suppressed %d stats of the sensitive survey %s requested by %s with less than %d respondents",
+			suppressed, survey.ID, user.Claims.Subject, app.sensitiveSurveyMinRespondents)
+	}
+
+	app.sseServer.RegisterUserForSurveyStats(user.Claims.Subject, *survey, resultChan)
+	app.sseServer.NotifySurveyStatsClient(surveyID, resultChan, *stats)
+	return nil
+}
+
+func (app *Application) unsubscribeFromSurveyStats(user *model.User, surveyID string, resultChan chan map[string]interface{}) {
+	app.sseServer.UnregisterUserForSurveyStats(surveyID, resultChan)
+}
+
+// getSurveyLiveStats aggregates the responses to a survey. Only the options of the questions are counted, so free text responses are never exposed.
+// Gives the number of the counts of a sensitive survey which are suppressed
+func (app *Application) getSurveyLiveStats(survey model.Survey) (*model.SurveyLiveStats, int, error) {
+	counts, err := app.getSurveyResponseCounts(survey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stats := model.SurveyLiveStats{SurveyID: survey.ID, Total: counts.Total, Questions: map[string]model.SurveyQuestionStats{},
+		DateUpdated: time.Now().UTC()}
+	for key, data := range survey.Data {
+		answered := counts.Answered[key]
+		stats.Questions[key] = model.SurveyQuestionStats{Answered: &answered, Options: countOptions(data, counts.Values[key])}
+	}
+	suppressed := 0
+	if survey.Sensitive {
+		suppressed = suppressSurveyLiveStats(&stats, app.sensitiveSurveyMinRespondents)
+	}
+
+	return &stats, suppressed, nil
+}
+
+// getSurveyResults aggregates the completed responses to a survey for its creator or the admins
+func (app *Application) getSurveyResults(user *model.User, surveyID string, filter model.SurveyResultsFilter, admin bool) (*model.SurveyResults, error) {
+	survey, err := app.storage.GetSurvey(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && survey.CreatorID != user.Claims.Subject {
+		return nil, fmt.Errorf("error on Application.getSurveyResults(%s) - %w", surveyID, model.ErrNotSurveyCreator)
+	}
+
+	aggregates, err := app.getSurveyResponseAggregates(*survey, filter)
+	if err != nil {
+		return nil, err
+	}
+	results := buildSurveyResults(*survey, filter, *aggregates)
+	if survey.Sensitive {
+		suppressed := suppressSurveyResults(&results, app.sensitiveSurveyMinRespondents)
+		if suppressed > 0 {
+			log.Printf("Suppressed %d results of the sensitive survey %s requested by %s with less than %d respondents",
+				suppressed, survey.ID, user.Claims.Subject, app.sensitiveSurveyMinRespondents)
+		}
+	}
+	return &results, nil
+}
+
 func (app *Application) deleteSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time) error {
 	return app.storage.DeleteSurveyResponses(user, surveyIDs, surveyTypes, startDate, endDate)
 }
 
 func (app *Application) getSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error) {
-	return app.storage.GetSurveyResponse(user, id)
+	response, err := app.storage.GetSurveyResponse(user, id)
+	if err != nil {
+		return nil, err
+	}
+	err = app.decryptSurveyResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
 func (app *Application) getSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time, limit *int, offset *int) ([]model.SurveyResponse, error) {
-	return app.storage.GetSurveyResponses(user, surveyIDs, surveyTypes, startDate, endDate, limit, offset)
+	responses, err := app.storage.GetSurveyResponses(user, surveyIDs, surveyTypes, startDate, endDate, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	err = app.decryptSurveyResponses(responses)
+	if err != nil {
+		return nil, err
+	}
+	return responses, nil
 }
 
 func (app *Application) createSurveyResponse(user *model.User, survey model.Survey) (*model.SurveyResponse, error) {
+	evaluated, err := app.evaluateSurveyResponse(user, survey)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := app.reserveSurveyResponseAttempt(user, *evaluated)
+	if err != nil {
+		return nil, err
+	}
+	quotas, counts, err := app.reserveSurveyQuotas(user, *evaluated)
+	if err != nil {
+		app.releaseSurveyResponseAttempt(attempts)
+		return nil, err
+	}
+
 	response := model.SurveyResponse{ID: uuid.NewString(), AppID: user.Claims.AppID, OrgID: user.Claims.OrgID,
-		UserID: user.Claims.Subject, DateCreated: time.Now().UTC(), Survey: survey}
-	return app.storage.CreateSurveyResponse(response)
+		UserID: user.Claims.Subject, DateCreated: time.Now().UTC(), Survey: *evaluated, SurveyVersionID: evaluated.VersionID,
+		Status: model.SurveyResponseStatusCompleted, Presentation: surveyPresentation(*evaluated, user.Claims.Subject)}
+	encrypted, err := app.encryptSurveyResponse(response)
+	if err == nil {
+		_, err = app.storage.CreateSurveyResponse(encrypted)
+	}
+	if err != nil {
+		app.releaseSurveyResponseAttempt(attempts)
+		app.releaseSurveyQuotas(evaluated.ID, quotas)
+		return nil, err
+	}
+
+	app.closeSurveyIfQuotasMet(*evaluated, counts)
+	app.sendSurveyAlerts(user, *evaluated, nil)
+	return &response, nil
+}
+
+// reserveSurveyResponseAttempt counts a new response of the user if the response limits of the survey allow it.
+// Gives the attempts before the reservation, or nil if the survey has no response limits
+func (app *Application) reserveSurveyResponseAttempt(user *model.User, survey model.Survey) (*model.SurveyResponseAttempts, error) {
+	if survey.ResponseLimits == nil || survey.ResponseLimits.IsEmpty() {
+		return nil, nil
+	}
+	return app.storage.ReserveSurveyResponseAttempt(user, survey.ID, *survey.ResponseLimits, time.Now().UTC())
+}
+
+// reserveSurveyQuotas counts a new response in the quotas of the survey which apply to the user.
+// Gives the reserved quotas and the counts after the reservation
+func (app *Application) reserveSurveyQuotas(user *model.User, survey model.Survey) ([]model.SurveyQuota, *model.SurveyQuotaCounts, error) {
+	if len(survey.Quotas) == 0 {
+		return nil, nil, nil
+	}
+
+	var groupIDs []string
+	for _, quota := range survey.Quotas {
+		if quota.GroupID != nil {
+			var err error
+			groupIDs, err = app.getUserGroupIDs(user)
+			if err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+
+	quotas := []model.SurveyQuota{}
+	for _, quota := range survey.Quotas {
+		if quota.GroupID == nil || containsString(groupIDs, *quota.GroupID) {
+			quotas = append(quotas, quota)
+		}
+	}
+	if len(quotas) == 0 {
+		return nil, nil, nil
+	}
+
+	counts, err := app.storage.ReserveSurveyQuotas(survey, quotas)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quotas, counts, nil
+}
+
+// releaseSurveyQuotas gives back the counts reserved for a response which could not be stored
+func (app *Application) releaseSurveyQuotas(surveyID string, quotas []model.SurveyQuota) {
+	if len(quotas) == 0 {
+		return
+	}
+	err := app.storage.ReleaseSurveyQuotas(surveyID, quotas)
+	if err != nil {
+		log.Printf("Error on Application.releaseSurveyQuotas(%s): %s", surveyID, err)
+	}
+}
+
+// closeSurveyIfQuotasMet closes the survey when an overall quota or all of its quotas are full, and notifies its creator
+func (app *Application) closeSurveyIfQuotasMet(survey model.Survey, counts *model.SurveyQuotaCounts) {
+	if counts == nil {
+		return
+	}
+
+	full := 0
+	overallFull := false
+	for _, quota := range survey.Quotas {
+		if counts.Counts[quota.Key] >= quota.Limit {
+			full++
+			if quota.GroupID == nil {
+				overallFull = true
+			}
+		}
+	}
+	if !overallFull && full < len(survey.Quotas) {
+		return
+	}
+
+	closed, err := app.storage.CloseSurvey(survey.AppID, survey.OrgID, survey.ID)
+	if err != nil {
+		log.Printf("Error on Application.closeSurveyIfQuotasMet(%s): %s", survey.ID, err)
+		return
+	}
+	if !closed {
+		return
+	}
+
+	topic := "surveys"
+	app.notifications.SendNotification(model.NotificationMessage{
+		Message: model.InnerMessage{
+			AppID:      survey.AppID,
+			OrgID:      survey.OrgID,
+			Recipients: []model.UserRef{{UserID: survey.CreatorID}},
+			Sender:     &model.Sender{Type: "system"},
+			Topic:      &topic,
+			Subject:    "Survey closed",
+			Body:       fmt.Sprintf("Survey '%s' has reached its response quotas and is closed.", survey.Title),
+			Data: map[string]string{
+				"type":        "survey",
+				"operation":   "survey_quotas_met",
+				"entity_type": "survey",
+				"entity_id":   survey.ID,
+				"entity_name": survey.Title,
+			},
+		},
+	})
+}
+
+// releaseSurveyResponseAttempt gives back an attempt reserved for a response which could not be stored
+func (app *Application) releaseSurveyResponseAttempt(attempts *model.SurveyResponseAttempts) {
+	if attempts == nil {
+		return
+	}
+	err := app.storage.ReleaseSurveyResponseAttempt(*attempts)
+	if err != nil {
+		log.Printf("Error on Application.releaseSurveyResponseAttempt(%s): %s", attempts.ID, err)
+	}
 }
 
 func (app *Application) updateSurveyResponse(user *model.User, id string, survey model.Survey) error {
-	return app.storage.UpdateSurveyResponse(user, id, survey)
+	stored, err := app.storage.GetSurveyResponse(user, id)
+	if err != nil {
+		return err
+	}
+	if stored.Survey.ID != survey.ID {
+		return fmt.Errorf("error on Application.updateSurveyResponse(%s) - %w", id, model.ErrSurveyResponseSurveyMismatch)
+	}
+	// an update changes a completed response, so it does not count as a new attempt nor in the quotas. The responses in progress
+	// are completed by finalizing them, which checks the response limits and reserves the quotas
+	if stored.Status == model.SurveyResponseStatusInProgress {
+		return fmt.Errorf("error on Application.updateSurveyResponse(%s) - %w", id, model.ErrSurveyResponseInProgress)
+	}
+	err = app.decryptSurveyResponse(stored)
+	if err != nil {
+		return err
+	}
+
+	evaluated, err := app.evaluateSurveyResponse(user, survey)
+	if err != nil {
+		return err
+	}
+	response, err := app.encryptSurveyResponse(model.SurveyResponse{ID: id, UserID: user.Claims.Subject, Survey: *evaluated,
+		Presentation: surveyPresentation(*evaluated, user.Claims.Subject)})
+	if err != nil {
+		return err
+	}
+	err = app.storage.UpdateSurveyResponse(user, response)
+	if err != nil {
+		return err
+	}
+
+	// the alert rules are evaluated again, as the update can change whether the response meets their conditions
+	app.sendSurveyAlerts(user, *evaluated, &stored.Survey)
+	return nil
+}
+
+// evaluateSurveyResponse applies the responses of the user to the stored survey, evaluates its rules and validates the responses,
+// so that the survey definition, the scores and the result can not be forged by clients
+func (app *Application) evaluateSurveyResponse(user *model.User, survey model.Survey) (*model.Survey, error) {
+	stored, err := app.getSurveyToRespond(user, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := applySurveyResponses(stored, survey)
+
+	// the responses to the survey data which the responses do not reach are dropped, as they are not validated
+	reached, err := newSurveyRulesEvaluator(stored).reachedDataKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating survey %s rules - %s", stored.ID, err)
+	}
+	dropUnreachedSurveyResponses(stored, reached)
+
+	keys, err := evaluateSurvey(stored)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating survey %s rules - %s", stored.ID, err)
+	}
+
+	for key, message := range validateSurveyResponses(stored, keys) {
+		invalid[key] = message
+	}
+	if len(invalid) > 0 {
+		return nil, &model.SurveyResponseValidationError{Errors: invalid}
+	}
+	return stored, nil
+}
+
+// getSurveyToRespond gets the stored survey and checks that the user can respond to it
+func (app *Application) getSurveyToRespond(user *model.User, surveyID string) (*model.Survey, error) {
+	stored, err := app.storage.GetSurvey(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if !stored.IsOpen(time.Now().UTC()) {
+		return nil, fmt.Errorf("error on Application.getSurveyToRespond(%s) - %w", stored.ID, model.ErrSurveyNotOpen)
+	}
+	hasAccess, err := app.hasSurveyAccess(user, *stored, false)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, fmt.Errorf("error on Application.getSurveyToRespond(%s) - %w", stored.ID, model.ErrSurveyNotAssigned)
+	}
+	return stored, nil
+}
+
+// applySurveyResponses copies the responses of survey to the stored survey. Gives the response keys which are not part of the stored survey
+func applySurveyResponses(stored *model.Survey, survey model.Survey) map[string]string {
+	invalid := map[string]string{}
+	for key := range survey.Data {
+		if _, ok := stored.Data[key]; !ok {
+			invalid[key] = "not part of the survey"
+		}
+	}
+
+	for key, data := range stored.Data {
+		if responseData, ok := survey.Data[key]; ok {
+			data.Response = responseData.Response
+			stored.Data[key] = data
+		}
+	}
+	if survey.SurveyStats != nil {
+		stored.SurveyStats = &model.SurveyStats{ResponseData: survey.SurveyStats.ResponseData}
+	}
+	return invalid
+}
+
+func (app *Application) getSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error) {
+	response, err := app.storage.GetSurveyResponseProgress(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	err = app.decryptSurveyResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// saveSurveyResponseProgress saves the partial responses of the user to a survey. The responses are not validated until the response is finalized
+func (app *Application) saveSurveyResponseProgress(user *model.User, surveyID string, progress model.SurveyResponseProgress) (*model.SurveyResponse, error) {
+	stored, err := app.getSurveyToRespond(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := app.getSurveyResponseProgress(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the responses saved before are kept unless they are changed, so the clients can save only the last answered questions
+	if current != nil {
+		applySurveyResponses(stored, current.Survey)
+	}
+	invalid := applySurveyResponses(stored, progress.Survey)
+	if progress.CurrentDataKey != nil {
+		if _, ok := stored.Data[*progress.CurrentDataKey]; !ok {
+			invalid[*progress.CurrentDataKey] = "not part of the survey"
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &model.SurveyResponseValidationError{Errors: invalid}
+	}
+
+	now := time.Now().UTC()
+	dateExpires := now.Add(app.surveyProgressExpiration)
+	response := model.SurveyResponse{ID: uuid.NewString(), AppID: user.Claims.AppID, OrgID: user.Claims.OrgID, UserID: user.Claims.Subject,
+		Survey: *stored, SurveyVersionID: stored.VersionID, Status: model.SurveyResponseStatusInProgress, CurrentDataKey: progress.CurrentDataKey,
+		DateExpires: &dateExpires, DateCreated: now, DateUpdated: &now, Presentation: surveyPresentation(*stored, user.Claims.Subject)}
+	response, err = app.encryptSurveyResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	err = app.storage.SaveSurveyResponseProgress(response)
+	if err != nil {
+		return nil, err
+	}
+	return app.getSurveyResponseProgress(user, surveyID)
+}
+
+// finalizeSurveyResponseProgress validates the saved responses of the user to a survey and completes the response
+func (app *Application) finalizeSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error) {
+	response, err := app.getSurveyResponseProgress(user, surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, fmt.Errorf("error on Application.finalizeSurveyResponseProgress(%s) - %w", surveyID, model.ErrSurveyResponseProgressNotFound)
+	}
+
+	evaluated, err := app.evaluateSurveyResponse(user, response.Survey)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := app.reserveSurveyResponseAttempt(user, *evaluated)
+	if err != nil {
+		return nil, err
+	}
+	quotas, counts, err := app.reserveSurveyQuotas(user, *evaluated)
+	if err != nil {
+		app.releaseSurveyResponseAttempt(attempts)
+		return nil, err
+	}
+	completed, err := app.encryptSurveyResponse(model.SurveyResponse{ID: response.ID, UserID: response.UserID, Survey: *evaluated,
+		Presentation: response.Presentation})
+	if err == nil {
+		err = app.storage.CompleteSurveyResponseProgress(user, completed)
+	}
+	if err != nil {
+		app.releaseSurveyResponseAttempt(attempts)
+		app.releaseSurveyQuotas(evaluated.ID, quotas)
+		return nil, err
+	}
+	app.closeSurveyIfQuotasMet(*evaluated, counts)
+	app.sendSurveyAlerts(user, *evaluated, nil)
+
+	now := time.Now().UTC()
+	response.Survey = *evaluated
+	response.SurveyVersionID = evaluated.VersionID
+	response.Status = model.SurveyResponseStatusCompleted
+	response.CurrentDataKey = nil
+	response.DateExpires = nil
+	response.DateUpdated = &now
+	return response, nil
 }
 
 func (app *Application) deleteSurveyResponse(user *model.User, id string) error {
@@ -448,6 +1251,11 @@ func (app *Application) getUserData(user *model.User) (*model.UserDataResponse,
 			errChan <- err
 			return
 		}
+		err = app.decryptSurveyResponses(sr)
+		if err != nil {
+			errChan <- err
+			return
+		}
 		surveyResponse = sr
 	}()
 
@@ -470,3 +1278,19 @@ func (app *Application) getUserData(user *model.User) (*model.UserDataResponse,
 
 	return &userResponse, nil
 }
+
+// getHealth gives the health of the service. It is degraded when a change stream watcher failed, and starting
+// when a change stream watcher did not connect yet
+func (app *Application) getHealth() model.Health {
+	health := model.Health{Status: model.HealthStatusOK, ChangeStreams: app.storage.GetChangeStreamsStatus()}
+	for _, changeStream := range health.ChangeStreams {
+		if changeStream.Starting {
+			if health.Status == model.HealthStatusOK {
+				health.Status = model.HealthStatusStarting
+			}
+		} else if !changeStream.Healthy {
+			health.Status = model.HealthStatusDegraded
+		}
+	}
+	return health
+}
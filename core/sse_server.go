@@ -14,7 +14,10 @@
 
 package core
 
-import "polls/core/model"
+import (
+	"polls/core/model"
+	"sync"
+)
 
 // SSEClient struct
 type SSEClient struct {
@@ -23,14 +26,26 @@ type SSEClient struct {
 	resultChan chan map[string]interface{}
 }
 
+// SurveyStatsClient struct
+type SurveyStatsClient struct {
+	surveyID   string
+	userID     string
+	resultChan chan map[string]interface{}
+}
+
 // SSEServer struct
 type SSEServer struct {
 	PollClientsMapping map[string][]SSEClient
+
+	surveyStatsLock           sync.RWMutex
+	SurveyStatsClientsMapping map[string][]SurveyStatsClient
+	surveyStatsSurveys        map[string]model.Survey
 }
 
 // NewSSEServer new instance
 func NewSSEServer() *SSEServer {
-	return &SSEServer{PollClientsMapping: map[string][]SSEClient{}}
+	return &SSEServer{PollClientsMapping: map[string][]SSEClient{}, SurveyStatsClientsMapping: map[string][]SurveyStatsClient{},
+		surveyStatsSurveys: map[string]model.Survey{}}
 }
 
 // RegisterUserForPoll registers a user for a poll updates
@@ -105,3 +120,89 @@ func (s *SSEServer) NotifyPollUpdate(pollID string, poll model.PollNotification)
 		}
 	}
 }
+
+// RegisterUserForSurveyStats registers a user for a survey stats updates
+func (s *SSEServer) RegisterUserForSurveyStats(userID string, survey model.Survey, resultChan chan map[string]interface{}) {
+	s.surveyStatsLock.Lock()
+	defer s.surveyStatsLock.Unlock()
+
+	s.SurveyStatsClientsMapping[survey.ID] = append(s.SurveyStatsClientsMapping[survey.ID], SurveyStatsClient{surveyID: survey.ID, userID: userID, resultChan: resultChan})
+	s.surveyStatsSurveys[survey.ID] = survey
+}
+
+// UnregisterUserForSurveyStats unregisters the subscription of a user for a survey stats updates
+func (s *SSEServer) UnregisterUserForSurveyStats(surveyID string, resultChan chan map[string]interface{}) {
+	s.surveyStatsLock.Lock()
+	defer s.surveyStatsLock.Unlock()
+
+	var newList []SurveyStatsClient
+	for _, client := range s.SurveyStatsClientsMapping[surveyID] {
+		if client.resultChan != resultChan {
+			newList = append(newList, client)
+		}
+	}
+
+	if len(newList) > 0 {
+		s.SurveyStatsClientsMapping[surveyID] = newList
+	} else {
+		delete(s.SurveyStatsClientsMapping, surveyID)
+		delete(s.surveyStatsSurveys, surveyID)
+	}
+}
+
+// UpdateSurveyForStats replaces the survey which stats are observed after the survey was updated. Gives false if nobody observes
+// the survey stats
+func (s *SSEServer) UpdateSurveyForStats(survey model.Survey) bool {
+	s.surveyStatsLock.Lock()
+	defer s.surveyStatsLock.Unlock()
+
+	if _, ok := s.surveyStatsSurveys[survey.ID]; !ok {
+		return false
+	}
+	s.surveyStatsSurveys[survey.ID] = survey
+	return true
+}
+
+// GetSurveyForStats gives the survey which stats are observed. Gives nil if nobody observes the survey stats
+func (s *SSEServer) GetSurveyForStats(surveyID string) *model.Survey {
+	s.surveyStatsLock.RLock()
+	defer s.surveyStatsLock.RUnlock()
+
+	if survey, ok := s.surveyStatsSurveys[surveyID]; ok {
+		return &survey
+	}
+	return nil
+}
+
+// NotifySurveyStatsUpdate notifies all subscribers for changed survey stats. Stale updates are dropped for the slow subscribers
+func (s *SSEServer) NotifySurveyStatsUpdate(surveyID string, stats model.SurveyLiveStats) {
+	s.surveyStatsLock.RLock()
+	defer s.surveyStatsLock.RUnlock()
+
+	for _, client := range s.SurveyStatsClientsMapping[surveyID] {
+		notifySurveyStatsClient(client.resultChan, surveyID, stats)
+	}
+}
+
+// NotifySurveyStatsClient notifies a single subscriber for the survey stats, e.g. with the current stats when it subscribes
+func (s *SSEServer) NotifySurveyStatsClient(surveyID string, resultChan chan map[string]interface{}, stats model.SurveyLiveStats) {
+	s.surveyStatsLock.RLock()
+	defer s.surveyStatsLock.RUnlock()
+
+	for _, client := range s.SurveyStatsClientsMapping[surveyID] {
+		if client.resultChan == resultChan {
+			notifySurveyStatsClient(client.resultChan, surveyID, stats)
+		}
+	}
+}
+
+func notifySurveyStatsClient(resultChan chan map[string]interface{}, surveyID string, stats model.SurveyLiveStats) {
+	select {
+	case resultChan <- map[string]interface{}{
+		"survey_id":  surveyID,
+		"event_type": "survey_stats_updated",
+		"stats":      stats,
+	}:
+	default:
+	}
+}
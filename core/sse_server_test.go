@@ -0,0 +1,89 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"testing"
+)
+
+func statsTestApplication() *Application {
+	storage := newTestStorage()
+	storage.surveys["survey1"] = model.Survey{ID: "survey1", CreatorID: "creator", Title: "title", Status: model.SurveyStatusPublished,
+		VersionID: "version1", Version: 1, Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"}}}
+	storage.responseCounts["survey1"] = model.SurveyResponseCounts{Total: 3, Answered: map[string]int{"q1": 3}}
+	return &Application{storage: storage, sseServer: NewSSEServer(), surveyStatsPending: map[string]bool{}}
+}
+
+func TestSubscribeToSurveyStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		wantErr bool
+	}{
+		{"creator", "creator", false},
+		{"not the creator", "respondent", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := statsTestApplication()
+			first := make(chan map[string]interface{}, 1)
+			if err := app.subscribeToSurveyStats(newTestUser("creator"), "survey1", first); err != nil {
+				t.Fatalf("subscribeToSurveyStats() error = %v", err)
+			}
+			<-first
+
+			second := make(chan map[string]interface{}, 1)
+			err := app.subscribeToSurveyStats(newTestUser(tt.user), "survey1", second)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("subscribeToSurveyStats() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(first) != 0 {
+				t.Errorf("subscribeToSurveyStats() sent the snapshot to the other subscribers")
+			}
+			if tt.wantErr {
+				return
+			}
+			select {
+			case event := <-second:
+				if stats, _ := event["stats"].(model.SurveyLiveStats); stats.Total != 3 {
+					t.Errorf("subscribeToSurveyStats() snapshot = %+v, want the current stats", event)
+				}
+			default:
+				t.Errorf("subscribeToSurveyStats() did not send the snapshot to the new subscriber")
+			}
+		})
+	}
+}
+
+func TestSaveSurveyRevisionRefreshesSurveyStats(t *testing.T) {
+	app := statsTestApplication()
+	user := newTestUser("creator")
+	if err := app.subscribeToSurveyStats(user, "survey1", make(chan map[string]interface{}, 1)); err != nil {
+		t.Fatalf("subscribeToSurveyStats() error = %v", err)
+	}
+
+	survey := model.Survey{Title: "title", Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"},
+		"q2": {Type: surveyDataTypeText, Text: "Anything else?"}}}
+	if err := app.updateSurvey(user, survey, "survey1", false); err != nil {
+		t.Fatalf("updateSurvey() error = %v", err)
+	}
+
+	observed := app.sseServer.GetSurveyForStats("survey1")
+	if observed == nil || observed.Version != 2 || len(observed.Data) != 2 {
+		t.Errorf("GetSurveyForStats() = %+v, want the updated survey", observed)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"polls/core/model"
+	"sort"
+	"time"
+
+	"github.com/rokwire/core-auth-library-go/v3/tokenauth"
+)
+
+// testStorage is an in memory storage for the unit tests. The storage methods which the tests do not use panic
+type testStorage struct {
+	Storage
+
+	surveys        map[string]model.Survey
+	questions      map[string]model.SurveyQuestion
+	versions       map[string]model.SurveyVersion
+	responses      map[string]model.SurveyResponse
+	responseCounts map[string]model.SurveyResponseCounts
+	attempts       map[string]model.SurveyResponseAttempts
+	quotaCounts    map[string]map[string]int
+	closed         map[string]bool
+	templates      map[string]model.SurveyTemplate
+
+	failResponses bool // the responses can not be stored
+}
+
+func newTestStorage() *testStorage {
+	return &testStorage{surveys: map[string]model.Survey{}, questions: map[string]model.SurveyQuestion{}, versions: map[string]model.SurveyVersion{},
+		responses: map[string]model.SurveyResponse{}, responseCounts: map[string]model.SurveyResponseCounts{}, attempts: map[string]model.SurveyResponseAttempts{},
+		quotaCounts: map[string]map[string]int{}, closed: map[string]bool{},
+		templates: map[string]model.SurveyTemplate{}}
+}
+
+func newTestUser(subject string) *model.User {
+	user := &model.User{Claims: tokenauth.Claims{AppID: "app1", OrgID: "org1"}}
+	user.Claims.Subject = subject
+	return user
+}
+
+func (s *testStorage) GetSurvey(user *model.User, id string) (*model.Survey, error) {
+	survey, ok := s.surveys[id]
+	if !ok {
+		return nil, fmt.Errorf("survey %s not found", id)
+	}
+	// the stored data must not change through the returned survey
+	data := make(map[string]model.SurveyData, len(survey.Data))
+	for key, item := range survey.Data {
+		data[key] = item
+	}
+	survey.Data = data
+	return &survey, nil
+}
+
+func (s *testStorage) GetSurveyQuestionsByIDs(appID string, orgID string, ids []string) ([]model.SurveyQuestion, error) {
+	questions := []model.SurveyQuestion{}
+	for _, id := range ids {
+		if question, ok := s.questions[id]; ok {
+			questions = append(questions, question)
+		}
+	}
+	return questions, nil
+}
+
+func (s *testStorage) UpdateSurvey(user *model.User, survey model.Survey, version int, admin bool) error {
+	stored, ok := s.surveys[survey.ID]
+	if !ok || stored.Version != version || (!admin && stored.CreatorID != user.Claims.Subject) {
+		return fmt.Errorf("survey %s invalid id or version", survey.ID)
+	}
+	s.surveys[survey.ID] = survey
+	return nil
+}
+
+func (s *testStorage) CreateSurveyVersion(version model.SurveyVersion) error {
+	if _, ok := s.versions[version.ID]; ok {
+		return fmt.Errorf("survey version %s exists", version.ID)
+	}
+	s.versions[version.ID] = version
+	return nil
+}
+
+func (s *testStorage) DeleteSurveyVersion(appID string, orgID string, id string) error {
+	delete(s.versions, id)
+	return nil
+}
+
+func (s *testStorage) GetSurveyResponseCounts(appID string, orgID string, surveyID string) (*model.SurveyResponseCounts, error) {
+	counts := s.responseCounts[surveyID]
+	return &counts, nil
+}
+
+// surveyResponses gives the responses to a survey ordered by id
+func (s *testStorage) surveyResponses(surveyID string) []model.SurveyResponse {
+	responses := []model.SurveyResponse{}
+	for _, response := range s.responses {
+		if response.Survey.ID == surveyID {
+			responses = append(responses, response)
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].ID < responses[j].ID })
+	return responses
+}
+
+func (s *testStorage) CountSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (int, error) {
+	return len(s.surveyResponses(surveyID)), nil
+}
+
+func (s *testStorage) StreamSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter,
+	handler func(surveyResponse model.SurveyResponse) error) error {
+	for _, response := range s.surveyResponses(surveyID) {
+		err := handler(response)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testStorage) GetSurveyVersion(appID string, orgID string, surveyID string, id string) (*model.SurveyVersion, error) {
+	version, ok := s.versions[id]
+	if !ok || version.SurveyID != surveyID {
+		return nil, fmt.Errorf("survey version %s not found", id)
+	}
+	return &version, nil
+}
+
+func (s *testStorage) GetSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error) {
+	response, ok := s.responses[id]
+	if !ok || response.UserID != user.Claims.Subject {
+		return nil, fmt.Errorf("survey response %s not found", id)
+	}
+	return &response, nil
+}
+
+func (s *testStorage) CreateSurveyResponse(surveyResponse model.SurveyResponse) (*model.SurveyResponse, error) {
+	if s.failResponses {
+		return nil, errors.New("the response can not be stored")
+	}
+	s.responses[surveyResponse.ID] = surveyResponse
+	return &surveyResponse, nil
+}
+
+func (s *testStorage) UpdateSurveyResponse(user *model.User, surveyResponse model.SurveyResponse) error {
+	stored, ok := s.responses[surveyResponse.ID]
+	if !ok || stored.UserID != user.Claims.Subject {
+		return fmt.Errorf("survey response %s not found", surveyResponse.ID)
+	}
+	stored.Survey = surveyResponse.Survey
+	stored.Presentation = surveyResponse.Presentation
+	s.responses[surveyResponse.ID] = stored
+	return nil
+}
+
+func (s *testStorage) ReserveSurveyResponseAttempt(user *model.User, surveyID string, limits model.SurveyResponseLimits, now time.Time) (*model.SurveyResponseAttempts, error) {
+	id := fmt.Sprintf("%s_%s", surveyID, user.Claims.Subject)
+	previous, ok := s.attempts[id]
+	if !ok {
+		previous = model.SurveyResponseAttempts{ID: id, SurveyID: surveyID, UserID: user.Claims.Subject}
+	}
+	if limits.MaxAttempts != nil && previous.Count >= *limits.MaxAttempts {
+		return nil, model.ErrSurveyResponseLimitReached
+	}
+	if limits.CooldownSeconds != nil && previous.DateLastAttempt != nil &&
+		previous.DateLastAttempt.After(now.Add(-time.Duration(*limits.CooldownSeconds)*time.Second)) {
+		return nil, model.ErrSurveyResponseLimitReached
+	}
+
+	reserved := previous
+	reserved.Count++
+	reserved.DateLastAttempt = &now
+	s.attempts[id] = reserved
+	return &previous, nil
+}
+
+func (s *testStorage) ReleaseSurveyResponseAttempt(previous model.SurveyResponseAttempts) error {
+	s.attempts[previous.ID] = previous
+	return nil
+}
+
+func (s *testStorage) ReserveSurveyQuotas(survey model.Survey, quotas []model.SurveyQuota) (*model.SurveyQuotaCounts, error) {
+	counts, ok := s.quotaCounts[survey.ID]
+	if !ok {
+		counts = map[string]int{}
+		s.quotaCounts[survey.ID] = counts
+	}
+	for _, quota := range quotas {
+		if counts[quota.Key] >= quota.Limit {
+			return nil, model.ErrSurveyQuotaReached
+		}
+	}
+	for _, quota := range quotas {
+		counts[quota.Key]++
+	}
+
+	reserved := make(map[string]int, len(counts))
+	for key, count := range counts {
+		reserved[key] = count
+	}
+	return &model.SurveyQuotaCounts{ID: survey.ID, Counts: reserved}, nil
+}
+
+func (s *testStorage) ReleaseSurveyQuotas(surveyID string, quotas []model.SurveyQuota) error {
+	for _, quota := range quotas {
+		s.quotaCounts[surveyID][quota.Key]--
+	}
+	return nil
+}
+
+func (s *testStorage) CloseSurvey(appID string, orgID string, id string) (bool, error) {
+	if s.closed[id] {
+		return false, nil
+	}
+	s.closed[id] = true
+	return true, nil
+}
+
+func (s *testStorage) GetSurveyResponseAggregates(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (*model.SurveyResponseAggregates, error) {
+	aggregates := model.SurveyResponseAggregates{Answered: map[string]int{}, Values: map[string][]model.SurveyResponseValueCount{}}
+	for _, response := range s.surveyResponses(surveyID) {
+		if response.Status == model.SurveyResponseStatusInProgress {
+			aggregates.InProgress++
+			continue
+		}
+		aggregates.Completed++
+		for key, data := range response.Survey.Data {
+			if data.Response != nil {
+				aggregates.Answered[key]++
+				aggregates.Values[key] = append(aggregates.Values[key], model.SurveyResponseValueCount{Value: data.Response, Count: 1})
+			}
+		}
+	}
+	return &aggregates, nil
+}
+
+func (s *testStorage) CreateSurvey(survey model.Survey) (*model.Survey, error) {
+	s.surveys[survey.ID] = survey
+	return &survey, nil
+}
+
+func (s *testStorage) GetSurveyTemplate(appID string, orgID string, id string) (*model.SurveyTemplate, error) {
+	template, ok := s.templates[id]
+	if !ok || template.AppID != appID || template.OrgID != orgID {
+		return nil, fmt.Errorf("survey template %s not found", id)
+	}
+	return &template, nil
+}
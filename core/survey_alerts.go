@@ -0,0 +1,155 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"polls/core/model"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// surveyAlertPlaceholder matches the {{<key>}} placeholders of the alert messages
+var surveyAlertPlaceholder = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// surveyAlerts gives the alerts of the alert rules which conditions are met by an evaluated survey response,
+// with the placeholders of their messages replaced by the values of the response. When the response updates a previous
+// response, the alert rules which conditions were already met by the previous response are not alerted again
+func surveyAlerts(survey model.Survey, previous *model.Survey) ([]model.SurveyAlert, error) {
+	if len(survey.AlertRules) == 0 {
+		return nil, nil
+	}
+
+	e, matched, err := matchSurveyAlertRules(survey)
+	if err != nil {
+		return nil, err
+	}
+	previouslyMatched := make([]bool, len(survey.AlertRules))
+	if previous != nil {
+		previousSurvey := *previous
+		previousSurvey.AlertRules = survey.AlertRules
+		_, previouslyMatched, err = matchSurveyAlertRules(previousSurvey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	alerts := []model.SurveyAlert{}
+	for i, rule := range survey.AlertRules {
+		if !matched[i] || previouslyMatched[i] {
+			continue
+		}
+		alerts = append(alerts, model.SurveyAlert{ContactKey: rule.ContactKey, Content: map[string]interface{}{
+			"subject": e.renderAlertText(rule.Subject),
+			"body":    e.renderAlertText(rule.Body),
+		}})
+	}
+	return alerts, nil
+}
+
+// matchSurveyAlertRules evaluates the conditions of the alert rules of a survey response. Gives the evaluator of the response
+// and whether each alert rule condition is met
+func matchSurveyAlertRules(survey model.Survey) (*surveyRulesEvaluator, []bool, error) {
+	e := newSurveyRulesEvaluator(&survey)
+	keys, err := e.reachedDataKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range keys {
+		score, err := e.dataScore(key, survey.Data[key])
+		if err != nil {
+			return nil, nil, err
+		}
+		if score != nil {
+			e.scores[key] = *score
+		}
+	}
+
+	matched := make([]bool, len(survey.AlertRules))
+	for i, rule := range survey.AlertRules {
+		var condition interface{}
+		err = json.Unmarshal([]byte(rule.Condition), &condition)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing alert rule %d condition - %s", i, err)
+		}
+		matched[i], err = e.evaluateCondition(condition)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error evaluating alert rule %d condition - %s", i, err)
+		}
+	}
+	return e, matched, nil
+}
+
+// isSensitiveAlertPlaceholder checks if an alert message placeholder gives the responses of the survey data, which the alerts
+// of the sensitive surveys must not contain
+func isSensitiveAlertPlaceholder(key string) bool {
+	return strings.HasPrefix(key, "data.")
+}
+
+// renderAlertText replaces the placeholders of an alert message with the values of the survey keys. The placeholders of the
+// survey data are left empty for the sensitive surveys, as the alerts are sent in plain text
+func (e *surveyRulesEvaluator) renderAlertText(text string) string {
+	return surveyAlertPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		key := surveyAlertPlaceholder.FindStringSubmatch(placeholder)[1]
+		if e.survey.Sensitive && isSensitiveAlertPlaceholder(key) {
+			return ""
+		}
+		var value interface{}
+		switch key {
+		case "survey.id":
+			value = e.survey.ID
+		case "survey.title":
+			value = e.survey.Title
+		case "survey.result":
+			value = e.survey.ResultJSON
+		default:
+			value, _ = e.property(key)
+		}
+
+		switch item := value.(type) {
+		case nil:
+			return ""
+		case string:
+			return item
+		case float64:
+			return strconv.FormatFloat(item, 'f', -1, 64)
+		default:
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return ""
+			}
+			return string(encoded)
+		}
+	})
+}
+
+// sendSurveyAlerts sends the alerts which a completed survey response triggers. The previous survey response is given when
+// the response is updated, so that the alerts already sent for it are not sent again. A failed alert does not fail the response
+func (app *Application) sendSurveyAlerts(user *model.User, survey model.Survey, previous *model.Survey) {
+	alerts, err := surveyAlerts(survey, previous)
+	if err != nil {
+		log.Printf("Error on Application.sendSurveyAlerts(%s): %s", survey.ID, err)
+		return
+	}
+	for _, alert := range alerts {
+		err = app.createSurveyAlert(user, alert)
+		if err != nil {
+			log.Printf("Error on Application.sendSurveyAlerts(%s): %s", survey.ID, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+func alertsTestSurvey(mood interface{}, sleep interface{}, sensitive bool) model.Survey {
+	return model.Survey{
+		ID:        "survey1",
+		Title:     "Wellness",
+		Sensitive: sensitive,
+		Data: map[string]model.SurveyData{
+			"mood":  {Type: surveyDataTypeNumeric, Response: mood},
+			"sleep": {Type: surveyDataTypeNumeric, Response: sleep},
+		},
+		AlertRules: []model.SurveyAlertRule{
+			{ContactKey: "counselor", Condition: `{"operator": "<=", "data_key": "data.mood", "compare_to": 2}`,
+				Subject: "Low mood in {{survey.title}}", Body: "Mood {{data.mood}} for {{ survey.id }}"},
+			{ContactKey: "nurse", Condition: `{"operator": "<", "data_key": "data.sleep", "compare_to": 5}`,
+				Subject: "Low sleep", Body: "Sleep {{data.sleep.response}} {{unknown.key}}"},
+		},
+	}
+}
+
+func TestSurveyAlerts(t *testing.T) {
+	moodAlert := model.SurveyAlert{ContactKey: "counselor", Content: map[string]interface{}{"subject": "Low mood in Wellness", "body": "Mood 1 for survey1"}}
+	sleepAlert := model.SurveyAlert{ContactKey: "nurse", Content: map[string]interface{}{"subject": "Low sleep", "body": "Sleep 4 "}}
+
+	tests := []struct {
+		name     string
+		survey   model.Survey
+		previous *model.Survey
+		want     []model.SurveyAlert
+	}{
+		{"no rule met", alertsTestSurvey(5.0, 8.0, false), nil, []model.SurveyAlert{}},
+		{"one rule met", alertsTestSurvey(1.0, 8.0, false), nil, []model.SurveyAlert{moodAlert}},
+		{"all rules met", alertsTestSurvey(1.0, 4.0, false), nil, []model.SurveyAlert{moodAlert, sleepAlert}},
+		{"no response", alertsTestSurvey(nil, nil, false), nil, []model.SurveyAlert{}},
+		{"rule newly met by an update", alertsTestSurvey(1.0, 8.0, false), surveyPtr(alertsTestSurvey(5.0, 8.0, false)), []model.SurveyAlert{moodAlert}},
+		{"rule already met before an update", alertsTestSurvey(1.0, 4.0, false), surveyPtr(alertsTestSurvey(2.0, 8.0, false)), []model.SurveyAlert{sleepAlert}},
+		{"rule no longer met after an update", alertsTestSurvey(5.0, 8.0, false), surveyPtr(alertsTestSurvey(1.0, 8.0, false)), []model.SurveyAlert{}},
+		{"sensitive survey", alertsTestSurvey(1.0, 8.0, true), nil, []model.SurveyAlert{{ContactKey: "counselor",
+			Content: map[string]interface{}{"subject": "Low mood in Wellness", "body": "Mood  for survey1"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := surveyAlerts(tt.survey, tt.previous)
+			if err != nil {
+				t.Fatalf("surveyAlerts() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("surveyAlerts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSurveyAlertsInvalidCondition(t *testing.T) {
+	survey := alertsTestSurvey(1.0, 8.0, false)
+	survey.AlertRules[1].Condition = `{"operator": `
+	if _, err := surveyAlerts(survey, nil); err == nil {
+		t.Errorf("surveyAlerts() error = nil, want an error")
+	}
+}
+
+func TestLintSurveyAlertRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      model.SurveyAlertRule
+		sensitive bool
+		wantErrs  int
+	}{
+		{"valid", model.SurveyAlertRule{ContactKey: "c", Condition: `{"operator": "==", "data_key": "data.q", "compare_to": 1}`, Subject: "s", Body: "{{data.q}}"}, false, 0},
+		{"missing contact and body", model.SurveyAlertRule{Condition: `{"operator": "==", "data_key": "data.q", "compare_to": 1}`, Subject: "s"}, false, 2},
+		{"invalid condition", model.SurveyAlertRule{ContactKey: "c", Condition: `[1]`, Subject: "s", Body: "b"}, false, 1},
+		{"response placeholder of a sensitive survey", model.SurveyAlertRule{ContactKey: "c", Condition: `{"operator": "==", "data_key": "data.q", "compare_to": 1}`,
+			Subject: "{{data.q}}", Body: "{{ data.q.response }} in {{survey.title}}"}, true, 2},
+		{"survey placeholders of a sensitive survey", model.SurveyAlertRule{ContactKey: "c", Condition: `{"operator": "==", "data_key": "data.q", "compare_to": 1}`,
+			Subject: "s", Body: "{{survey.title}}"}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lint := lintSurvey(model.Survey{Sensitive: tt.sensitive, AlertRules: []model.SurveyAlertRule{tt.rule}})
+			if len(lint.Issues) != tt.wantErrs {
+				t.Errorf("lintSurvey() = %+v, want %d errors", lint.Issues, tt.wantErrs)
+			}
+			for _, issue := range lint.Issues {
+				if issue.Type != surveyLintInvalidAlertRule {
+					t.Errorf("lintSurvey() issue type = %s, want %s", issue.Type, surveyLintInvalidAlertRule)
+				}
+			}
+		})
+	}
+}
+
+func surveyPtr(survey model.Survey) *model.Survey {
+	return &survey
+}
@@ -0,0 +1,301 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"polls/core/model"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// exportSurveyBundle gives the portable definition of a survey to its creator or the admins
+func (app *Application) exportSurveyBundle(user *model.User, id string, admin bool) (*model.SurveyBundle, error) {
+	survey, err := app.storage.GetSurvey(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && survey.CreatorID != user.Claims.Subject {
+		return nil, fmt.Errorf("error on Application.exportSurveyBundle(%s) - %w", id, model.ErrNotSurveyCreator)
+	}
+
+	bundle := model.NewSurveyBundle(*survey, time.Now().UTC())
+	return &bundle, nil
+}
+
+// importSurveyBundle creates a draft survey from a survey bundle. The survey gets new ids and the tenant of the user
+func (app *Application) importSurveyBundle(user *model.User, bundle model.SurveyBundle, admin bool) (*model.Survey, error) {
+	if bundle.SchemaVersion < 1 || bundle.SchemaVersion > model.SurveyBundleSchemaVersion {
+		return nil, &model.SurveyImportError{Errors: map[string]string{
+			"schema_version": fmt.Sprintf("schema version %d is not supported, the supported versions are 1 to %d", bundle.SchemaVersion, model.SurveyBundleSchemaVersion)}}
+	}
+
+	survey := bundle.ToSurvey()
+	survey.Status = model.SurveyStatusDraft
+	return app.createSurvey(user, survey, admin)
+}
+
+// importSurveyJS creates a draft survey from a SurveyJS survey JSON
+func (app *Application) importSurveyJS(user *model.User, data []byte, admin bool) (*model.Survey, error) {
+	survey, err := surveyFromSurveyJS(data)
+	if err != nil {
+		return nil, err
+	}
+
+	survey.Status = model.SurveyStatusDraft
+	return app.createSurvey(user, *survey, admin)
+}
+
+// surveyJSSurvey is the part of a SurveyJS survey JSON which can be mapped to a survey.
+// The texts are strings or objects of localized strings
+type surveyJSSurvey struct {
+	Title       interface{}       `json:"title"`
+	Description interface{}       `json:"description"`
+	Pages       []surveyJSElement `json:"pages"`
+	Elements    []surveyJSElement `json:"elements"`
+	Questions   []surveyJSElement `json:"questions"`
+}
+
+// surveyJSElement is a SurveyJS page, panel or question
+type surveyJSElement struct {
+	Type        string            `json:"type"`
+	Name        string            `json:"name"`
+	Title       interface{}       `json:"title"`
+	Description interface{}       `json:"description"`
+	IsRequired  bool              `json:"isRequired"`
+	InputType   string            `json:"inputType"`
+	Choices     []interface{}     `json:"choices"`
+	Min         interface{}       `json:"min"`
+	Max         interface{}       `json:"max"`
+	MaxLength   *int              `json:"maxLength"`
+	RateMin     *float64          `json:"rateMin"`
+	RateMax     *float64          `json:"rateMax"`
+	RateValues  []interface{}     `json:"rateValues"`
+	LabelTrue   interface{}       `json:"labelTrue"`
+	LabelFalse  interface{}       `json:"labelFalse"`
+	Elements    []surveyJSElement `json:"elements"`
+	Questions   []surveyJSElement `json:"questions"`
+}
+
+// the SurveyJS elements which only display content, so they are not imported
+var surveyJSSkippedTypes = map[string]bool{"html": true, "image": true, "expression": true}
+
+// surveyJSImport converts the SurveyJS elements to survey data and collects the elements which can not be converted
+type surveyJSImport struct {
+	data   map[string]model.SurveyData
+	errors map[string]string
+}
+
+// surveyFromSurveyJS maps a SurveyJS survey JSON to a survey. Every SurveyJS page becomes a page with its questions,
+// and the pages follow each other in their order. The panels are flattened into their pages
+func surveyFromSurveyJS(data []byte) (*model.Survey, error) {
+	var surveyJS surveyJSSurvey
+	err := json.Unmarshal(data, &surveyJS)
+	if err != nil {
+		return nil, &model.SurveyImportError{Errors: map[string]string{"survey": fmt.Sprintf("invalid SurveyJS JSON - %s", err)}}
+	}
+
+	pages := surveyJS.Pages
+	if len(pages) == 0 {
+		pages = []surveyJSElement{{Elements: append(surveyJS.Elements, surveyJS.Questions...)}}
+	}
+
+	i := surveyJSImport{data: map[string]model.SurveyData{}, errors: map[string]string{}}
+	pageKeys := make([]string, len(pages))
+	for index, page := range pages {
+		pageKeys[index] = page.Name
+		if len(page.Name) == 0 {
+			pageKeys[index] = fmt.Sprintf("page%d", index+1)
+		}
+	}
+	for index, page := range pages {
+		pageData := model.SurveyData{Type: surveyDataTypePage, Text: surveyJSText(page.Title), MoreInfo: surveyJSText(page.Description),
+			AllowSkip: true, DataKeys: i.addElements(pageKeys[index], append(page.Elements, page.Questions...))}
+		if index+1 < len(pages) {
+			pageData.DefaultFollowUpKey = &pageKeys[index+1]
+		}
+		i.add(pageKeys[index], pageData)
+	}
+	if len(i.data) == len(pages) && len(i.errors) == 0 {
+		i.errors["pages"] = "the survey has no questions"
+	}
+	if len(i.errors) > 0 {
+		return nil, &model.SurveyImportError{Errors: i.errors}
+	}
+
+	survey := model.Survey{Title: surveyJSText(surveyJS.Title), Data: i.data, DefaultDataKey: &pageKeys[0]}
+	if description := surveyJSText(surveyJS.Description); len(description) > 0 {
+		survey.MoreInfo = &description
+	}
+	return &survey, nil
+}
+
+func (i *surveyJSImport) add(key string, data model.SurveyData) {
+	if _, ok := i.data[key]; ok {
+		i.errors[key] = "the name is used more than once"
+		return
+	}
+	i.data[key] = data
+}
+
+// addElements converts the questions of a page or a panel and gives their keys
+func (i *surveyJSImport) addElements(parentKey string, elements []surveyJSElement) []string {
+	keys := []string{}
+	for index, element := range elements {
+		if surveyJSSkippedTypes[element.Type] {
+			continue
+		}
+		if element.Type == "panel" {
+			keys = append(keys, i.addElements(parentKey, append(element.Elements, element.Questions...))...)
+			continue
+		}
+		if len(element.Name) == 0 {
+			i.errors[fmt.Sprintf("%s.elements[%d]", parentKey, index)] = "the question has no name"
+			continue
+		}
+
+		data, message := surveyJSQuestion(element)
+		if len(message) > 0 {
+			i.errors[element.Name] = message
+			continue
+		}
+		i.add(element.Name, data)
+		keys = append(keys, element.Name)
+	}
+	return keys
+}
+
+// surveyJSQuestion maps a SurveyJS question to the survey data type which accepts the same responses
+func surveyJSQuestion(element surveyJSElement) (model.SurveyData, string) {
+	data := model.SurveyData{Text: surveyJSText(element.Title), MoreInfo: surveyJSText(element.Description), AllowSkip: !element.IsRequired}
+	if len(data.Text) == 0 {
+		data.Text = element.Name
+	}
+
+	switch element.Type {
+	case "text":
+		switch element.InputType {
+		case "number", "range":
+			data.Type = surveyDataTypeNumeric
+			data.Minimum = surveyJSNumber(element.Min)
+			data.Maximum = surveyJSNumber(element.Max)
+		case "date", "datetime-local":
+			askTime := element.InputType == "datetime-local"
+			data.Type = surveyDataTypeDateTime
+			data.AskTime = &askTime
+		default:
+			data.Type = surveyDataTypeText
+			data.MaxLength = element.MaxLength
+		}
+	case "comment":
+		data.Type = surveyDataTypeText
+		data.MaxLength = element.MaxLength
+	case "radiogroup", "dropdown":
+		data.Type = surveyDataTypeMultipleChoice
+		data.Options = surveyJSOptions(element.Choices)
+	case "checkbox", "tagbox":
+		allowMultiple := true
+		data.Type = surveyDataTypeMultipleChoice
+		data.Options = surveyJSOptions(element.Choices)
+		data.AllowMultiple = &allowMultiple
+	case "boolean":
+		labelTrue, labelFalse := surveyJSText(element.LabelTrue), surveyJSText(element.LabelFalse)
+		if len(labelTrue) == 0 {
+			labelTrue = "Yes"
+		}
+		if len(labelFalse) == 0 {
+			labelFalse = "No"
+		}
+		data.Type = surveyDataTypeTrueFalse
+		data.Options = []model.OptionData{{Title: labelTrue, Value: true}, {Title: labelFalse, Value: false}}
+	case "rating":
+		if len(element.RateValues) > 0 {
+			data.Type = surveyDataTypeMultipleChoice
+			data.Options = surveyJSOptions(element.RateValues)
+			break
+		}
+		minimum, maximum, wholeNum := 1.0, 5.0, true
+		if element.RateMin != nil {
+			minimum = *element.RateMin
+		}
+		if element.RateMax != nil {
+			maximum = *element.RateMax
+		}
+		data.Type = surveyDataTypeNumeric
+		data.Minimum = &minimum
+		data.Maximum = &maximum
+		data.WholeNum = &wholeNum
+	default:
+		return data, fmt.Sprintf("the question type %s is not supported", element.Type)
+	}
+	return data, ""
+}
+
+// surveyJSOptions maps SurveyJS choices, which are values or objects with a value and a text
+func surveyJSOptions(choices []interface{}) []model.OptionData {
+	options := make([]model.OptionData, len(choices))
+	for index, choice := range choices {
+		if item, ok := choice.(map[string]interface{}); ok {
+			options[index] = model.OptionData{Title: surveyJSText(item["text"]), Value: item["value"]}
+			if len(options[index].Title) == 0 {
+				options[index].Title = fmt.Sprint(item["value"])
+			}
+			continue
+		}
+		options[index] = model.OptionData{Title: fmt.Sprint(choice), Value: choice}
+	}
+	return options
+}
+
+// surveyJSText gives a SurveyJS text. The localized texts are given in the default locale, or in English, or in the first locale
+func surveyJSText(value interface{}) string {
+	switch text := value.(type) {
+	case string:
+		return text
+	case map[string]interface{}:
+		for _, locale := range []string{"default", "en"} {
+			if localized, ok := text[locale].(string); ok {
+				return localized
+			}
+		}
+		locales := make([]string, 0, len(text))
+		for locale := range text {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+		for _, locale := range locales {
+			if localized, ok := text[locale].(string); ok {
+				return localized
+			}
+		}
+	}
+	return ""
+}
+
+// surveyJSNumber gives a SurveyJS number, which can be given as a string too
+func surveyJSNumber(value interface{}) *float64 {
+	switch number := value.(type) {
+	case float64:
+		return &number
+	case string:
+		parsed, err := strconv.ParseFloat(number, 64)
+		if err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
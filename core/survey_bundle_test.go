@@ -0,0 +1,152 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSurveyFromSurveyJS(t *testing.T) {
+	data := []byte(`{
+		"title": {"default": "Feedback", "fr": "Avis"},
+		"description": "About the event",
+		"pages": [
+			{"name": "first", "title": "First", "elements": [
+				{"type": "radiogroup", "name": "rating", "title": "Rating", "isRequired": true, "choices": ["good", {"value": "bad", "text": "Bad"}]},
+				{"type": "panel", "name": "details", "elements": [
+					{"type": "text", "name": "age", "inputType": "number", "min": 18, "max": "99"},
+					{"type": "html", "name": "note"}
+				]}
+			]},
+			{"elements": [
+				{"type": "comment", "name": "comments", "maxLength": 200},
+				{"type": "checkbox", "name": "topics", "choices": ["a", "b"]}
+			]}
+		]
+	}`)
+
+	survey, err := surveyFromSurveyJS(data)
+	if err != nil {
+		t.Fatalf("surveyFromSurveyJS() error = %v", err)
+	}
+	if survey.Title != "Feedback" || survey.MoreInfo == nil || *survey.MoreInfo != "About the event" {
+		t.Errorf("surveyFromSurveyJS() title = %s, more info = %v", survey.Title, survey.MoreInfo)
+	}
+	if survey.DefaultDataKey == nil || *survey.DefaultDataKey != "first" {
+		t.Errorf("surveyFromSurveyJS() default data key = %v, want first", survey.DefaultDataKey)
+	}
+
+	keys := make([]string, 0, len(survey.Data))
+	for key := range survey.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	wantKeys := []string{"age", "comments", "first", "page2", "rating", "topics"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Fatalf("surveyFromSurveyJS() keys = %v, want %v", keys, wantKeys)
+	}
+
+	first := survey.Data["first"]
+	if first.Type != surveyDataTypePage || !reflect.DeepEqual(first.DataKeys, []string{"rating", "age"}) ||
+		first.DefaultFollowUpKey == nil || *first.DefaultFollowUpKey != "page2" {
+		t.Errorf("surveyFromSurveyJS() first page = %+v", first)
+	}
+	if second := survey.Data["page2"]; !reflect.DeepEqual(second.DataKeys, []string{"comments", "topics"}) || second.DefaultFollowUpKey != nil {
+		t.Errorf("surveyFromSurveyJS() second page = %+v", second)
+	}
+
+	rating := survey.Data["rating"]
+	wantOptions := []model.OptionData{{Title: "good", Value: "good"}, {Title: "Bad", Value: "bad"}}
+	if rating.Type != surveyDataTypeMultipleChoice || rating.AllowSkip || !reflect.DeepEqual(rating.Options, wantOptions) {
+		t.Errorf("surveyFromSurveyJS() rating = %+v", rating)
+	}
+	if age := survey.Data["age"]; age.Type != surveyDataTypeNumeric || !age.AllowSkip || age.Text != "age" ||
+		age.Minimum == nil || *age.Minimum != 18 || age.Maximum == nil || *age.Maximum != 99 {
+		t.Errorf("surveyFromSurveyJS() age = %+v", age)
+	}
+	if comments := survey.Data["comments"]; comments.Type != surveyDataTypeText || comments.MaxLength == nil || *comments.MaxLength != 200 {
+		t.Errorf("surveyFromSurveyJS() comments = %+v", comments)
+	}
+	if topics := survey.Data["topics"]; topics.AllowMultiple == nil || !*topics.AllowMultiple {
+		t.Errorf("surveyFromSurveyJS() topics = %+v", topics)
+	}
+}
+
+func TestSurveyJSQuestion(t *testing.T) {
+	tests := []struct {
+		name        string
+		element     surveyJSElement
+		wantType    string
+		wantOptions int
+		wantError   bool
+	}{
+		{"text", surveyJSElement{Type: "text"}, surveyDataTypeText, 0, false},
+		{"date", surveyJSElement{Type: "text", InputType: "date"}, surveyDataTypeDateTime, 0, false},
+		{"number", surveyJSElement{Type: "text", InputType: "number"}, surveyDataTypeNumeric, 0, false},
+		{"dropdown", surveyJSElement{Type: "dropdown", Choices: []interface{}{"a", "b", "c"}}, surveyDataTypeMultipleChoice, 3, false},
+		{"boolean", surveyJSElement{Type: "boolean"}, surveyDataTypeTrueFalse, 2, false},
+		{"rating scale", surveyJSElement{Type: "rating"}, surveyDataTypeNumeric, 0, false},
+		{"rating values", surveyJSElement{Type: "rating", RateValues: []interface{}{1.0, 2.0}}, surveyDataTypeMultipleChoice, 2, false},
+		{"unsupported", surveyJSElement{Type: "signaturepad"}, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, message := surveyJSQuestion(tt.element)
+			if (len(message) > 0) != tt.wantError {
+				t.Fatalf("surveyJSQuestion() message = %s, want error %v", message, tt.wantError)
+			}
+			if data.Type != tt.wantType || len(data.Options) != tt.wantOptions {
+				t.Errorf("surveyJSQuestion() = %+v, want type %s and %d options", data, tt.wantType, tt.wantOptions)
+			}
+		})
+	}
+}
+
+func TestSurveyFromSurveyJSErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantErrors []string
+	}{
+		{"invalid JSON", `{"pages": `, []string{"survey"}},
+		{"no questions", `{"pages": [{"name": "p1", "elements": [{"type": "html", "name": "intro"}]}]}`, []string{"pages"}},
+		{"unnamed question", `{"elements": [{"type": "text"}]}`, []string{"page1.elements[0]"}},
+		{"duplicate name", `{"elements": [{"type": "text", "name": "q"}, {"type": "comment", "name": "q"}]}`, []string{"q"}},
+		{"unsupported question", `{"elements": [{"type": "matrix", "name": "grid"}]}`, []string{"grid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := surveyFromSurveyJS([]byte(tt.data))
+			var importErr *model.SurveyImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("surveyFromSurveyJS() error = %v, want a SurveyImportError", err)
+			}
+			keys := []string{}
+			for key := range importErr.Errors {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantErrors) {
+				t.Errorf("surveyFromSurveyJS() errors = %v, want %v", importErr.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
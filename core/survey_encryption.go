@@ -0,0 +1,190 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"polls/core/model"
+	"sort"
+)
+
+// surveyResponsePayload holds the answers of a survey response which are encrypted for the sensitive surveys
+type surveyResponsePayload struct {
+	Responses  map[string]interface{} `bson:"responses"`
+	Stats      *model.SurveyStats     `bson:"stats"`
+	ResultJSON string                 `bson:"result_json"`
+}
+
+// encryptSurveyResponse gives a copy of a response to a sensitive survey with its answers encrypted and removed from the survey.
+// The other responses are given as they are
+func (app *Application) encryptSurveyResponse(response model.SurveyResponse) (model.SurveyResponse, error) {
+	if !response.Survey.Sensitive || !app.encryption.IsEnabled() {
+		return response, nil
+	}
+
+	payload := surveyResponsePayload{Responses: map[string]interface{}{}, Stats: response.Survey.SurveyStats, ResultJSON: response.Survey.ResultJSON}
+	data := make(map[string]model.SurveyData, len(response.Survey.Data))
+	for key, item := range response.Survey.Data {
+		if item.Response != nil {
+			payload.Responses[key] = item.Response
+		}
+		item.Response = nil
+		data[key] = item
+	}
+
+	encrypted, err := app.encryption.Encrypt(payload, surveyResponseAssociatedData(response))
+	if err != nil {
+		return response, fmt.Errorf("error on Application.encryptSurveyResponse(%s) - %s", response.ID, err)
+	}
+	response.Survey.Data = data
+	response.Survey.SurveyStats = nil
+	response.Survey.ResultJSON = ""
+	response.Encrypted = encrypted
+	return response, nil
+}
+
+// decryptSurveyResponse puts the encrypted answers of a response back to its survey
+func (app *Application) decryptSurveyResponse(response *model.SurveyResponse) error {
+	if response == nil || response.Encrypted == nil {
+		return nil
+	}
+
+	var payload surveyResponsePayload
+	err := app.encryption.Decrypt(*response.Encrypted, surveyResponseAssociatedData(*response), &payload)
+	if err != nil {
+		return fmt.Errorf("error on Application.decryptSurveyResponse(%s) - %s", response.ID, err)
+	}
+	for key, value := range payload.Responses {
+		if item, ok := response.Survey.Data[key]; ok {
+			item.Response = value
+			response.Survey.Data[key] = item
+		}
+	}
+	response.Survey.SurveyStats = payload.Stats
+	response.Survey.ResultJSON = payload.ResultJSON
+	response.Encrypted = nil
+	return nil
+}
+
+func (app *Application) decryptSurveyResponses(responses []model.SurveyResponse) error {
+	for i := range responses {
+		err := app.decryptSurveyResponse(&responses[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// surveyResponseAssociatedData binds the encrypted answers to the user and the survey, so they can not be moved to another response
+func surveyResponseAssociatedData(response model.SurveyResponse) string {
+	return fmt.Sprintf("%s/%s", response.UserID, response.Survey.ID)
+}
+
+// getSurveyResponseCounts counts the completed responses to a survey by question and response value
+func (app *Application) getSurveyResponseCounts(survey model.Survey) (*model.SurveyResponseCounts, error) {
+	if !survey.Sensitive || !app.encryption.IsEnabled() {
+		return app.storage.GetSurveyResponseCounts(survey.AppID, survey.OrgID, survey.ID)
+	}
+
+	aggregates, err := app.aggregateEncryptedSurveyResponses(survey, model.SurveyResultsFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return &model.SurveyResponseCounts{Total: aggregates.Completed, Answered: aggregates.Answered, Values: aggregates.Values}, nil
+}
+
+// getSurveyResponseAggregates aggregates the responses to a survey matching the filter for the survey results
+func (app *Application) getSurveyResponseAggregates(survey model.Survey, filter model.SurveyResultsFilter) (*model.SurveyResponseAggregates, error) {
+	if !survey.Sensitive || !app.encryption.IsEnabled() {
+		return app.storage.GetSurveyResponseAggregates(survey.AppID, survey.OrgID, survey.ID, filter)
+	}
+	return app.aggregateEncryptedSurveyResponses(survey, filter)
+}
+
+// aggregateEncryptedSurveyResponses aggregates the responses to a sensitive survey in the service, as the database can not aggregate the encrypted answers
+func (app *Application) aggregateEncryptedSurveyResponses(survey model.Survey, filter model.SurveyResultsFilter) (*model.SurveyResponseAggregates, error) {
+	responses, err := app.storage.GetSurveyResponsesBySurvey(survey.AppID, survey.OrgID, survey.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+	err = app.decryptSurveyResponses(responses)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates := model.SurveyResponseAggregates{Answered: map[string]int{}, Values: map[string][]model.SurveyResponseValueCount{},
+		Numbers: map[string][]float64{}, Scores: map[string][]float64{}}
+	for _, response := range responses {
+		if response.Status == model.SurveyResponseStatusInProgress {
+			aggregates.InProgress++
+			continue
+		}
+		aggregates.Completed++
+
+		for key, data := range response.Survey.Data {
+			if data.Response == nil {
+				continue
+			}
+			aggregates.Answered[key]++
+			for _, value := range toList(data.Response) {
+				aggregates.Values[key] = addValueCount(aggregates.Values[key], value)
+			}
+			if number, ok := numberValue(data.Response); ok && data.Type == surveyDataTypeNumeric {
+				aggregates.Numbers[key] = append(aggregates.Numbers[key], number)
+			}
+		}
+		if response.Survey.SurveyStats != nil {
+			for section, score := range response.Survey.SurveyStats.Scores {
+				aggregates.Scores[section] = append(aggregates.Scores[section], score)
+			}
+		}
+	}
+
+	for _, numbers := range aggregates.Numbers {
+		sort.Float64s(numbers)
+	}
+	for _, scores := range aggregates.Scores {
+		sort.Float64s(scores)
+	}
+	return &aggregates, nil
+}
+
+func addValueCount(values []model.SurveyResponseValueCount, value interface{}) []model.SurveyResponseValueCount {
+	for i, entry := range values {
+		if fmt.Sprint(entry.Value) == fmt.Sprint(value) {
+			values[i].Count++
+			return values
+		}
+	}
+	return append(values, model.SurveyResponseValueCount{Value: value, Count: 1})
+}
+
+// numberValue gives the value as a number if it is stored as a number
+func numberValue(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
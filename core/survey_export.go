@@ -0,0 +1,238 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"polls/core/model"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the first characters of the CSV cells which spreadsheets evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// the columns of the survey responses CSV export which come before the question columns
+var surveyResponsesExportColumns = []string{"id", "user_id", "survey_version_id", "date_created", "date_updated"}
+
+// surveyResponseExportRow is a survey response flattened for the exports
+type surveyResponseExportRow struct {
+	ID              string                 `json:"id"`
+	UserID          string                 `json:"user_id"`
+	SurveyVersionID string                 `json:"survey_version_id"`
+	DateCreated     time.Time              `json:"date_created"`
+	DateUpdated     *time.Time             `json:"date_updated"`
+	Responses       map[string]interface{} `json:"responses"`
+}
+
+// surveyResponsesWriter writes the rows of a survey responses export in one format
+type surveyResponsesWriter interface {
+	write(row surveyResponseExportRow) error
+	close() error
+}
+
+// newSurveyResponsesWriter creates the writer of the export format
+func newSurveyResponsesWriter(format string, w io.Writer, keys []string) (surveyResponsesWriter, error) {
+	if format == model.SurveyResponsesExportFormatJSONL {
+		return &surveyResponsesJSONLWriter{encoder: json.NewEncoder(w)}, nil
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(append(append([]string{}, surveyResponsesExportColumns...), keys...))
+	if err != nil {
+		return nil, err
+	}
+	return &surveyResponsesCSVWriter{writer: writer, keys: keys}, nil
+}
+
+type surveyResponsesCSVWriter struct {
+	writer *csv.Writer
+	keys   []string
+}
+
+func (c *surveyResponsesCSVWriter) write(row surveyResponseExportRow) error {
+	record := []string{row.ID, row.UserID, row.SurveyVersionID, row.DateCreated.Format(time.RFC3339), formatExportDate(row.DateUpdated)}
+	for _, key := range c.keys {
+		record = append(record, exportValue(row.Responses[key]))
+	}
+	return c.writer.Write(record)
+}
+
+func (c *surveyResponsesCSVWriter) close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type surveyResponsesJSONLWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *surveyResponsesJSONLWriter) write(row surveyResponseExportRow) error {
+	return j.encoder.Encode(row)
+}
+
+func (j *surveyResponsesJSONLWriter) close() error {
+	return nil
+}
+
+// exportSurveyResponses writes the completed responses to a survey to w for its creator or the admins. The responses to the sensitive surveys
+// are exported only to the admins, pseudonymized and when there are enough respondents.
+// The responses are streamed from the storage, so large surveys are not loaded into memory
+func (app *Application) exportSurveyResponses(user *model.User, surveyID string, export model.SurveyResponsesExport, admin bool, w io.Writer) error {
+	survey, err := app.storage.GetSurvey(user, surveyID)
+	if err != nil {
+		return err
+	}
+	if !admin && survey.CreatorID != user.Claims.Subject {
+		return fmt.Errorf("error on Application.exportSurveyResponses(%s) - %w", surveyID, model.ErrNotSurveyCreator)
+	}
+
+	// the individual responses to the sensitive surveys are never revealed to their owners
+	if survey.Sensitive && !admin {
+		return fmt.Errorf("error on Application.exportSurveyResponses(%s) - %w", surveyID, model.ErrSensitiveSurveyExport)
+	}
+	if survey.Sensitive {
+		export.Pseudonymize = true
+		count, err := app.storage.CountSurveyResponses(survey.AppID, survey.OrgID, survey.ID, export.Filter)
+		if err != nil {
+			return err
+		}
+		if belowMinRespondents(&count, app.sensitiveSurveyMinRespondents) {
+			return fmt.Errorf("error on Application.exportSurveyResponses(%s) - %w", surveyID, model.ErrSurveyTooFewRespondents)
+		}
+	}
+
+	keys := surveyQuestionKeys(*survey)
+	writer, err := newSurveyResponsesWriter(export.Format, w, keys)
+	if err != nil {
+		return fmt.Errorf("error on Application.exportSurveyResponses(%s) - %s", surveyID, err)
+	}
+
+	exported := 0
+	err = app.storage.StreamSurveyResponses(survey.AppID, survey.OrgID, survey.ID, export.Filter, func(response model.SurveyResponse) error {
+		err := app.decryptSurveyResponse(&response)
+		if err != nil {
+			return err
+		}
+
+		row := surveyResponseExportRow{ID: response.ID, UserID: response.UserID, SurveyVersionID: response.SurveyVersionID,
+			DateCreated: response.DateCreated, DateUpdated: response.DateUpdated, Responses: map[string]interface{}{}}
+		if export.Pseudonymize {
+			row.UserID = app.surveyUserPseudonym(survey.ID, response.UserID)
+		}
+		for _, key := range keys {
+			row.Responses[key] = normalizeValue(response.Survey.Data[key].Response)
+		}
+		exported++
+		return writer.write(row)
+	})
+	if err != nil {
+		return err
+	}
+	err = writer.close()
+	if err != nil {
+		return fmt.Errorf("error on Application.exportSurveyResponses(%s) - %s", surveyID, err)
+	}
+
+	if survey.Sensitive {
+		log.Printf("Exported %d responses of the sensitive survey %s for %s", exported, survey.ID, user.Claims.Subject)
+	}
+	return nil
+}
+
+// surveyUserPseudonym gives a pseudonym of a user which is the same in all the exports of a survey, but can not be linked to the user
+// or to the pseudonyms of the user in other surveys without the pseudonym key
+func (app *Application) surveyUserPseudonym(surveyID string, userID string) string {
+	mac := hmac.New(sha256.New, app.exportPseudonymKey)
+	mac.Write([]byte(surveyID + "/" + userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// surveyQuestionKeys gives the keys of the questions of a survey in the order they are asked when the default follow ups are taken.
+// The questions which can not be reached this way follow in alphabetical order
+func surveyQuestionKeys(survey model.Survey) []string {
+	visited := map[string]bool{}
+	keys := []string{}
+	var visit func(key string)
+	visit = func(key string) {
+		for len(key) > 0 && !visited[key] {
+			data, ok := survey.Data[key]
+			if !ok {
+				return
+			}
+			visited[key] = true
+			if data.Type != surveyDataTypeResult && data.Type != surveyDataTypePage {
+				keys = append(keys, key)
+			}
+			for _, pageKey := range data.DataKeys {
+				visit(pageKey)
+			}
+
+			key = ""
+			if data.DefaultFollowUpKey != nil {
+				key = *data.DefaultFollowUpKey
+			}
+		}
+	}
+	if survey.DefaultDataKey != nil {
+		visit(*survey.DefaultDataKey)
+	}
+
+	remaining := []string{}
+	for key, data := range survey.Data {
+		if !visited[key] && data.Type != surveyDataTypeResult && data.Type != surveyDataTypePage {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+	return append(keys, remaining...)
+}
+
+// exportValue formats a response for a CSV cell. The lists and the objects are given as JSON
+func exportValue(value interface{}) string {
+	switch v := normalizeValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// csvText gives a text for a CSV cell. The texts which spreadsheets would evaluate as formulas are prefixed with a quote
+func csvText(text string) string {
+	if len(text) > 0 && strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
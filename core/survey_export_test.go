@@ -0,0 +1,142 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"polls/core/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"text", "fine", "fine"},
+		{"number", 4.5, "4.5"},
+		{"negative number", -2.0, "-2"},
+		{"bool", true, "true"},
+		{"list", []interface{}{"a", "b"}, `["a","b"]`},
+		{"formula", "=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"plus", "+1", "'+1"},
+		{"minus", "-1+2", "'-1+2"},
+		{"at", "@cmd", "'@cmd"},
+		{"tab", "\tx", "'\tx"},
+		{"carriage return", "\rx", "'\rx"},
+		{"formula inside", "a=b", "a=b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exportValue(tt.value); got != tt.want {
+				t.Errorf("exportValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func exportTestApplication(sensitive bool, respondents int) *Application {
+	storage := newTestStorage()
+	first := "q1"
+	second := "q2"
+	survey := model.Survey{ID: "survey1", CreatorID: "creator", Sensitive: sensitive, DefaultDataKey: &first,
+		Data: map[string]model.SurveyData{
+			"q1": {Type: surveyDataTypeText, DefaultFollowUpKey: &second},
+			"q2": {Type: surveyDataTypeNumeric},
+		}}
+	storage.surveys[survey.ID] = survey
+
+	created := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+	for i, text := range []interface{}{"fine", "=HYPERLINK(\"x\")", nil}[:respondents] {
+		response := model.SurveyResponse{ID: string(rune('a' + i)), UserID: "user", SurveyVersionID: "version1", DateCreated: created, Survey: survey}
+		response.Survey.Data = map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Response: text}, "q2": {Type: surveyDataTypeNumeric, Response: float64(i)}}
+		storage.responses[response.ID] = response
+	}
+	return &Application{storage: storage, sensitiveSurveyMinRespondents: 3, exportPseudonymKey: []byte("key")}
+}
+
+func TestExportSurveyResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		app     *Application
+		user    string
+		format  string
+		admin   bool
+		want    string
+		wantErr error
+	}{
+		{"csv", exportTestApplication(false, 3), "creator", model.SurveyResponsesExportFormatCSV, false,
+			"id,user_id,survey_version_id,date_created,date_updated,q1,q2\n" +
+				"a,user,version1,2026-10-19T09:00:00Z,,fine,0\n" +
+				"b,user,version1,2026-10-19T09:00:00Z,,\"'=HYPERLINK(\"\"x\"\")\",1\n" +
+				"c,user,version1,2026-10-19T09:00:00Z,,,2\n", nil},
+		{"jsonl", exportTestApplication(false, 1), "creator", model.SurveyResponsesExportFormatJSONL, false,
+			`{"id":"a","user_id":"user","survey_version_id":"version1","date_created":"2026-10-19T09:00:00Z","date_updated":null,"responses":{"q1":"fine","q2":0}}` + "\n", nil},
+		{"not the creator", exportTestApplication(false, 3), "respondent", model.SurveyResponsesExportFormatCSV, false, "", model.ErrNotSurveyCreator},
+		{"sensitive survey for the creator", exportTestApplication(true, 3), "creator", model.SurveyResponsesExportFormatCSV, false, "", model.ErrSensitiveSurveyExport},
+		{"sensitive survey with too few respondents", exportTestApplication(true, 2), "admin", model.SurveyResponsesExportFormatCSV, true, "", model.ErrSurveyTooFewRespondents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := tt.app.exportSurveyResponses(newTestUser(tt.user), "survey1", model.SurveyResponsesExport{Format: tt.format}, tt.admin, &out)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("exportSurveyResponses() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("exportSurveyResponses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportSensitiveSurveyResponsesPseudonymized(t *testing.T) {
+	app := exportTestApplication(true, 3)
+	var out bytes.Buffer
+	err := app.exportSurveyResponses(newTestUser("admin"), "survey1", model.SurveyResponsesExport{Format: model.SurveyResponsesExportFormatJSONL}, true, &out)
+	if err != nil {
+		t.Fatalf("exportSurveyResponses() error = %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(`"user_id":"user"`)) {
+		t.Errorf("exportSurveyResponses() = %s, want pseudonymized user ids", out.String())
+	}
+	if want := app.surveyUserPseudonym("survey1", "user"); !bytes.Contains(out.Bytes(), []byte(want)) {
+		t.Errorf("exportSurveyResponses() = %s, want the pseudonym %s", out.String(), want)
+	}
+}
+
+func TestSurveyQuestionKeys(t *testing.T) {
+	first := "page"
+	next := "q3"
+	survey := model.Survey{DefaultDataKey: &first, Data: map[string]model.SurveyData{
+		"page":   {Type: surveyDataTypePage, DataKeys: []string{"q2", "q1"}, DefaultFollowUpKey: &next},
+		"q1":     {Type: surveyDataTypeText},
+		"q2":     {Type: surveyDataTypeText},
+		"q3":     {Type: surveyDataTypeText},
+		"b":      {Type: surveyDataTypeText},
+		"a":      {Type: surveyDataTypeText},
+		"result": {Type: surveyDataTypeResult},
+	}}
+	if got, want := surveyQuestionKeys(survey), []string{"q2", "q1", "q3", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("surveyQuestionKeys() = %v, want %v", got, want)
+	}
+}
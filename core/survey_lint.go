@@ -0,0 +1,438 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"polls/core/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	surveyLintDuplicateKey     = "duplicate_key"
+	surveyLintDanglingKey      = "dangling_key"
+	surveyLintMissingPageKey   = "missing_page_key"
+	surveyLintInvalidRule      = "invalid_rule"
+	surveyLintUnreachable      = "unreachable"
+	surveyLintCycleWithoutExit = "cycle_without_exit"
+	surveyLintInvalidStatus    = "invalid_status"
+	surveyLintInvalidDates     = "invalid_dates"
+	surveyLintInvalidLimits    = "invalid_response_limits"
+	surveyLintInvalidQuota     = "invalid_quota"
+	surveyLintUnknownQuestion  = "unknown_question"
+	surveyLintInvalidStrings   = "invalid_strings"
+	surveyLintInvalidSchedule  = "invalid_schedule"
+	surveyLintInvalidAlertRule = "invalid_alert_rule"
+)
+
+type surveyLinter struct {
+	survey model.Survey
+	issues []model.SurveyLintIssue
+	edges  map[string][]string
+}
+
+// lintSurvey checks a survey definition and its flow for problems which would break the survey at runtime
+func lintSurvey(survey model.Survey) model.SurveyLint {
+	l := surveyLinter{survey: survey, issues: []model.SurveyLintIssue{}, edges: map[string][]string{}}
+
+	for _, key := range survey.DuplicateDataKeys() {
+		l.addError(surveyLintDuplicateKey, key, "the key is defined more than once")
+	}
+
+	switch survey.Status {
+	case "", model.SurveyStatusDraft, model.SurveyStatusPublished, model.SurveyStatusClosed, model.SurveyStatusArchived:
+	default:
+		l.addError(surveyLintInvalidStatus, "", fmt.Sprintf("status %s is not one of draft, published, closed or archived", survey.Status))
+	}
+	if survey.StartDate != nil && survey.EndDate != nil && survey.EndDate.Before(*survey.StartDate) {
+		l.addError(surveyLintInvalidDates, "", "the end date is before the start date")
+	}
+	if survey.ResponseLimits != nil {
+		if survey.ResponseLimits.MaxAttempts != nil && *survey.ResponseLimits.MaxAttempts < 1 {
+			l.addError(surveyLintInvalidLimits, "", "the max attempts must be at least 1")
+		}
+		if survey.ResponseLimits.CooldownSeconds != nil && *survey.ResponseLimits.CooldownSeconds < 0 {
+			l.addError(surveyLintInvalidLimits, "", "the cooldown must not be negative")
+		}
+	}
+	quotaKeys := map[string]bool{}
+	for _, quota := range survey.Quotas {
+		switch {
+		case len(quota.Key) == 0:
+			l.addError(surveyLintInvalidQuota, "", "the quota key is missing")
+		case strings.ContainsAny(quota.Key, ".$"):
+			l.addError(surveyLintInvalidQuota, "", fmt.Sprintf("the quota key %s must not contain . or $", quota.Key))
+		case quotaKeys[quota.Key]:
+			l.addError(surveyLintInvalidQuota, "", fmt.Sprintf("the quota key %s is defined more than once", quota.Key))
+		}
+		quotaKeys[quota.Key] = true
+		if quota.Limit < 1 {
+			l.addError(surveyLintInvalidQuota, "", fmt.Sprintf("the limit of the quota %s must be at least 1", quota.Key))
+		}
+		if quota.GroupID != nil && len(*quota.GroupID) == 0 {
+			l.addError(surveyLintInvalidQuota, "", fmt.Sprintf("the group of the quota %s is empty", quota.Key))
+		}
+	}
+
+	if survey.Schedule != nil {
+		l.checkSchedule(*survey.Schedule)
+	}
+
+	for i, rule := range survey.AlertRules {
+		l.checkAlertRule(i, rule)
+	}
+
+	for _, language := range invalidSurveyStrings(survey) {
+		l.addWarning(surveyLintInvalidStrings, "", fmt.Sprintf("the strings of the language %s are not a table of texts", language))
+	}
+
+	starts := []string{}
+	if survey.DefaultDataKey != nil && len(*survey.DefaultDataKey) > 0 {
+		if _, ok := survey.Data[*survey.DefaultDataKey]; ok {
+			starts = append(starts, *survey.DefaultDataKey)
+		} else {
+			l.addError(surveyLintDanglingKey, "", fmt.Sprintf("default data key %s does not exist", *survey.DefaultDataKey))
+		}
+	}
+	if survey.DefaultDataKeyRule != nil {
+		starts = append(starts, l.ruleTargets("", "default data key rule", *survey.DefaultDataKeyRule)...)
+	}
+
+	keys := l.sortedDataKeys()
+	for _, key := range keys {
+		data := survey.Data[key]
+		for _, pageKey := range data.DataKeys {
+			if _, ok := survey.Data[pageKey]; ok {
+				l.edges[key] = append(l.edges[key], pageKey)
+			} else {
+				l.addError(surveyLintMissingPageKey, key, fmt.Sprintf("page data key %s does not exist", pageKey))
+			}
+		}
+		if data.DefaultFollowUpKey != nil && len(*data.DefaultFollowUpKey) > 0 {
+			if _, ok := survey.Data[*data.DefaultFollowUpKey]; ok {
+				l.edges[key] = append(l.edges[key], *data.DefaultFollowUpKey)
+			} else {
+				l.addError(surveyLintDanglingKey, key, fmt.Sprintf("default follow up key %s does not exist", *data.DefaultFollowUpKey))
+			}
+		}
+		if data.FollowUpRule != nil {
+			l.edges[key] = append(l.edges[key], l.ruleTargets(key, "follow up rule", *data.FollowUpRule)...)
+		}
+		if data.ScoreRule != nil {
+			l.checkRule(key, "score rule", *data.ScoreRule)
+		}
+		if data.DefaultResponseRule != nil {
+			l.checkRule(key, "default response rule", *data.DefaultResponseRule)
+		}
+	}
+	if len(survey.ResultRules) > 0 {
+		l.checkRule("", "result rules", survey.ResultRules)
+	}
+
+	// surveys without a flow are answered as a whole
+	if len(starts) > 0 {
+		l.checkReachability(keys, starts)
+		l.checkCycles(keys)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Key < l.issues[j].Key
+	})
+	valid := true
+	for _, issue := range l.issues {
+		if issue.Severity == model.SurveyLintSeverityError {
+			valid = false
+		}
+	}
+	return model.SurveyLint{Valid: valid, Issues: l.issues}
+}
+
+func (l *surveyLinter) addError(issueType string, key string, message string) {
+	l.issues = append(l.issues, model.SurveyLintIssue{Severity: model.SurveyLintSeverityError, Type: issueType, Key: key, Message: message})
+}
+
+func (l *surveyLinter) addWarning(issueType string, key string, message string) {
+	l.issues = append(l.issues, model.SurveyLintIssue{Severity: model.SurveyLintSeverityWarning, Type: issueType, Key: key, Message: message})
+}
+
+func (l *surveyLinter) sortedDataKeys() []string {
+	keys := make([]string, 0, len(l.survey.Data))
+	for key := range l.survey.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *surveyLinter) parseRule(key string, name string, rule string) (interface{}, bool) {
+	var parsed interface{}
+	err := json.Unmarshal([]byte(rule), &parsed)
+	if err != nil {
+		l.addError(surveyLintInvalidRule, key, fmt.Sprintf("%s is not valid JSON - %s", name, err))
+		return nil, false
+	}
+	return parsed, true
+}
+
+func (l *surveyLinter) checkRule(key string, name string, rule string) {
+	parsed, ok := l.parseRule(key, name, rule)
+	if ok {
+		l.collectRuleResults(key, name, parsed, map[string]bool{})
+	}
+}
+
+// ruleTargets gives the existing data keys which a flow rule can lead to and reports the missing ones
+func (l *surveyLinter) ruleTargets(key string, name string, rule string) []string {
+	parsed, ok := l.parseRule(key, name, rule)
+	if !ok {
+		return nil
+	}
+
+	targets := []string{}
+	for _, result := range l.collectRuleResults(key, name, parsed, map[string]bool{}) {
+		if _, ok := l.survey.Data[result]; ok {
+			targets = append(targets, result)
+		} else {
+			l.addError(surveyLintDanglingKey, key, fmt.Sprintf("%s leads to %s which does not exist", name, result))
+		}
+	}
+	return targets
+}
+
+// collectRuleResults gives the string results of a rule which are not references to the survey properties
+func (l *surveyLinter) collectRuleResults(key string, name string, result interface{}, subRules map[string]bool) []string {
+	switch value := result.(type) {
+	case string:
+		if len(value) == 0 || strings.HasPrefix(value, "data.") || strings.HasPrefix(value, "stats.") ||
+			strings.HasPrefix(value, "constants.") || strings.HasPrefix(value, "strings.") {
+			return nil
+		}
+		return []string{value}
+	case []interface{}:
+		results := []string{}
+		for _, item := range value {
+			results = append(results, l.collectRuleResults(key, name, item, subRules)...)
+		}
+		return results
+	case map[string]interface{}:
+		if _, ok := value["condition"]; ok {
+			return append(l.collectRuleResults(key, name, value["true_result"], subRules),
+				l.collectRuleResults(key, name, value["false_result"], subRules)...)
+		}
+		if cases, ok := value["cases"].([]interface{}); ok {
+			results := []string{}
+			for _, item := range cases {
+				if rule, ok := item.(map[string]interface{}); ok {
+					results = append(results, l.collectRuleResults(key, name, rule["true_result"], subRules)...)
+				}
+			}
+			return results
+		}
+		if actions, ok := value["actions"].([]interface{}); ok {
+			return l.collectRuleResults(key, name, actions, subRules)
+		}
+		if action, ok := value["action"].(string); ok {
+			if action == "return" {
+				return l.collectRuleResults(key, name, value["data"], subRules)
+			}
+			return nil
+		}
+		if ruleKey, ok := value["rule_key"].(string); ok {
+			subRule, ok := l.survey.SubRules[ruleKey]
+			if !ok {
+				l.addError(surveyLintDanglingKey, key, fmt.Sprintf("%s references sub rule %s which does not exist", name, ruleKey))
+				return nil
+			}
+			if subRules[ruleKey] {
+				return nil
+			}
+			subRules[ruleKey] = true
+			if subRuleString, ok := subRule.(string); ok {
+				parsed, ok := l.parseRule(key, name, subRuleString)
+				if !ok {
+					return nil
+				}
+				return l.collectRuleResults(key, name, parsed, subRules)
+			}
+			return l.collectRuleResults(key, name, normalizeValue(subRule), subRules)
+		}
+	}
+	return nil
+}
+
+func (l *surveyLinter) checkReachability(keys []string, starts []string) {
+	reached := map[string]bool{}
+	queue := append([]string{}, starts...)
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if reached[key] {
+			continue
+		}
+		reached[key] = true
+		queue = append(queue, l.edges[key]...)
+	}
+
+	for _, key := range keys {
+		if !reached[key] {
+			l.addWarning(surveyLintUnreachable, key, "the question can not be reached from the start of the survey")
+		}
+	}
+}
+
+// checkCycles reports the loops in the survey flow which the respondent can not leave
+func (l *surveyLinter) checkCycles(keys []string) {
+	for _, component := range stronglyConnectedComponents(keys, l.edges) {
+		members := map[string]bool{}
+		for _, key := range component {
+			members[key] = true
+		}
+		if len(component) == 1 && !containsString(l.edges[component[0]], component[0]) {
+			continue
+		}
+
+		exit := false
+		for _, key := range component {
+			data := l.survey.Data[key]
+			if data.DefaultFollowUpKey == nil || len(*data.DefaultFollowUpKey) == 0 {
+				exit = true
+			}
+			for _, next := range l.edges[key] {
+				if !members[next] {
+					exit = true
+				}
+			}
+		}
+		if !exit {
+			sort.Strings(component)
+			l.addError(surveyLintCycleWithoutExit, component[0], fmt.Sprintf("the questions %s form a loop without an exit", strings.Join(component, ", ")))
+		}
+	}
+}
+
+// stronglyConnectedComponents gives the strongly connected components of a graph using the Tarjan's algorithm
+func stronglyConnectedComponents(nodes []string, edges map[string][]string) [][]string {
+	index := 0
+	indexes := map[string]int{}
+	lowLinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	components := [][]string{}
+
+	var connect func(node string)
+	connect = func(node string) {
+		indexes[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range edges[node] {
+			if _, visited := indexes[next]; !visited {
+				connect(next)
+				if lowLinks[next] < lowLinks[node] {
+					lowLinks[node] = lowLinks[next]
+				}
+			} else if onStack[next] && indexes[next] < lowLinks[node] {
+				lowLinks[node] = indexes[next]
+			}
+		}
+
+		if lowLinks[node] == indexes[node] {
+			component := []string{}
+			for {
+				last := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[last] = false
+				component = append(component, last)
+				if last == node {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := indexes[node]; !visited {
+			connect(node)
+		}
+	}
+	return components
+}
+
+func (l *surveyLinter) checkSchedule(schedule model.SurveySchedule) {
+	switch schedule.Frequency {
+	case model.SurveyScheduleFrequencyDaily:
+	case model.SurveyScheduleFrequencyWeekly:
+		if len(schedule.Weekdays) == 0 {
+			l.addError(surveyLintInvalidSchedule, "", "the weekly schedule has no weekdays")
+		}
+	default:
+		l.addError(surveyLintInvalidSchedule, "", fmt.Sprintf("frequency %s is not one of daily or weekly", schedule.Frequency))
+	}
+	for _, day := range schedule.Weekdays {
+		if day < 0 || day > 6 {
+			l.addError(surveyLintInvalidSchedule, "", fmt.Sprintf("weekday %d is not between 0 for Sunday and 6 for Saturday", day))
+		}
+	}
+	if schedule.Hour < 0 || schedule.Hour > 23 || schedule.Minute < 0 || schedule.Minute > 59 {
+		l.addError(surveyLintInvalidSchedule, "", fmt.Sprintf("%d:%d is not a time of the day", schedule.Hour, schedule.Minute))
+	}
+	if schedule.Timezone != nil && len(*schedule.Timezone) > 0 {
+		if _, err := time.LoadLocation(*schedule.Timezone); err != nil {
+			l.addError(surveyLintInvalidSchedule, "", fmt.Sprintf("timezone %s is unknown", *schedule.Timezone))
+		}
+	}
+}
+
+func (l *surveyLinter) checkAlertRule(index int, rule model.SurveyAlertRule) {
+	if len(rule.ContactKey) == 0 {
+		l.addError(surveyLintInvalidAlertRule, "", fmt.Sprintf("the contact key of the alert rule %d is missing", index))
+	}
+	if len(rule.Subject) == 0 || len(rule.Body) == 0 {
+		l.addError(surveyLintInvalidAlertRule, "", fmt.Sprintf("the alert rule %d has no subject or no body", index))
+	}
+	var condition interface{}
+	err := json.Unmarshal([]byte(rule.Condition), &condition)
+	if err != nil {
+		l.addError(surveyLintInvalidAlertRule, "", fmt.Sprintf("the condition of the alert rule %d is not valid JSON - %s", index, err))
+		return
+	}
+	if _, ok := condition.(map[string]interface{}); !ok {
+		l.addError(surveyLintInvalidAlertRule, "", fmt.Sprintf("the condition of the alert rule %d is not a condition", index))
+	}
+	if l.survey.Sensitive {
+		for _, text := range []string{rule.Subject, rule.Body} {
+			for _, match := range surveyAlertPlaceholder.FindAllStringSubmatch(text, -1) {
+				if isSensitiveAlertPlaceholder(match[1]) {
+					l.addError(surveyLintInvalidAlertRule, "", fmt.Sprintf("the alert rule %d of a sensitive survey must not contain the response placeholder %s", index, match[0]))
+				}
+			}
+		}
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
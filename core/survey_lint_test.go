@@ -0,0 +1,151 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestLintSurveyFlow(t *testing.T) {
+	exitRule := `{"condition": {"operator": "==", "data_key": "data.q2", "compare_to": "yes"}, "true_result": "q3", "false_result": "q1"}`
+	danglingRule := `{"condition": {"operator": "==", "data_key": "data.q1", "compare_to": "yes"}, "true_result": "q9", "false_result": "q2"}`
+
+	tests := []struct {
+		name      string
+		start     *string
+		data      map[string]model.SurveyData
+		wantValid bool
+		wantTypes []string
+	}{
+		{"linear flow", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q2")},
+			"q2": {},
+		}, true, []string{}},
+		{"no flow", nil, map[string]model.SurveyData{
+			"q1": {}, "q2": {},
+		}, true, []string{}},
+		{"self loop", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q1")},
+		}, false, []string{surveyLintCycleWithoutExit}},
+		{"loop without exit", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q2")},
+			"q2": {DefaultFollowUpKey: stringPtr("q3")},
+			"q3": {DefaultFollowUpKey: stringPtr("q1")},
+		}, false, []string{surveyLintCycleWithoutExit}},
+		{"loop with a rule exit", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q2")},
+			"q2": {DefaultFollowUpKey: stringPtr("q1"), FollowUpRule: &exitRule},
+			"q3": {},
+		}, true, []string{}},
+		{"loop with an end", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q2")},
+			"q2": {FollowUpRule: stringPtr(`{"action": "return", "data": "q1"}`)},
+		}, true, []string{}},
+		{"unreachable question", stringPtr("q1"), map[string]model.SurveyData{
+			"q1": {}, "q2": {},
+		}, true, []string{surveyLintUnreachable}},
+		{"dangling keys", stringPtr("q0"), map[string]model.SurveyData{
+			"q1": {DefaultFollowUpKey: stringPtr("q9"), FollowUpRule: &danglingRule},
+			"q2": {},
+		}, false, []string{surveyLintDanglingKey, surveyLintDanglingKey, surveyLintDanglingKey}},
+		{"missing page key", stringPtr("page"), map[string]model.SurveyData{
+			"page": {Type: surveyDataTypePage, DataKeys: []string{"q1", "q2"}},
+			"q1":   {},
+		}, false, []string{surveyLintMissingPageKey}},
+		{"invalid rule", nil, map[string]model.SurveyData{
+			"q1": {ScoreRule: stringPtr(`{"condition": `)},
+		}, false, []string{surveyLintInvalidRule}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lint := lintSurvey(model.Survey{DefaultDataKey: tt.start, Data: tt.data})
+			types := []string{}
+			for _, issue := range lint.Issues {
+				types = append(types, issue.Type)
+			}
+			sort.Strings(types)
+			if lint.Valid != tt.wantValid || !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("lintSurvey() = %v %+v, want %v %v", lint.Valid, lint.Issues, tt.wantValid, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []string
+		edges map[string][]string
+		want  [][]string
+	}{
+		{"no edges", []string{"a", "b"}, map[string][]string{}, [][]string{{"a"}, {"b"}}},
+		{"chain", []string{"a", "b", "c"}, map[string][]string{"a": {"b"}, "b": {"c"}}, [][]string{{"a"}, {"b"}, {"c"}}},
+		{"cycle", []string{"a", "b", "c"}, map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}, [][]string{{"a", "b", "c"}}},
+		{"two cycles", []string{"a", "b", "c", "d"}, map[string][]string{"a": {"b"}, "b": {"a", "c"}, "c": {"d"}, "d": {"c"}},
+			[][]string{{"a", "b"}, {"c", "d"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stronglyConnectedComponents(tt.nodes, tt.edges)
+			for _, component := range got {
+				sort.Strings(component)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i][0] < got[j][0] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stronglyConnectedComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintSurveySchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule model.SurveySchedule
+		wantErrs int
+	}{
+		{"daily", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyDaily, Hour: 9}, 0},
+		{"weekly", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly, Weekdays: []int{1, 3}, Timezone: stringPtr("UTC")}, 0},
+		{"weekly without weekdays", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly}, 1},
+		{"unknown frequency", model.SurveySchedule{Frequency: "monthly"}, 1},
+		{"invalid weekday", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly, Weekdays: []int{7}}, 1},
+		{"invalid time", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyDaily, Hour: 24, Minute: 60}, 1},
+		{"unknown timezone", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyDaily, Timezone: stringPtr("Mars/Base")}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := tt.schedule
+			lint := lintSurvey(model.Survey{Schedule: &schedule})
+			if len(lint.Issues) != tt.wantErrs || lint.Valid != (tt.wantErrs == 0) {
+				t.Errorf("lintSurvey() = %+v, want %d errors", lint, tt.wantErrs)
+			}
+			for _, issue := range lint.Issues {
+				if issue.Type != surveyLintInvalidSchedule {
+					t.Errorf("lintSurvey() issue type = %s, want %s", issue.Type, surveyLintInvalidSchedule)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"sort"
+)
+
+// the minimum number of respondents behind every aggregated value of a sensitive survey
+const defaultSensitiveSurveyMinRespondents = 5
+
+// suppressSurveyLiveStats hides the counts of a sensitive survey which come from less respondents than the minimum.
+// Gives the number of the suppressed counts
+func suppressSurveyLiveStats(stats *model.SurveyLiveStats, minRespondents int) int {
+	stats.MinRespondents = &minRespondents
+	stats.Suppressed = stats.Total < minRespondents
+
+	suppressed := 0
+	for key, question := range stats.Questions {
+		if stats.Suppressed || belowMinRespondents(question.Answered, minRespondents) {
+			stats.Questions[key] = model.SurveyQuestionStats{Suppressed: true}
+			suppressed++
+			continue
+		}
+		suppressed += suppressOptions(question.Options, minRespondents)
+	}
+	return suppressed
+}
+
+// suppressSurveyResults hides the results of a sensitive survey which come from less respondents than the minimum,
+// and the values of the individual responses. Gives the number of the suppressed results
+func suppressSurveyResults(results *model.SurveyResults, minRespondents int) int {
+	results.MinRespondents = &minRespondents
+	results.Suppressed = results.Total < minRespondents
+
+	suppressed := 0
+	for key, question := range results.Questions {
+		if results.Suppressed || belowMinRespondents(question.Answered, minRespondents) {
+			results.Questions[key] = model.SurveyQuestionResults{Suppressed: true}
+			suppressed++
+			continue
+		}
+		suppressed += suppressOptions(question.Options, minRespondents)
+		if question.Numeric != nil {
+			if question.Numeric.Count < minRespondents {
+				question.Numeric = nil
+				suppressed++
+			} else {
+				question.Numeric.Min = nil
+				question.Numeric.Max = nil
+			}
+		}
+		results.Questions[key] = question
+	}
+
+	for section, scores := range results.Scores {
+		if results.Suppressed || scores.Count < minRespondents {
+			delete(results.Scores, section)
+			suppressed++
+			continue
+		}
+		scores.Min = nil
+		scores.Max = nil
+		results.Scores[section] = scores
+	}
+	return suppressed
+}
+
+// suppressSurveyVersionsResponsesCounts hides the responses counts of the versions of a sensitive survey below the minimum
+// the same way as the option counts. Gives the number of the suppressed counts
+func suppressSurveyVersionsResponsesCounts(versions []model.SurveyVersion, minRespondents int) int {
+	counts := make(map[string]*int, len(versions))
+	for _, version := range versions {
+		counts[version.ID] = version.ResponsesCount
+	}
+	suppressed := suppressOptions(counts, minRespondents)
+	for i := range versions {
+		versions[i].ResponsesCount = counts[versions[i].ID]
+	}
+	return suppressed
+}
+
+// suppressOptions hides the option counts below the minimum. When a single count is hidden, the next smallest one is hidden
+// as well, so that the hidden count can not be derived from the total. Gives the number of the suppressed counts
+func suppressOptions(options map[string]*int, minRespondents int) int {
+	suppressed := 0
+	for title, count := range options {
+		if belowMinRespondents(count, minRespondents) {
+			options[title] = nil
+			suppressed++
+		}
+	}
+	if suppressed != 1 {
+		return suppressed
+	}
+
+	titles := []string{}
+	for title, count := range options {
+		if count != nil && *count > 0 {
+			titles = append(titles, title)
+		}
+	}
+	if len(titles) == 0 {
+		return suppressed
+	}
+	sort.Slice(titles, func(i, j int) bool {
+		if *options[titles[i]] != *options[titles[j]] {
+			return *options[titles[i]] < *options[titles[j]]
+		}
+		return titles[i] < titles[j]
+	})
+	options[titles[0]] = nil
+	return suppressed + 1
+}
+
+// belowMinRespondents checks if a count comes from some, but less respondents than the minimum
+func belowMinRespondents(count *int, minRespondents int) bool {
+	return count != nil && *count > 0 && *count < minRespondents
+}
@@ -0,0 +1,173 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
+
+// optionCounts gives the counts of the options, with -1 for the suppressed ones
+func optionCounts(options map[string]*int) map[string]int {
+	counts := map[string]int{}
+	for title, count := range options {
+		counts[title] = -1
+		if count != nil {
+			counts[title] = *count
+		}
+	}
+	return counts
+}
+
+func TestSuppressOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        map[string]*int
+		want           map[string]int
+		wantSuppressed int
+	}{
+		{"none below the minimum", map[string]*int{"a": intPtr(5), "b": intPtr(7)}, map[string]int{"a": 5, "b": 7}, 0},
+		{"zero counts are kept", map[string]*int{"a": intPtr(0), "b": intPtr(7)}, map[string]int{"a": 0, "b": 7}, 0},
+		{"single count suppresses the next smallest", map[string]*int{"a": intPtr(2), "b": intPtr(9), "c": intPtr(6)},
+			map[string]int{"a": -1, "b": 9, "c": -1}, 2},
+		{"ties are broken by title", map[string]*int{"a": intPtr(1), "c": intPtr(6), "b": intPtr(6)},
+			map[string]int{"a": -1, "b": -1, "c": 6}, 2},
+		{"several counts are enough", map[string]*int{"a": intPtr(1), "b": intPtr(3), "c": intPtr(8)},
+			map[string]int{"a": -1, "b": -1, "c": 8}, 2},
+		{"single count without others", map[string]*int{"a": intPtr(2), "b": intPtr(0)}, map[string]int{"a": -1, "b": 0}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suppressed := suppressOptions(tt.options, 5)
+			if suppressed != tt.wantSuppressed {
+				t.Errorf("suppressOptions() = %d, want %d", suppressed, tt.wantSuppressed)
+			}
+			if got := optionCounts(tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suppressOptions() options = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressSurveyResults(t *testing.T) {
+	newResults := func(total int) *model.SurveyResults {
+		return &model.SurveyResults{
+			Total: total,
+			Questions: map[string]model.SurveyQuestionResults{
+				"rare":    {Answered: intPtr(3)},
+				"options": {Answered: intPtr(10), Options: map[string]*int{"a": intPtr(1), "b": intPtr(4), "c": intPtr(5)}},
+				"numeric": {Answered: intPtr(10), Numeric: &model.SurveyDistribution{Count: 10, Min: floatPtr(1), Max: floatPtr(9)}},
+				"few":     {Answered: intPtr(10), Numeric: &model.SurveyDistribution{Count: 2}},
+			},
+			Scores: map[string]model.SurveyDistribution{
+				"":      {Count: 10, Min: floatPtr(0), Max: floatPtr(4)},
+				"small": {Count: 4},
+			},
+		}
+	}
+
+	t.Run("below the minimum respondents", func(t *testing.T) {
+		results := newResults(4)
+		suppressed := suppressSurveyResults(results, 5)
+		if !results.Suppressed || suppressed != 6 {
+			t.Fatalf("suppressSurveyResults() = %d, suppressed %v, want 6 and true", suppressed, results.Suppressed)
+		}
+		for key, question := range results.Questions {
+			if !question.Suppressed || question.Answered != nil {
+				t.Errorf("suppressSurveyResults() question %s = %+v, want suppressed", key, question)
+			}
+		}
+		if len(results.Scores) != 0 {
+			t.Errorf("suppressSurveyResults() scores = %v, want none", results.Scores)
+		}
+	})
+
+	t.Run("above the minimum respondents", func(t *testing.T) {
+		results := newResults(10)
+		suppressed := suppressSurveyResults(results, 5)
+		if results.Suppressed || results.MinRespondents == nil || *results.MinRespondents != 5 {
+			t.Fatalf("suppressSurveyResults() suppressed %v, min respondents %v", results.Suppressed, results.MinRespondents)
+		}
+		// the rare question, the options a and b, the few numeric responses and the small scores section
+		if suppressed != 5 {
+			t.Errorf("suppressSurveyResults() = %d, want 5", suppressed)
+		}
+		if !results.Questions["rare"].Suppressed {
+			t.Errorf("suppressSurveyResults() rare = %+v, want suppressed", results.Questions["rare"])
+		}
+		wantOptions := map[string]int{"a": -1, "b": -1, "c": 5}
+		if got := optionCounts(results.Questions["options"].Options); !reflect.DeepEqual(got, wantOptions) {
+			t.Errorf("suppressSurveyResults() options = %v, want %v", got, wantOptions)
+		}
+		numeric := results.Questions["numeric"].Numeric
+		if numeric == nil || numeric.Min != nil || numeric.Max != nil {
+			t.Errorf("suppressSurveyResults() numeric = %+v, want no min and max", numeric)
+		}
+		if results.Questions["few"].Numeric != nil {
+			t.Errorf("suppressSurveyResults() few = %+v, want no distribution", results.Questions["few"].Numeric)
+		}
+		if scores, ok := results.Scores[""]; !ok || scores.Min != nil || scores.Max != nil {
+			t.Errorf("suppressSurveyResults() scores = %+v, want no min and max", scores)
+		}
+		if _, ok := results.Scores["small"]; ok {
+			t.Errorf("suppressSurveyResults() small scores are not suppressed")
+		}
+	})
+}
+
+func TestSuppressSurveyVersionsResponsesCounts(t *testing.T) {
+	tests := []struct {
+		name           string
+		counts         map[string]*int
+		want           map[string]int
+		wantSuppressed int
+	}{
+		{"all above the minimum", map[string]*int{"v1": intPtr(5), "v2": intPtr(12)}, map[string]int{"v1": 5, "v2": 12}, 0},
+		{"versions without responses", map[string]*int{"v1": intPtr(0), "v2": intPtr(12)}, map[string]int{"v1": 0, "v2": 12}, 0},
+		{"single small count", map[string]*int{"v1": intPtr(3), "v2": intPtr(12), "v3": intPtr(7)},
+			map[string]int{"v1": -1, "v2": 12, "v3": -1}, 2},
+		{"only version", map[string]*int{"v1": intPtr(2)}, map[string]int{"v1": -1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := []model.SurveyVersion{}
+			for id, count := range tt.counts {
+				versions = append(versions, model.SurveyVersion{ID: id, ResponsesCount: count})
+			}
+			suppressed := suppressSurveyVersionsResponsesCounts(versions, 5)
+			if suppressed != tt.wantSuppressed {
+				t.Errorf("suppressSurveyVersionsResponsesCounts() = %d, want %d", suppressed, tt.wantSuppressed)
+			}
+			got := map[string]*int{}
+			for _, version := range versions {
+				got[version.ID] = version.ResponsesCount
+			}
+			if counts := optionCounts(got); !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("suppressSurveyVersionsResponsesCounts() counts = %v, want %v", counts, tt.want)
+			}
+		})
+	}
+}
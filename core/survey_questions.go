@@ -0,0 +1,183 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"log"
+	"polls/core/model"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (app *Application) getSurveyQuestions(user *model.User) ([]model.SurveyQuestion, error) {
+	return app.storage.GetSurveyQuestions(user.Claims.AppID, user.Claims.OrgID)
+}
+
+func (app *Application) getSurveyQuestion(user *model.User, id string) (*model.SurveyQuestion, error) {
+	return app.storage.GetSurveyQuestion(user.Claims.AppID, user.Claims.OrgID, id)
+}
+
+func (app *Application) createSurveyQuestion(user *model.User, question model.SurveyQuestion) (*model.SurveyQuestion, error) {
+	err := validateSurveyQuestion(question)
+	if err != nil {
+		return nil, err
+	}
+
+	question.ID = uuid.NewString()
+	question.AppID = user.Claims.AppID
+	question.OrgID = user.Claims.OrgID
+	question.CreatorID = user.Claims.Subject
+	question.Data = surveyQuestionDefinition(question.Data)
+	question.Version = 1
+	question.DateCreated = time.Now().UTC()
+	question.DateUpdated = nil
+	return app.storage.CreateSurveyQuestion(question)
+}
+
+// updateSurveyQuestion updates a question of the question bank and stores a new version of every survey which references it.
+// The surveys which can not be stored keep the previous definition and are reported in a SurveyQuestionPropagationError
+func (app *Application) updateSurveyQuestion(user *model.User, id string, question model.SurveyQuestion) error {
+	err := validateSurveyQuestion(question)
+	if err != nil {
+		return err
+	}
+
+	question.Data = surveyQuestionDefinition(question.Data)
+	err = app.storage.UpdateSurveyQuestion(user.Claims.AppID, user.Claims.OrgID, id, question)
+	if err != nil {
+		return err
+	}
+
+	surveys, err := app.storage.GetSurveysByQuestion(user.Claims.AppID, user.Claims.OrgID, id)
+	if err != nil {
+		return err
+	}
+	failed := []string{}
+	for _, survey := range surveys {
+		_, err = app.saveSurveyRevision(user, survey, survey.ID, true, nil)
+		if err != nil {
+			log.Printf("Error on Application.updateSurveyQuestion(%s): survey %s is not updated - %s", id, survey.ID, err)
+			failed = append(failed, survey.ID)
+		}
+	}
+	if len(failed) > 0 {
+		return &model.SurveyQuestionPropagationError{SurveyIDs: failed}
+	}
+	return nil
+}
+
+// deleteSurveyQuestion deletes a question of the question bank which is not referenced by any survey
+func (app *Application) deleteSurveyQuestion(user *model.User, id string) error {
+	surveys, err := app.storage.GetSurveysByQuestion(user.Claims.AppID, user.Claims.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if len(surveys) > 0 {
+		return fmt.Errorf("error on Application.deleteSurveyQuestion(%s) - %w by %d surveys", id, model.ErrSurveyQuestionInUse, len(surveys))
+	}
+	return app.storage.DeleteSurveyQuestion(user.Claims.AppID, user.Claims.OrgID, id)
+}
+
+// resolveSurveyQuestions gives the survey data which reference the question bank the current definitions of their questions.
+// Gives the keys of the survey data which reference unknown questions
+func (app *Application) resolveSurveyQuestions(survey *model.Survey) ([]string, error) {
+	ids := []string{}
+	for _, data := range survey.Data {
+		if data.QuestionID != nil {
+			ids = append(ids, *data.QuestionID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	questions, err := app.storage.GetSurveyQuestionsByIDs(survey.AppID, survey.OrgID, ids)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string]model.SurveyData, len(questions))
+	for _, question := range questions {
+		definitions[question.ID] = question.Data
+	}
+
+	missing := []string{}
+	for key, data := range survey.Data {
+		if data.QuestionID == nil {
+			continue
+		}
+		definition, ok := definitions[*data.QuestionID]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		// the flow and the response belong to the survey
+		definition.QuestionID = data.QuestionID
+		definition.Section = data.Section
+		definition.DefaultFollowUpKey = data.DefaultFollowUpKey
+		definition.DefaultResponseRule = data.DefaultResponseRule
+		definition.FollowUpRule = data.FollowUpRule
+		definition.ScoreRule = data.ScoreRule
+		definition.Response = data.Response
+		survey.Data[key] = definition
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// checkSurveyDefinition resolves the question bank references of a survey before it is stored and checks the result
+func (app *Application) checkSurveyDefinition(survey *model.Survey) error {
+	missing, err := app.resolveSurveyQuestions(survey)
+	if err != nil {
+		return err
+	}
+
+	lint := lintSurvey(*survey)
+	for _, key := range missing {
+		lint.Valid = false
+		lint.Issues = append(lint.Issues, model.SurveyLintIssue{Severity: model.SurveyLintSeverityError, Type: surveyLintUnknownQuestion,
+			Key: key, Message: fmt.Sprintf("question %s does not exist", *survey.Data[key].QuestionID)})
+	}
+	if !lint.Valid {
+		return &model.SurveyLintError{SurveyLint: lint}
+	}
+	return nil
+}
+
+func validateSurveyQuestion(question model.SurveyQuestion) error {
+	switch question.Data.Type {
+	case "":
+		return fmt.Errorf("%w - the data type is missing", model.ErrInvalidSurveyQuestion)
+	case surveyDataTypePage, surveyDataTypeResult:
+		return fmt.Errorf("%w - the data type %s can not be reused", model.ErrInvalidSurveyQuestion, question.Data.Type)
+	}
+	return nil
+}
+
+// surveyQuestionDefinition gives the parts of a survey data which are defined by the question bank
+func surveyQuestionDefinition(data model.SurveyData) model.SurveyData {
+	data.QuestionID = nil
+	data.Section = nil
+	data.DefaultFollowUpKey = nil
+	data.DefaultResponseRule = nil
+	data.FollowUpRule = nil
+	data.ScoreRule = nil
+	data.Response = nil
+	data.DataKeys = nil
+	return data
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+// questionsTestApplication gives an application with a survey which references a question of the question bank. The survey
+// stores the previous definition of the question, as if storing the survey again failed when the question was updated
+func questionsTestApplication() *Application {
+	storage := newTestStorage()
+	questionID := "question1"
+	followUp := "q2"
+	storage.questions[questionID] = model.SurveyQuestion{ID: questionID, Data: model.SurveyData{Type: surveyDataTypeText, Text: "How are you today?"}}
+	storage.surveys["survey1"] = model.Survey{ID: "survey1", CreatorID: "creator", Title: "title", Status: model.SurveyStatusPublished,
+		Data: map[string]model.SurveyData{
+			"q1": {QuestionID: &questionID, Type: surveyDataTypeText, Text: "How are you?", DefaultFollowUpKey: &followUp, Response: "fine"},
+			"q2": {Type: surveyDataTypeText, Text: "Anything else?"},
+			"q3": {QuestionID: stringPtr("deleted"), Type: surveyDataTypeText, Text: "Deleted question"},
+		},
+		Strings: map[string]interface{}{"es": map[string]interface{}{"title": "Titulo"}},
+	}
+	return &Application{storage: storage}
+}
+
+func TestResolveSurveyQuestions(t *testing.T) {
+	app := questionsTestApplication()
+	survey, _ := app.storage.GetSurvey(newTestUser("creator"), "survey1")
+
+	missing, err := app.resolveSurveyQuestions(survey)
+	if err != nil {
+		t.Fatalf("resolveSurveyQuestions() error = %v", err)
+	}
+	if !reflect.DeepEqual(missing, []string{"q3"}) {
+		t.Errorf("resolveSurveyQuestions() missing = %v, want [q3]", missing)
+	}
+	q1 := survey.Data["q1"]
+	if q1.Text != "How are you today?" || q1.DefaultFollowUpKey == nil || *q1.DefaultFollowUpKey != "q2" || q1.Response != "fine" {
+		t.Errorf("resolveSurveyQuestions() q1 = %+v, want the question definition with the survey flow and response", q1)
+	}
+	if survey.Data["q3"].Text != "Deleted question" {
+		t.Errorf("resolveSurveyQuestions() q3 = %+v, want the stored definition", survey.Data["q3"])
+	}
+}
+
+func TestGetSurveyResolvesQuestions(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		admin bool
+	}{
+		{"creator", "creator", false},
+		{"respondent", "respondent", false},
+		{"admin", "admin", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := questionsTestApplication()
+			survey, err := app.getSurvey(newTestUser(tt.user), "survey1", tt.admin, nil)
+			if err != nil || survey == nil {
+				t.Fatalf("getSurvey() = %v, %v", survey, err)
+			}
+			if text := survey.Data["q1"].Text; text != "How are you today?" {
+				t.Errorf("getSurvey() q1 text = %s, want the current question definition", text)
+			}
+		})
+	}
+}
+
+func TestGetSurveyTranslationsResolvesQuestions(t *testing.T) {
+	app := questionsTestApplication()
+	translations, err := app.getSurveyTranslations(newTestUser("admin"), "survey1", nil)
+	if err != nil {
+		t.Fatalf("getSurveyTranslations() error = %v", err)
+	}
+
+	texts := map[string]bool{}
+	for _, untranslated := range translations.Untranslated["es"] {
+		texts[untranslated.Text] = true
+	}
+	if !texts["How are you today?"] || texts["How are you?"] {
+		t.Errorf("getSurveyTranslations() untranslated = %+v, want the current question definition", translations.Untranslated["es"])
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"polls/utils"
+)
+
+// surveyPresentation gives the order in which a randomized survey is presented to a user, or nil if the survey is not randomized.
+// Every page and every question is shuffled by its own seed, so the order of one does not change when the others are edited.
+// The creator is presented the survey as it is defined, so that an edited survey keeps its order
+func surveyPresentation(survey model.Survey, userID string) *model.SurveyPresentation {
+	if survey.Randomization == nil || (!survey.Randomization.ShuffleOptions && !survey.Randomization.ShuffleQuestions) || survey.CreatorID == userID {
+		return nil
+	}
+
+	presentation := model.SurveyPresentation{}
+	for key, data := range survey.Data {
+		if survey.Randomization.ShuffleQuestions && data.Type == surveyDataTypePage && len(data.DataKeys) > 1 {
+			if presentation.DataKeys == nil {
+				presentation.DataKeys = map[string][]string{}
+			}
+			order := utils.ShuffledOrder(len(data.DataKeys), userID, survey.ID, key, "data_keys")
+			presentation.DataKeys[key] = make([]string, len(order))
+			for i, index := range order {
+				presentation.DataKeys[key][i] = data.DataKeys[index]
+			}
+		}
+		if survey.Randomization.ShuffleOptions && data.Type == surveyDataTypeMultipleChoice && len(data.Options) > 1 {
+			if presentation.Options == nil {
+				presentation.Options = map[string][]int{}
+			}
+			presentation.Options[key] = utils.ShuffledOrder(len(data.Options), userID, survey.ID, key, "options")
+		}
+	}
+	return &presentation
+}
+
+// presentSurvey orders the pages and the options of a survey as they are presented to a user
+func presentSurvey(survey *model.Survey, userID string) {
+	presentation := surveyPresentation(*survey, userID)
+	if presentation == nil {
+		return
+	}
+
+	data := make(map[string]model.SurveyData, len(survey.Data))
+	for key, item := range survey.Data {
+		if dataKeys, ok := presentation.DataKeys[key]; ok {
+			item.DataKeys = dataKeys
+		}
+		if order, ok := presentation.Options[key]; ok {
+			options := make([]model.OptionData, len(order))
+			for i, index := range order {
+				options[i] = item.Options[index]
+			}
+			item.Options = options
+		}
+		data[key] = item
+	}
+	survey.Data = data
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func randomizedTestSurvey(randomization *model.SurveyRandomization) model.Survey {
+	options := []model.OptionData{{Title: "a", Value: 0}, {Title: "b", Value: 1}, {Title: "c", Value: 2}, {Title: "d", Value: 3}, {Title: "e", Value: 4}}
+	return model.Survey{ID: "survey1", CreatorID: "creator", Randomization: randomization,
+		Data: map[string]model.SurveyData{
+			"page1": {Type: surveyDataTypePage, DataKeys: []string{"q1", "q2", "q3", "q4", "q5"}},
+			"q1":    {Type: surveyDataTypeMultipleChoice, Options: options},
+			"q2":    {Type: surveyDataTypeMultipleChoice, Options: options[:1]},
+			"q3":    {Type: surveyDataTypeText},
+		},
+	}
+}
+
+func TestSurveyPresentation(t *testing.T) {
+	both := &model.SurveyRandomization{ShuffleOptions: true, ShuffleQuestions: true}
+	tests := []struct {
+		name          string
+		randomization *model.SurveyRandomization
+		userID        string
+		wantNil       bool
+		wantDataKeys  bool
+		wantOptions   bool
+	}{
+		{"not randomized", nil, "user1", true, false, false},
+		{"nothing shuffled", &model.SurveyRandomization{}, "user1", true, false, false},
+		{"creator", both, "creator", true, false, false},
+		{"shuffled options", &model.SurveyRandomization{ShuffleOptions: true}, "user1", false, false, true},
+		{"shuffled questions", &model.SurveyRandomization{ShuffleQuestions: true}, "user1", false, true, false},
+		{"shuffled options and questions", both, "user1", false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := randomizedTestSurvey(tt.randomization)
+			presentation := surveyPresentation(survey, tt.userID)
+			if presentation == nil {
+				if !tt.wantNil {
+					t.Fatalf("surveyPresentation() = nil")
+				}
+				return
+			}
+			if tt.wantNil {
+				t.Fatalf("surveyPresentation() = %v, want nil", presentation)
+			}
+
+			if again := surveyPresentation(survey, tt.userID); !reflect.DeepEqual(presentation, again) {
+				t.Errorf("surveyPresentation() = %v, then %v", presentation, again)
+			}
+
+			if _, ok := presentation.DataKeys["page1"]; ok != tt.wantDataKeys {
+				t.Errorf("surveyPresentation() data keys = %v", presentation.DataKeys)
+			} else if ok {
+				dataKeys := append([]string{}, presentation.DataKeys["page1"]...)
+				sort.Strings(dataKeys)
+				if !reflect.DeepEqual(dataKeys, survey.Data["page1"].DataKeys) {
+					t.Errorf("surveyPresentation() data keys = %v, want a permutation of %v", presentation.DataKeys["page1"], survey.Data["page1"].DataKeys)
+				}
+			}
+
+			if _, ok := presentation.Options["q1"]; ok != tt.wantOptions {
+				t.Errorf("surveyPresentation() options = %v", presentation.Options)
+			} else if ok {
+				order := append([]int{}, presentation.Options["q1"]...)
+				sort.Ints(order)
+				if !reflect.DeepEqual(order, []int{0, 1, 2, 3, 4}) {
+					t.Errorf("surveyPresentation() options = %v, want a permutation of the options", presentation.Options["q1"])
+				}
+			}
+			if _, ok := presentation.Options["q2"]; ok {
+				t.Errorf("surveyPresentation() shuffled a single option")
+			}
+		})
+	}
+}
+
+func TestSurveyPresentationPerUser(t *testing.T) {
+	survey := randomizedTestSurvey(&model.SurveyRandomization{ShuffleOptions: true, ShuffleQuestions: true})
+	first := surveyPresentation(survey, "user1")
+	for _, userID := range []string{"user2", "user3", "user4", "user5", "user6"} {
+		if !reflect.DeepEqual(first, surveyPresentation(survey, userID)) {
+			return
+		}
+	}
+	t.Errorf("surveyPresentation() = %v for every user", first)
+}
+
+func TestPresentSurvey(t *testing.T) {
+	randomization := &model.SurveyRandomization{ShuffleOptions: true, ShuffleQuestions: true}
+
+	creatorSurvey := randomizedTestSurvey(randomization)
+	presentSurvey(&creatorSurvey, "creator")
+	if !reflect.DeepEqual(creatorSurvey, randomizedTestSurvey(randomization)) {
+		t.Errorf("presentSurvey() changed the survey of the creator")
+	}
+
+	survey := randomizedTestSurvey(randomization)
+	presentSurvey(&survey, "user1")
+	presentation := surveyPresentation(randomizedTestSurvey(randomization), "user1")
+	if !reflect.DeepEqual(survey.Data["page1"].DataKeys, presentation.DataKeys["page1"]) {
+		t.Errorf("presentSurvey() data keys = %v, want %v", survey.Data["page1"].DataKeys, presentation.DataKeys["page1"])
+	}
+	defined := randomizedTestSurvey(randomization).Data["q1"].Options
+	for i, index := range presentation.Options["q1"] {
+		if survey.Data["q1"].Options[i] != defined[index] {
+			t.Errorf("presentSurvey() options = %v, want the order %v", survey.Data["q1"].Options, presentation.Options["q1"])
+			break
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"polls/driven/notifications"
+	"testing"
+	"time"
+)
+
+func responsesTestApplication(limits *model.SurveyResponseLimits) (*Application, *testStorage) {
+	storage := newTestStorage()
+	storage.surveys["survey1"] = model.Survey{ID: "survey1", CreatorID: "creator", Status: model.SurveyStatusPublished, ResponseLimits: limits,
+		Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"}}}
+	return &Application{storage: storage}, storage
+}
+
+func responsesTestSurvey(response string) model.Survey {
+	return model.Survey{ID: "survey1", Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Response: response}}}
+}
+
+func TestCreateSurveyResponseLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		limits       *model.SurveyResponseLimits
+		lastAttempt  time.Duration // the time since the last attempt, if the user responded before
+		attempts     int
+		wantErr      error
+		wantAttempts int
+	}{
+		{"no limits", nil, 0, 0, nil, 0},
+		{"first response", &model.SurveyResponseLimits{MaxAttempts: intPtr(1)}, 0, 0, nil, 1},
+		{"one response per user", &model.SurveyResponseLimits{MaxAttempts: intPtr(1)}, time.Hour, 1, model.ErrSurveyResponseLimitReached, 1},
+		{"attempts left", &model.SurveyResponseLimits{MaxAttempts: intPtr(3)}, time.Hour, 2, nil, 3},
+		{"within the cooldown", &model.SurveyResponseLimits{CooldownSeconds: intPtr(86400)}, time.Hour, 1, model.ErrSurveyResponseLimitReached, 1},
+		{"after the cooldown", &model.SurveyResponseLimits{CooldownSeconds: intPtr(86400)}, 25 * time.Hour, 1, nil, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, storage := responsesTestApplication(tt.limits)
+			if tt.attempts > 0 {
+				last := time.Now().UTC().Add(-tt.lastAttempt)
+				storage.attempts["survey1_respondent"] = model.SurveyResponseAttempts{ID: "survey1_respondent", SurveyID: "survey1", UserID: "respondent",
+					Count: tt.attempts, DateLastAttempt: &last}
+			}
+
+			response, err := app.createSurveyResponse(newTestUser("respondent"), responsesTestSurvey("fine"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("createSurveyResponse() error = %v, want %v", err, tt.wantErr)
+			}
+			if (response != nil) != (tt.wantErr == nil) {
+				t.Errorf("createSurveyResponse() = %+v, want a response %v", response, tt.wantErr == nil)
+			}
+			if count := storage.attempts["survey1_respondent"].Count; count != tt.wantAttempts {
+				t.Errorf("createSurveyResponse() attempts = %d, want %d", count, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestCreateSurveyResponseReleasesAttempt(t *testing.T) {
+	app, storage := responsesTestApplication(&model.SurveyResponseLimits{MaxAttempts: intPtr(1)})
+	storage.failResponses = true
+
+	if _, err := app.createSurveyResponse(newTestUser("respondent"), responsesTestSurvey("fine")); err == nil {
+		t.Fatalf("createSurveyResponse() error = nil, want an error")
+	}
+	if count := storage.attempts["survey1_respondent"].Count; count != 0 {
+		t.Errorf("createSurveyResponse() attempts = %d, want the attempt released", count)
+	}
+}
+
+func TestUpdateSurveyResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		surveyID string
+		wantErr  error
+	}{
+		{"completed response", model.SurveyResponseStatusCompleted, "survey1", nil},
+		{"response in progress", model.SurveyResponseStatusInProgress, "survey1", model.ErrSurveyResponseInProgress},
+		{"response to another survey", model.SurveyResponseStatusCompleted, "survey2", model.ErrSurveyResponseSurveyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, storage := responsesTestApplication(&model.SurveyResponseLimits{MaxAttempts: intPtr(1)})
+			storage.responses["response1"] = model.SurveyResponse{ID: "response1", UserID: "respondent", Status: tt.status,
+				Survey: responsesTestSurvey("fine")}
+
+			survey := responsesTestSurvey("better")
+			survey.ID = tt.surveyID
+			err := app.updateSurveyResponse(newTestUser("respondent"), "response1", survey)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("updateSurveyResponse() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := "fine"
+			if tt.wantErr == nil {
+				want = "better"
+			}
+			if response := storage.responses["response1"].Survey.Data["q1"].Response; response != want {
+				t.Errorf("updateSurveyResponse() response = %v, want %s", response, want)
+			}
+			if len(storage.attempts) != 0 {
+				t.Errorf("updateSurveyResponse() attempts = %+v, want no attempt", storage.attempts)
+			}
+		})
+	}
+}
+
+func TestCreateSurveyResponseQuotas(t *testing.T) {
+	app, storage := responsesTestApplication(nil)
+	// the notifications of the closed surveys fail to be delivered without a notifications service
+	app.notifications = notifications.NewNotificationsAdapter("http://127.0.0.1:0", "", "", "")
+	survey := storage.surveys["survey1"]
+	survey.Quotas = []model.SurveyQuota{{Key: "overall", Limit: 2}}
+	storage.surveys["survey1"] = survey
+
+	storage.failResponses = true
+	if _, err := app.createSurveyResponse(newTestUser("respondent1"), responsesTestSurvey("fine")); err == nil {
+		t.Fatalf("createSurveyResponse() error = nil, want an error")
+	}
+	if count := storage.quotaCounts["survey1"]["overall"]; count != 0 {
+		t.Errorf("createSurveyResponse() quota count = %d, want the quota released", count)
+	}
+	storage.failResponses = false
+
+	for i, user := range []string{"respondent1", "respondent2"} {
+		if _, err := app.createSurveyResponse(newTestUser(user), responsesTestSurvey("fine")); err != nil {
+			t.Fatalf("createSurveyResponse() error = %v", err)
+		}
+		if closed := storage.closed["survey1"]; closed != (i == 1) {
+			t.Errorf("createSurveyResponse() closed = %v after %d responses", closed, i+1)
+		}
+	}
+
+	_, err := app.createSurveyResponse(newTestUser("respondent3"), responsesTestSurvey("fine"))
+	if !errors.Is(err, model.ErrSurveyQuotaReached) {
+		t.Errorf("createSurveyResponse() error = %v, want %v", err, model.ErrSurveyQuotaReached)
+	}
+}
+
+func TestCloseSurveyIfQuotasMet(t *testing.T) {
+	overall := model.SurveyQuota{Key: "overall", Limit: 10}
+	group1 := model.SurveyQuota{Key: "group1", Limit: 2, GroupID: stringPtr("group1")}
+	group2 := model.SurveyQuota{Key: "group2", Limit: 2, GroupID: stringPtr("group2")}
+
+	tests := []struct {
+		name       string
+		quotas     []model.SurveyQuota
+		counts     *model.SurveyQuotaCounts
+		wantClosed bool
+	}{
+		{"no counts", []model.SurveyQuota{overall}, nil, false},
+		{"overall quota not met", []model.SurveyQuota{overall}, &model.SurveyQuotaCounts{Counts: map[string]int{"overall": 9}}, false},
+		{"overall quota met", []model.SurveyQuota{overall, group1}, &model.SurveyQuotaCounts{Counts: map[string]int{"overall": 10}}, true},
+		{"some group quotas met", []model.SurveyQuota{group1, group2}, &model.SurveyQuotaCounts{Counts: map[string]int{"group1": 2, "group2": 1}}, false},
+		{"all group quotas met", []model.SurveyQuota{group1, group2}, &model.SurveyQuotaCounts{Counts: map[string]int{"group1": 2, "group2": 3}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, storage := responsesTestApplication(nil)
+			app.notifications = notifications.NewNotificationsAdapter("http://127.0.0.1:0", "", "", "")
+			survey := storage.surveys["survey1"]
+			survey.Quotas = tt.quotas
+
+			app.closeSurveyIfQuotasMet(survey, tt.counts)
+			if closed := storage.closed["survey1"]; closed != tt.wantClosed {
+				t.Errorf("closeSurveyIfQuotasMet() closed = %v, want %v", closed, tt.wantClosed)
+			}
+		})
+	}
+}
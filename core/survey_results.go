@@ -0,0 +1,111 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"math"
+	"polls/core/model"
+	"sort"
+	"time"
+)
+
+// the percentiles given in the distributions of the survey results
+var surveyResultsPercentiles = []int{10, 25, 75, 90}
+
+// buildSurveyResults builds the results of a survey from the aggregates of its responses
+func buildSurveyResults(survey model.Survey, filter model.SurveyResultsFilter, aggregates model.SurveyResponseAggregates) model.SurveyResults {
+	results := model.SurveyResults{SurveyID: survey.ID, Filter: filter, Total: aggregates.Completed, InProgress: aggregates.InProgress,
+		CompletionRate: rate(aggregates.Completed, aggregates.Completed+aggregates.InProgress),
+		Questions:      map[string]model.SurveyQuestionResults{}, DateCreated: time.Now().UTC()}
+
+	for key, data := range survey.Data {
+		if data.Type == surveyDataTypeResult || data.Type == surveyDataTypePage {
+			continue
+		}
+		answered := aggregates.Answered[key]
+		questionResults := model.SurveyQuestionResults{Answered: &answered, AnswerRate: rate(answered, aggregates.Completed),
+			Options: countOptions(data, aggregates.Values[key])}
+		if data.Type == surveyDataTypeNumeric {
+			questionResults.Numeric = distribution(aggregates.Numbers[key])
+		}
+		results.Questions[key] = questionResults
+	}
+
+	if len(aggregates.Scores) > 0 {
+		results.Scores = map[string]model.SurveyDistribution{}
+		for section, scores := range aggregates.Scores {
+			if scoresDistribution := distribution(scores); scoresDistribution != nil {
+				results.Scores[section] = *scoresDistribution
+			}
+		}
+	}
+	return results
+}
+
+// countOptions counts the responses by the title of the options of a question. Gives nil for the questions without options
+func countOptions(data model.SurveyData, values []model.SurveyResponseValueCount) map[string]*int {
+	if len(data.Options) == 0 {
+		return nil
+	}
+
+	options := map[string]*int{}
+	for _, option := range data.Options {
+		count := 0
+		for _, value := range values {
+			if fmt.Sprint(value.Value) == fmt.Sprint(option.Value) {
+				count += value.Count
+			}
+		}
+		options[option.Title] = &count
+	}
+	return options
+}
+
+// distribution describes the distribution of values. Gives nil when there are no values
+func distribution(values []float64) *model.SurveyDistribution {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	result := model.SurveyDistribution{Count: len(sorted), Min: &sorted[0], Max: &sorted[len(sorted)-1],
+		Mean: sum / float64(len(sorted)), Median: percentile(sorted, 50), Percentiles: map[string]float64{}}
+	for _, p := range surveyResultsPercentiles {
+		result.Percentiles[fmt.Sprintf("p%d", p)] = percentile(sorted, p)
+	}
+	return &result
+}
+
+// percentile gives the p-th percentile of sorted values using linear interpolation between the closest ranks
+func percentile(sorted []float64, p int) float64 {
+	position := float64(p) / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+func rate(count int, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	value := float64(count) / float64(total)
+	return &value
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"polls/driven/encryption"
+	"reflect"
+	"testing"
+)
+
+func TestDistribution(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []float64
+		wantNil     bool
+		wantMean    float64
+		wantMedian  float64
+		wantP25     float64
+		wantP90     float64
+		wantMinimum float64
+		wantMaximum float64
+	}{
+		{"no values", nil, true, 0, 0, 0, 0, 0, 0},
+		{"one value", []float64{4}, false, 4, 4, 4, 4, 4, 4},
+		{"even count", []float64{4, 1, 3, 2}, false, 2.5, 2.5, 1.75, 3.7, 1, 4},
+		{"odd count", []float64{10, 0, 5, 20, 15}, false, 10, 10, 5, 18, 0, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distribution(tt.values)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("distribution() = %+v, want nil", got)
+				}
+				return
+			}
+			if got.Count != len(tt.values) || got.Mean != tt.wantMean || got.Median != tt.wantMedian || *got.Min != tt.wantMinimum ||
+				*got.Max != tt.wantMaximum {
+				t.Errorf("distribution() = %+v", got)
+			}
+			if !floatEqual(got.Percentiles["p25"], tt.wantP25) || !floatEqual(got.Percentiles["p90"], tt.wantP90) {
+				t.Errorf("distribution() percentiles = %v, want p25 %v and p90 %v", got.Percentiles, tt.wantP25, tt.wantP90)
+			}
+		})
+	}
+}
+
+func TestCountOptions(t *testing.T) {
+	options := []model.OptionData{{Title: "Yes", Value: true}, {Title: "No", Value: false}, {Title: "Three", Value: 3}}
+	values := []model.SurveyResponseValueCount{{Value: true, Count: 4}, {Value: 3.0, Count: 2}, {Value: "other", Count: 1}}
+
+	if got := countOptions(model.SurveyData{}, values); got != nil {
+		t.Errorf("countOptions() = %v, want nil for the questions without options", got)
+	}
+	got := optionCounts(countOptions(model.SurveyData{Options: options}, values))
+	if want := map[string]int{"Yes": 4, "No": 0, "Three": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("countOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildSurveyResults(t *testing.T) {
+	survey := model.Survey{ID: "survey1", Data: map[string]model.SurveyData{
+		"page":  {Type: surveyDataTypePage, DataKeys: []string{"color", "age"}},
+		"color": {Type: surveyDataTypeMultipleChoice, Options: []model.OptionData{{Title: "Red", Value: "red"}, {Title: "Blue", Value: "blue"}}},
+		"age":   {Type: surveyDataTypeNumeric},
+	}}
+	aggregates := model.SurveyResponseAggregates{Completed: 4, InProgress: 1,
+		Answered: map[string]int{"color": 4, "age": 2},
+		Values:   map[string][]model.SurveyResponseValueCount{"color": {{Value: "red", Count: 3}, {Value: "blue", Count: 1}}},
+		Numbers:  map[string][]float64{"age": {20, 30}},
+		Scores:   map[string][]float64{"total": {1, 2, 3}, "empty": {}},
+	}
+
+	results := buildSurveyResults(survey, model.SurveyResultsFilter{}, aggregates)
+	if results.Total != 4 || results.InProgress != 1 || results.CompletionRate == nil || *results.CompletionRate != 0.8 {
+		t.Errorf("buildSurveyResults() totals = %d, %d, %v", results.Total, results.InProgress, results.CompletionRate)
+	}
+	if _, ok := results.Questions["page"]; ok || len(results.Questions) != 2 {
+		t.Errorf("buildSurveyResults() questions = %+v, want the questions only", results.Questions)
+	}
+	if color := results.Questions["color"]; !reflect.DeepEqual(optionCounts(color.Options), map[string]int{"Red": 3, "Blue": 1}) ||
+		*color.AnswerRate != 1 || color.Numeric != nil {
+		t.Errorf("buildSurveyResults() color = %+v", color)
+	}
+	if age := results.Questions["age"]; *age.AnswerRate != 0.5 || age.Numeric == nil || age.Numeric.Mean != 25 || age.Options != nil {
+		t.Errorf("buildSurveyResults() age = %+v", age)
+	}
+	if _, ok := results.Scores["empty"]; ok || results.Scores["total"].Median != 2 {
+		t.Errorf("buildSurveyResults() scores = %+v", results.Scores)
+	}
+
+	if empty := buildSurveyResults(survey, model.SurveyResultsFilter{}, model.SurveyResponseAggregates{}); empty.CompletionRate != nil {
+		t.Errorf("buildSurveyResults() completion rate = %v, want nil without responses", *empty.CompletionRate)
+	}
+}
+
+func floatEqual(a float64, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestGetSurveyResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string
+		admin     bool
+		sensitive bool
+		wantErr   error
+		wantRed   int
+	}{
+		{"creator", "creator", false, false, nil, 2},
+		{"admin", "admin", true, false, nil, 2},
+		{"not the creator", "respondent", false, false, model.ErrNotSurveyCreator, 0},
+		{"sensitive survey with too few respondents", "creator", false, true, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newTestStorage()
+			survey := model.Survey{ID: "survey1", CreatorID: "creator", Sensitive: tt.sensitive, Data: map[string]model.SurveyData{
+				"color": {Type: surveyDataTypeMultipleChoice, Options: []model.OptionData{{Title: "Red", Value: "red"}, {Title: "Blue", Value: "blue"}}}}}
+			storage.surveys[survey.ID] = survey
+			for i, color := range []string{"red", "red", "blue"} {
+				response := model.SurveyResponse{ID: string(rune('a' + i)), Status: model.SurveyResponseStatusCompleted, Survey: survey}
+				response.Survey.Data = map[string]model.SurveyData{"color": {Response: color}}
+				storage.responses[response.ID] = response
+			}
+			// the responses are not encrypted without encryption keys
+			app := &Application{storage: storage, encryption: &encryption.Adapter{}, sensitiveSurveyMinRespondents: 5}
+
+			results, err := app.getSurveyResults(newTestUser(tt.user), "survey1", model.SurveyResultsFilter{}, tt.admin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("getSurveyResults() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if red := optionCounts(results.Questions["color"].Options)["Red"]; red != tt.wantRed || results.Suppressed != tt.sensitive {
+				t.Errorf("getSurveyResults() red = %d, suppressed = %v, want %d", red, results.Suppressed, tt.wantRed)
+			}
+		})
+	}
+}
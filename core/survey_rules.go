@@ -0,0 +1,642 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"polls/core/model"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The survey rules are JSON encoded strings. A rule result is one of:
+//   - a rule: {"condition": <condition>, "true_result": <result>, "false_result": <result>}
+//   - cases: {"cases": [<rule>, ...]} - gives the true_result of the first rule which condition is true
+//   - an action: {"action": "return" | "sum" | "set_result" | ..., "data": <value>}
+//   - an action list: {"actions": [<action>, ...]}
+//   - a reference: {"rule_key": "<sub rule key>"} - evaluates Survey.SubRules[<sub rule key>]
+//   - a list of results or a plain value
+//
+// A condition is one of:
+//   - a logic: {"operator": "and" | "or", "conditions": [<condition>, ...]}
+//   - a comparison: {"operator": "==" | "!=" | "<" | ">" | "<=" | ">=" | "any" | "all" | "in_range",
+//     "data_key": <key>, "compare_to": <value>, "default_result": <bool>}
+//
+// Values which are strings prefixed with "data.", "stats.", "constants." or "strings." are resolved as keys
+// against the survey, for example "data.q1.response", "data.q1.score" or "stats.scores.section1".
+
+const maxRuleDepth = 32
+
+const (
+	surveyDataTypeResult = "survey_data.result"
+	surveyDataTypePage   = "survey_data.page"
+)
+
+type surveyRulesEvaluator struct {
+	survey *model.Survey
+	scores map[string]float64
+	result interface{}
+	depth  int
+}
+
+func newSurveyRulesEvaluator(survey *model.Survey) *surveyRulesEvaluator {
+	return &surveyRulesEvaluator{survey: survey, scores: map[string]float64{}}
+}
+
+// evaluateSurvey computes the stats and the result of a survey response on the server, so that clients can not forge them.
+// Gives the keys of the survey data reached by the respondent
+func evaluateSurvey(survey *model.Survey) ([]string, error) {
+	e := newSurveyRulesEvaluator(survey)
+
+	keys, err := e.reachedDataKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := model.SurveyStats{Scores: map[string]float64{}, MaximumScores: map[string]float64{}}
+	if survey.SurveyStats != nil {
+		stats.ResponseData = survey.SurveyStats.ResponseData
+	}
+	survey.SurveyStats = &stats
+
+	for _, key := range keys {
+		data := survey.Data[key]
+		if data.Response == nil && data.DefaultResponseRule != nil {
+			data.Response, err = e.evaluateRuleString(*data.DefaultResponseRule)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating default response rule for %s - %s", key, err)
+			}
+			survey.Data[key] = data
+		}
+		if data.Type == surveyDataTypeResult || data.Type == surveyDataTypePage {
+			continue
+		}
+
+		stats.Total++
+		if data.Response != nil {
+			stats.Complete++
+		}
+
+		score, err := e.dataScore(key, data)
+		if err != nil {
+			return nil, err
+		}
+		if score == nil {
+			continue
+		}
+		e.scores[key] = *score
+
+		section := ""
+		if data.Section != nil {
+			section = *data.Section
+		}
+		stats.Scored++
+		stats.Scores[section] += *score
+		if data.MaximumScore != nil {
+			stats.MaximumScores[section] += *data.MaximumScore
+		}
+	}
+
+	survey.ResultJSON = ""
+	if len(survey.ResultRules) > 0 {
+		result, err := e.evaluateRuleString(survey.ResultRules)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating result rules - %s", err)
+		}
+		if e.result != nil {
+			result = e.result
+		}
+		if result != nil {
+			resultJSON, err := json.Marshal(result)
+			if err != nil {
+				return nil, fmt.Errorf("error encoding survey result - %s", err)
+			}
+			survey.ResultJSON = string(resultJSON)
+		}
+	}
+
+	return keys, nil
+}
+
+// reachedDataKeys gives the keys of the survey data which the respondent reached by following the survey flow
+func (e *surveyRulesEvaluator) reachedDataKeys() ([]string, error) {
+	start := ""
+	if e.survey.DefaultDataKeyRule != nil {
+		value, err := e.evaluateRuleString(*e.survey.DefaultDataKeyRule)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating default data key rule - %s", err)
+		}
+		if key, ok := value.(string); ok {
+			start = key
+		}
+	}
+	if len(start) == 0 && e.survey.DefaultDataKey != nil {
+		start = *e.survey.DefaultDataKey
+	}
+
+	// surveys without a flow are answered as a whole
+	if len(start) == 0 {
+		keys := make([]string, 0, len(e.survey.Data))
+		for key := range e.survey.Data {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+
+	visited := map[string]bool{}
+	keys := []string{}
+	var visit func(key string) error
+	visit = func(key string) error {
+		for len(key) > 0 && !visited[key] {
+			data, ok := e.survey.Data[key]
+			if !ok {
+				return nil
+			}
+			visited[key] = true
+			keys = append(keys, key)
+
+			for _, pageKey := range data.DataKeys {
+				if err := visit(pageKey); err != nil {
+					return err
+				}
+			}
+
+			next := ""
+			if data.FollowUpRule != nil {
+				value, err := e.evaluateRuleString(*data.FollowUpRule)
+				if err != nil {
+					return fmt.Errorf("error evaluating follow up rule for %s - %s", key, err)
+				}
+				if nextKey, ok := value.(string); ok {
+					next = nextKey
+				}
+			}
+			if len(next) == 0 && data.DefaultFollowUpKey != nil {
+				next = *data.DefaultFollowUpKey
+			}
+			key = next
+		}
+		return nil
+	}
+
+	err := visit(start)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// dataScore gives the score of a survey data. Gives nil if the survey data is not scored
+func (e *surveyRulesEvaluator) dataScore(key string, data model.SurveyData) (*float64, error) {
+	if data.ScoreRule != nil {
+		value, err := e.evaluateRuleString(*data.ScoreRule)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating score rule for %s - %s", key, err)
+		}
+		score, ok := toFloat(value)
+		if !ok {
+			return nil, nil
+		}
+		return &score, nil
+	}
+
+	if data.SelfScore != nil && *data.SelfScore {
+		score, ok := toFloat(data.Response)
+		if !ok {
+			return nil, nil
+		}
+		if data.MaximumScore != nil && score > *data.MaximumScore {
+			score = *data.MaximumScore
+		}
+		return &score, nil
+	}
+
+	if data.MaximumScore != nil && (data.CorrectAnswer != nil || len(data.CorrectAnswers) > 0) {
+		score := 0.0
+		if isCorrectResponse(data) {
+			score = *data.MaximumScore
+		}
+		return &score, nil
+	}
+
+	var score *float64
+	for _, option := range data.Options {
+		if option.Score == nil || !containsValue(data.Response, option.Value) {
+			continue
+		}
+		if score == nil {
+			score = new(float64)
+		}
+		*score += *option.Score
+	}
+	return score, nil
+}
+
+func isCorrectResponse(data model.SurveyData) bool {
+	if data.Response == nil {
+		return false
+	}
+	if data.CorrectAnswer != nil {
+		return valuesEqual(data.Response, data.CorrectAnswer)
+	}
+
+	responses := toList(data.Response)
+	if len(responses) != len(data.CorrectAnswers) {
+		return false
+	}
+	for _, answer := range data.CorrectAnswers {
+		if !containsValue(responses, answer) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *surveyRulesEvaluator) evaluateRuleString(rule string) (interface{}, error) {
+	var parsed interface{}
+	err := json.Unmarshal([]byte(rule), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %s - %s", rule, err)
+	}
+	return e.evaluateResult(parsed)
+}
+
+func (e *surveyRulesEvaluator) evaluateResult(result interface{}) (interface{}, error) {
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth > maxRuleDepth {
+		return nil, fmt.Errorf("rules are nested too deep")
+	}
+
+	switch value := result.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		results := []interface{}{}
+		for _, item := range value {
+			itemResult, err := e.evaluateResult(item)
+			if err != nil {
+				return nil, err
+			}
+			if itemResult != nil {
+				results = append(results, itemResult)
+			}
+		}
+		if len(results) == 0 {
+			return nil, nil
+		}
+		if len(results) == 1 {
+			return results[0], nil
+		}
+		return results, nil
+	case map[string]interface{}:
+		if condition, ok := value["condition"]; ok {
+			matched, err := e.evaluateCondition(condition)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				return e.evaluateResult(value["true_result"])
+			}
+			return e.evaluateResult(value["false_result"])
+		}
+		if cases, ok := value["cases"].([]interface{}); ok {
+			for _, item := range cases {
+				rule, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid case %v", item)
+				}
+				matched, err := e.evaluateCondition(rule["condition"])
+				if err != nil {
+					return nil, err
+				}
+				if matched {
+					return e.evaluateResult(rule["true_result"])
+				}
+			}
+			return nil, nil
+		}
+		if actions, ok := value["actions"].([]interface{}); ok {
+			return e.evaluateResult(actions)
+		}
+		if action, ok := value["action"].(string); ok {
+			return e.evaluateAction(action, value["data"])
+		}
+		if ruleKey, ok := value["rule_key"].(string); ok {
+			subRule, ok := e.survey.SubRules[ruleKey]
+			if !ok {
+				return nil, fmt.Errorf("missing sub rule %s", ruleKey)
+			}
+			if subRuleString, ok := subRule.(string); ok {
+				return e.evaluateRuleString(subRuleString)
+			}
+			return e.evaluateResult(normalizeValue(subRule))
+		}
+		return value, nil
+	default:
+		return e.resolveValue(value), nil
+	}
+}
+
+func (e *surveyRulesEvaluator) evaluateAction(action string, data interface{}) (interface{}, error) {
+	switch action {
+	case "return":
+		return e.resolveValue(data), nil
+	case "set_result":
+		e.result = e.resolveValue(data)
+		return e.result, nil
+	case "sum":
+		sum := 0.0
+		for _, item := range toList(e.resolveValue(data)) {
+			number, ok := toFloat(item)
+			if ok {
+				sum += number
+			}
+		}
+		return sum, nil
+	default:
+		// the rest of the actions (alerts, notifications, navigation...) are executed by the clients
+		return nil, nil
+	}
+}
+
+func (e *surveyRulesEvaluator) evaluateCondition(condition interface{}) (bool, error) {
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth > maxRuleDepth {
+		return false, fmt.Errorf("rules are nested too deep")
+	}
+
+	item, ok := condition.(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("invalid condition %v", condition)
+	}
+	operator, _ := item["operator"].(string)
+
+	if conditions, ok := item["conditions"].([]interface{}); ok {
+		switch operator {
+		case "and":
+			for _, subCondition := range conditions {
+				matched, err := e.evaluateCondition(subCondition)
+				if err != nil || !matched {
+					return false, err
+				}
+			}
+			return true, nil
+		case "or":
+			for _, subCondition := range conditions {
+				matched, err := e.evaluateCondition(subCondition)
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+			return false, nil
+		default:
+			return false, fmt.Errorf("invalid logic operator %s", operator)
+		}
+	}
+
+	defaultResult, _ := item["default_result"].(bool)
+	dataKey, _ := item["data_key"].(string)
+	value := e.resolveValue(dataKey)
+	compareTo := e.resolveValue(item["compare_to"])
+	if value == nil {
+		return defaultResult, nil
+	}
+
+	switch operator {
+	case "==":
+		return valuesEqual(value, compareTo), nil
+	case "!=":
+		return !valuesEqual(value, compareTo), nil
+	case "<", ">", "<=", ">=":
+		left, leftOk := toFloat(value)
+		right, rightOk := toFloat(compareTo)
+		if !leftOk || !rightOk {
+			return defaultResult, nil
+		}
+		switch operator {
+		case "<":
+			return left < right, nil
+		case ">":
+			return left > right, nil
+		case "<=":
+			return left <= right, nil
+		default:
+			return left >= right, nil
+		}
+	case "any":
+		for _, item := range toList(value) {
+			if containsValue(compareTo, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "all":
+		for _, item := range toList(compareTo) {
+			if !containsValue(value, item) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "in_range":
+		bounds := toList(compareTo)
+		number, ok := toFloat(value)
+		if len(bounds) != 2 || !ok {
+			return defaultResult, nil
+		}
+		min, minOk := toFloat(bounds[0])
+		max, maxOk := toFloat(bounds[1])
+		if !minOk || !maxOk {
+			return defaultResult, nil
+		}
+		return number >= min && number <= max, nil
+	default:
+		return false, fmt.Errorf("invalid comparison operator %s", operator)
+	}
+}
+
+func (e *surveyRulesEvaluator) resolveValue(value interface{}) interface{} {
+	switch item := value.(type) {
+	case string:
+		if resolved, ok := e.property(item); ok {
+			return resolved
+		}
+		return item
+	case []interface{}:
+		list := make([]interface{}, len(item))
+		for i, entry := range item {
+			list[i] = e.resolveValue(entry)
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// property gives the value of a survey key. The second result is false if the key does not reference the survey
+func (e *surveyRulesEvaluator) property(key string) (interface{}, bool) {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) < 2 {
+		return nil, false
+	}
+
+	switch parts[0] {
+	case "data":
+		data, ok := e.survey.Data[parts[1]]
+		if !ok {
+			return nil, true
+		}
+		field := "response"
+		if len(parts) == 3 {
+			field = parts[2]
+		}
+		switch field {
+		case "response":
+			return normalizeValue(data.Response), true
+		case "score":
+			if score, ok := e.scores[parts[1]]; ok {
+				return score, true
+			}
+			return nil, true
+		case "maximum_score":
+			if data.MaximumScore != nil {
+				return *data.MaximumScore, true
+			}
+			return nil, true
+		case "correct_answer":
+			return normalizeValue(data.CorrectAnswer), true
+		case "correct_answers":
+			return normalizeValue(data.CorrectAnswers), true
+		case "section":
+			if data.Section != nil {
+				return *data.Section, true
+			}
+			return nil, true
+		case "type":
+			return data.Type, true
+		}
+		return nil, true
+	case "stats":
+		stats := e.survey.SurveyStats
+		if stats == nil {
+			return nil, true
+		}
+		switch parts[1] {
+		case "total":
+			return float64(stats.Total), true
+		case "complete":
+			return float64(stats.Complete), true
+		case "scored":
+			return float64(stats.Scored), true
+		case "scores", "maximum_scores":
+			scores := stats.Scores
+			if parts[1] == "maximum_scores" {
+				scores = stats.MaximumScores
+			}
+			if len(parts) == 3 {
+				if score, ok := scores[parts[2]]; ok {
+					return score, true
+				}
+				return nil, true
+			}
+			total := 0.0
+			for _, score := range scores {
+				total += score
+			}
+			return total, true
+		}
+		return nil, true
+	case "constants":
+		return normalizeValue(e.survey.Constants[strings.TrimPrefix(key, "constants.")]), true
+	case "strings":
+		key = strings.TrimPrefix(key, "strings.")
+		if value, ok := e.survey.Strings[key]; ok {
+			return normalizeValue(value), true
+		}
+		// the strings of the per language string tables are given in the default language
+		if text, ok := surveyStringTables(*e.survey)[defaultLanguage][key]; ok {
+			return text, true
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// normalizeValue converts the values decoded from the storage to the types used by the JSON decoding
+func normalizeValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch value.(type) {
+	case string, bool, float64, []interface{}, map[string]interface{}:
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	if err != nil {
+		return value
+	}
+	return normalized
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := normalizeValue(value).(type) {
+	case float64:
+		return number, true
+	case string:
+		parsed, err := strconv.ParseFloat(number, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+func toList(value interface{}) []interface{} {
+	value = normalizeValue(value)
+	if value == nil {
+		return nil
+	}
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{value}
+}
+
+func valuesEqual(a interface{}, b interface{}) bool {
+	a = normalizeValue(a)
+	b = normalizeValue(b)
+	aNumber, aOk := a.(float64)
+	bNumber, bOk := toFloat(b)
+	if aOk && bOk {
+		return aNumber == bNumber
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsValue(list interface{}, value interface{}) bool {
+	for _, item := range toList(list) {
+		if valuesEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"polls/core/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func rulesTestSurvey() *model.Survey {
+	return &model.Survey{
+		Data: map[string]model.SurveyData{
+			"age":      {Type: surveyDataTypeNumeric, Response: 30.0},
+			"answer":   {Type: surveyDataTypeText, Response: "yes"},
+			"colors":   {Type: surveyDataTypeMultipleChoice, Response: []interface{}{"red", "blue"}},
+			"count":    {Type: surveyDataTypeText, Response: "4"},
+			"skipped":  {Type: surveyDataTypeText, AllowSkip: true},
+			"age_copy": {Type: surveyDataTypeNumeric, Response: 30.0},
+		},
+		Constants: map[string]interface{}{"adult": 18.0},
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+		wantErr   bool
+	}{
+		{"equal number", `{"operator": "==", "data_key": "data.age", "compare_to": 30}`, true, false},
+		{"equal number string", `{"operator": "==", "data_key": "data.age", "compare_to": "30"}`, true, false},
+		{"equal data", `{"operator": "==", "data_key": "data.age", "compare_to": "data.age_copy"}`, true, false},
+		{"equal string", `{"operator": "==", "data_key": "data.answer", "compare_to": "yes"}`, true, false},
+		{"not equal", `{"operator": "!=", "data_key": "data.answer", "compare_to": "no"}`, true, false},
+		{"less", `{"operator": "<", "data_key": "data.age", "compare_to": 31}`, true, false},
+		{"less equal", `{"operator": "<=", "data_key": "data.age", "compare_to": 30}`, true, false},
+		{"greater", `{"operator": ">", "data_key": "data.age", "compare_to": 30}`, false, false},
+		{"greater equal constant", `{"operator": ">=", "data_key": "data.age", "compare_to": "constants.adult"}`, true, false},
+		{"greater numeric string", `{"operator": ">", "data_key": "data.count", "compare_to": 3}`, true, false},
+		{"compare not a number", `{"operator": "<", "data_key": "data.answer", "compare_to": 3, "default_result": true}`, true, false},
+		{"any", `{"operator": "any", "data_key": "data.colors", "compare_to": ["green", "blue"]}`, true, false},
+		{"any none", `{"operator": "any", "data_key": "data.colors", "compare_to": ["green"]}`, false, false},
+		{"all", `{"operator": "all", "data_key": "data.colors", "compare_to": ["red", "blue"]}`, true, false},
+		{"all missing", `{"operator": "all", "data_key": "data.colors", "compare_to": ["red", "green"]}`, false, false},
+		{"in range", `{"operator": "in_range", "data_key": "data.age", "compare_to": [18, 30]}`, true, false},
+		{"out of range", `{"operator": "in_range", "data_key": "data.age", "compare_to": [31, 40]}`, false, false},
+		{"invalid range", `{"operator": "in_range", "data_key": "data.age", "compare_to": [18], "default_result": true}`, true, false},
+		{"no response default", `{"operator": "==", "data_key": "data.skipped", "compare_to": "x", "default_result": true}`, true, false},
+		{"no response", `{"operator": "==", "data_key": "data.skipped", "compare_to": "x"}`, false, false},
+		{"and", `{"operator": "and", "conditions": [{"operator": "==", "data_key": "data.answer", "compare_to": "yes"}, {"operator": ">", "data_key": "data.age", "compare_to": 18}]}`, true, false},
+		{"and false", `{"operator": "and", "conditions": [{"operator": "==", "data_key": "data.answer", "compare_to": "yes"}, {"operator": ">", "data_key": "data.age", "compare_to": 40}]}`, false, false},
+		{"or", `{"operator": "or", "conditions": [{"operator": "==", "data_key": "data.answer", "compare_to": "no"}, {"operator": ">", "data_key": "data.age", "compare_to": 18}]}`, true, false},
+		{"or false", `{"operator": "or", "conditions": [{"operator": "==", "data_key": "data.answer", "compare_to": "no"}]}`, false, false},
+		{"invalid logic operator", `{"operator": "xor", "conditions": []}`, false, true},
+		{"invalid comparison operator", `{"operator": "~", "data_key": "data.age", "compare_to": 1}`, false, true},
+		{"invalid condition", `"data.age"`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newSurveyRulesEvaluator(rulesTestSurvey())
+			rule := fmt.Sprintf(`{"condition": %s, "true_result": true, "false_result": false}`, tt.condition)
+			got, err := e.evaluateRuleString(rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluateRuleString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("evaluateRuleString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		want       interface{}
+		wantResult interface{}
+	}{
+		{"plain value", `"done"`, "done", nil},
+		{"data value", `"data.answer"`, "yes", nil},
+		{"return", `{"action": "return", "data": "data.age"}`, 30.0, nil},
+		{"sum", `{"action": "sum", "data": ["data.age", "data.count", "data.answer"]}`, 34.0, nil},
+		{"set result", `{"action": "set_result", "data": "adult"}`, "adult", "adult"},
+		{"client action", `{"action": "alert", "data": "text"}`, nil, nil},
+		{"actions", `{"actions": [{"action": "return", "data": "a"}, {"action": "alert"}]}`, "a", nil},
+		{"cases", `{"cases": [{"condition": {"operator": "<", "data_key": "data.age", "compare_to": 18}, "true_result": "minor"}, {"condition": {"operator": ">=", "data_key": "data.age", "compare_to": 18}, "true_result": "adult"}]}`, "adult", nil},
+		{"no case", `{"cases": [{"condition": {"operator": "<", "data_key": "data.age", "compare_to": 18}, "true_result": "minor"}]}`, nil, nil},
+		{"sub rule", `{"rule_key": "greeting"}`, "hello", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := rulesTestSurvey()
+			survey.SubRules = map[string]interface{}{"greeting": `{"action": "return", "data": "hello"}`}
+			e := newSurveyRulesEvaluator(survey)
+			got, err := e.evaluateRuleString(tt.rule)
+			if err != nil {
+				t.Fatalf("evaluateRuleString() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluateRuleString() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(e.result, tt.wantResult) {
+				t.Errorf("evaluateRuleString() result = %v, want %v", e.result, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleDepth(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat(`{"actions": [`, depth) + `"leaf"` + strings.Repeat(`]}`, depth)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		subRules map[string]interface{}
+		wantErr  bool
+	}{
+		{"within the max depth", nested(maxRuleDepth/2 - 1), nil, false},
+		{"beyond the max depth", nested(maxRuleDepth), nil, true},
+		{"sub rule cycle", `{"rule_key": "a"}`, map[string]interface{}{"a": `{"rule_key": "b"}`, "b": `{"rule_key": "a"}`}, true},
+		{"missing sub rule", `{"rule_key": "missing"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := rulesTestSurvey()
+			survey.SubRules = tt.subRules
+			_, err := newSurveyRulesEvaluator(survey).evaluateRuleString(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("evaluateRuleString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReachedDataKeys(t *testing.T) {
+	first := "q1"
+	followUp := `{"condition": {"operator": "==", "data_key": "data.q1", "compare_to": "yes"}, "true_result": "q2", "false_result": "q3"}`
+
+	tests := []struct {
+		name     string
+		response interface{}
+		want     []string
+	}{
+		{"true branch", "yes", []string{"q1", "q2"}},
+		{"false branch", "no", []string{"q1", "q3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := &model.Survey{DefaultDataKey: &first, Data: map[string]model.SurveyData{
+				"q1": {Type: surveyDataTypeText, Response: tt.response, FollowUpRule: &followUp},
+				"q2": {Type: surveyDataTypeText},
+				"q3": {Type: surveyDataTypeText, DefaultFollowUpKey: &first},
+			}}
+			got, err := newSurveyRulesEvaluator(survey).reachedDataKeys()
+			if err != nil {
+				t.Fatalf("reachedDataKeys() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reachedDataKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"polls/core/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// the timezone of the scheduled surveys and the users which do not set one
+const defaultSurveyScheduleTimezone = "America/Chicago"
+
+// the reminder text of the scheduled surveys which do not set one. The survey strings translate it with this text as the key
+const defaultSurveyReminderText = "Reminder: please respond to the survey '%s'."
+
+// surveyScheduleOccurrence gives the start of the current period of a scheduled survey and the time of its reminder,
+// in the given location. The period starts at the midnight of the last scheduled day. Gives false if the schedule has no days
+func surveyScheduleOccurrence(schedule model.SurveySchedule, now time.Time, location *time.Location) (time.Time, time.Time, bool) {
+	local := now.In(location)
+	for days := 0; days <= 7; days++ {
+		periodStart := time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, location)
+		if !isSurveyScheduleDay(schedule, periodStart.Weekday()) {
+			continue
+		}
+		reminder := time.Date(periodStart.Year(), periodStart.Month(), periodStart.Day(), schedule.Hour, schedule.Minute, 0, 0, location)
+		if reminder.After(now) {
+			continue
+		}
+		return periodStart, reminder, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// isSurveyScheduleDay checks if a period of a scheduled survey starts on the weekday
+func isSurveyScheduleDay(schedule model.SurveySchedule, weekday time.Weekday) bool {
+	if schedule.Frequency == model.SurveyScheduleFrequencyDaily {
+		return true
+	}
+	for _, day := range schedule.Weekdays {
+		if time.Weekday(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// surveyReminderAudience gives the users targeted by a survey: its to members list and the members of its groups.
+// The users of an app are not known, so the surveys for everyone target the users who responded to them before
+// and the users who set their reminder settings. The groups which can not be loaded are reported in the error
+func (app *Application) surveyReminderAudience(survey model.Survey) ([]string, error) {
+	userIDs := []string{}
+	added := map[string]bool{}
+	add := func(ids []string) {
+		for _, id := range ids {
+			if len(id) > 0 && !added[id] {
+				added[id] = true
+				userIDs = append(userIDs, id)
+			}
+		}
+	}
+
+	if survey.IsForEveryone() {
+		respondents, err := app.storage.GetSurveyRespondents(survey.AppID, survey.OrgID, survey.ID)
+		if err != nil {
+			return nil, err
+		}
+		add(respondents)
+		registered, err := app.storage.GetSurveyReminderUserIDs(survey.AppID, survey.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		add(registered)
+		return userIDs, nil
+	}
+
+	for _, member := range survey.ToMembersList {
+		add([]string{member.UserID})
+	}
+	var errs []error
+	for _, groupID := range survey.GroupIDs {
+		members, err := app.groups.GetGroupMemberIDs(groupID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		add(members)
+	}
+	return userIDs, errors.Join(errs...)
+}
+
+// remindSurvey reminds the audience of a scheduled survey who did not respond in their current period and did not opt out.
+// The users are reminded even if some of them can not be processed, and the errors are returned
+func (app *Application) remindSurvey(survey model.Survey, now time.Time) error {
+	if survey.Schedule == nil {
+		return nil
+	}
+
+	var errs []error
+	userIDs, err := app.surveyReminderAudience(survey)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(userIDs) == 0 {
+		return errors.Join(errs...)
+	}
+
+	settingsList, err := app.storage.FindSurveyReminderSettings(survey.AppID, survey.OrgID, userIDs)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	settings := make(map[string]model.SurveyReminderSettings, len(settingsList))
+	for _, entry := range settingsList {
+		settings[entry.UserID] = entry
+	}
+
+	scheduleTimezone := defaultSurveyScheduleTimezone
+	if survey.Schedule.Timezone != nil && len(*survey.Schedule.Timezone) > 0 {
+		scheduleTimezone = *survey.Schedule.Timezone
+	}
+	scheduleLocation, err := time.LoadLocation(scheduleTimezone)
+	if err != nil {
+		return errors.Join(append(errs, fmt.Errorf("error on Application.remindSurvey(%s) - %s", survey.ID, err))...)
+	}
+
+	periods := map[string]time.Time{}
+	var since *time.Time
+	for _, userID := range userIDs {
+		location := scheduleLocation
+		if entry, ok := settings[userID]; ok {
+			if entry.OptOut || containsString(entry.OptOutSurveyIDs, survey.ID) {
+				continue
+			}
+			if entry.Timezone != nil && len(*entry.Timezone) > 0 {
+				if userLocation, err := time.LoadLocation(*entry.Timezone); err == nil {
+					location = userLocation
+				}
+			}
+		}
+
+		periodStart, reminder, ok := surveyScheduleOccurrence(*survey.Schedule, now, location)
+		if !ok || (survey.StartDate != nil && reminder.Before(*survey.StartDate)) {
+			continue
+		}
+		periods[userID] = periodStart
+		if since == nil || periodStart.Before(*since) {
+			since = &periodStart
+		}
+	}
+	if since == nil {
+		return errors.Join(errs...)
+	}
+
+	pending := make([]string, 0, len(periods))
+	for userID := range periods {
+		pending = append(pending, userID)
+	}
+	responded, err := app.storage.GetSurveyLastResponseDates(survey.AppID, survey.OrgID, survey.ID, pending, *since)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	var recipients []string
+	for _, userID := range pending {
+		periodStart := periods[userID]
+		if date, ok := responded[userID]; ok && !date.Before(periodStart) {
+			continue
+		}
+
+		// record the reminder first, so that a user is reminded at most once per period
+		created, err := app.storage.CreateSurveyReminder(model.SurveyReminder{
+			ID:          fmt.Sprintf("%s_%s_%s", survey.ID, userID, periodStart.UTC().Format(time.RFC3339)),
+			OrgID:       survey.OrgID,
+			AppID:       survey.AppID,
+			SurveyID:    survey.ID,
+			UserID:      userID,
+			PeriodStart: periodStart,
+			DateCreated: now,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if created {
+			recipients = append(recipients, userID)
+		}
+	}
+	if len(recipients) == 0 {
+		return errors.Join(errs...)
+	}
+
+	// the users set their language in their reminder settings, otherwise the to members list may give it
+	languages := map[string]string{}
+	for _, member := range survey.ToMembersList {
+		languages[member.UserID] = member.Language
+	}
+	for userID, entry := range settings {
+		if entry.Language != nil && len(*entry.Language) > 0 {
+			languages[userID] = *entry.Language
+		}
+	}
+
+	// the reminders are recorded already, so the users who do not get them are not reminded again in the period
+	for _, message := range surveyReminderMessages(survey, recipients, languages) {
+		err = app.notifications.DeliverNotification(message)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error on Application.remindSurvey(%s) - %d users were not reminded: %s", survey.ID,
+				len(message.Message.Recipients), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// surveyReminderMessages gives the reminders of a survey for the users, one per language. The title and the reminder text are localized
+// with the survey strings
+func surveyReminderMessages(survey model.Survey, userIDs []string, languages map[string]string) []model.NotificationMessage {
+	order := []string{}
+	byLanguage := map[string][]model.UserRef{}
+	for _, userID := range userIDs {
+		language := strings.ToLower(strings.TrimSpace(languages[userID]))
+		if _, ok := byLanguage[language]; !ok {
+			order = append(order, language)
+		}
+		byLanguage[language] = append(byLanguage[language], model.UserRef{UserID: userID})
+	}
+
+	tables := surveyStringTables(survey)
+	topic := "surveys"
+	messages := make([]model.NotificationMessage, len(order))
+	for i, language := range order {
+		localized := survey
+		localizeSurvey(&localized, []string{language})
+
+		var body string
+		if localized.Schedule != nil && localized.Schedule.ReminderText != nil && len(*localized.Schedule.ReminderText) > 0 {
+			body = *localized.Schedule.ReminderText
+		} else {
+			format := defaultSurveyReminderText
+			for _, candidate := range languageCandidates([]string{language}) {
+				if translated, ok := tables[candidate][defaultSurveyReminderText]; ok && strings.Count(translated, "%s") == 1 {
+					format = translated
+					break
+				}
+			}
+			body = fmt.Sprintf(format, localized.Title)
+		}
+
+		messages[i] = model.NotificationMessage{
+			Message: model.InnerMessage{
+				AppID:      survey.AppID,
+				OrgID:      survey.OrgID,
+				Recipients: byLanguage[language],
+				Sender:     &model.Sender{Type: "system"},
+				Topic:      &topic,
+				Subject:    localized.Title,
+				Body:       body,
+				Data: map[string]string{
+					"type":        "survey",
+					"operation":   "survey_reminder",
+					"entity_type": "survey",
+					"entity_id":   survey.ID,
+					"entity_name": localized.Title,
+				},
+			},
+		}
+	}
+	return messages
+}
+
+// getSurveyReminderSettings gives the survey reminder settings of a user, or the defaults if the user has not set them
+func (app *Application) getSurveyReminderSettings(user *model.User) (*model.SurveyReminderSettings, error) {
+	settings, err := app.storage.GetSurveyReminderSettings(user.Claims.AppID, user.Claims.OrgID, user.Claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &model.SurveyReminderSettings{OrgID: user.Claims.OrgID, AppID: user.Claims.AppID, UserID: user.Claims.Subject, OptOutSurveyIDs: []string{}}
+	}
+	return settings, nil
+}
+
+// updateSurveyReminderSettings sets the timezone of a user and the reminders the user opts out of
+func (app *Application) updateSurveyReminderSettings(user *model.User, settings model.SurveyReminderSettings) (*model.SurveyReminderSettings, error) {
+	if settings.Timezone != nil && len(*settings.Timezone) > 0 {
+		if _, err := time.LoadLocation(*settings.Timezone); err != nil {
+			return nil, fmt.Errorf("error on Application.updateSurveyReminderSettings(%s) - %w", *settings.Timezone, model.ErrInvalidSurveyReminderSettings)
+		}
+	}
+	if settings.OptOutSurveyIDs == nil {
+		settings.OptOutSurveyIDs = []string{}
+	}
+
+	now := time.Now().UTC()
+	settings.ID = uuid.NewString()
+	settings.OrgID = user.Claims.OrgID
+	settings.AppID = user.Claims.AppID
+	settings.UserID = user.Claims.Subject
+	settings.DateCreated = now
+	settings.DateUpdated = &now
+	err := app.storage.SaveSurveyReminderSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	return app.getSurveyReminderSettings(user)
+}
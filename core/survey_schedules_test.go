@@ -0,0 +1,113 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSurveyScheduleOccurrence(t *testing.T) {
+	location, err := time.LoadLocation(defaultSurveyScheduleTimezone)
+	if err != nil {
+		t.Skipf("timezone %s is not available - %s", defaultSurveyScheduleTimezone, err)
+	}
+	local := func(day int, hour int, minute int) time.Time {
+		// October 2026 starts on a Thursday
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, location)
+	}
+	daily := model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyDaily, Hour: 9, Minute: 30}
+	weekly := model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly, Weekdays: []int{int(time.Monday), int(time.Thursday)}, Hour: 18}
+
+	tests := []struct {
+		name         string
+		schedule     model.SurveySchedule
+		now          time.Time
+		wantStart    time.Time
+		wantReminder time.Time
+		wantOk       bool
+	}{
+		{"daily after the reminder", daily, local(19, 10, 0), local(19, 0, 0), local(19, 9, 30), true},
+		{"daily at the reminder", daily, local(19, 9, 30), local(19, 0, 0), local(19, 9, 30), true},
+		{"daily before the reminder", daily, local(19, 9, 0), local(18, 0, 0), local(18, 9, 30), true},
+		{"weekly on a scheduled day", weekly, local(19, 20, 0), local(19, 0, 0), local(19, 18, 0), true},
+		{"weekly between the scheduled days", weekly, local(21, 12, 0), local(19, 0, 0), local(19, 18, 0), true},
+		{"weekly before the reminder", weekly, local(22, 17, 0), local(19, 0, 0), local(19, 18, 0), true},
+		{"weekly a week back", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly, Weekdays: []int{int(time.Monday)}, Hour: 18},
+			local(19, 17, 0), local(12, 0, 0), local(12, 18, 0), true},
+		{"utc time", daily, local(19, 10, 0).UTC(), local(19, 0, 0), local(19, 9, 30), true},
+		{"no days", model.SurveySchedule{Frequency: model.SurveyScheduleFrequencyWeekly}, local(19, 10, 0), time.Time{}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, reminder, ok := surveyScheduleOccurrence(tt.schedule, tt.now, location)
+			if ok != tt.wantOk || !start.Equal(tt.wantStart) || !reminder.Equal(tt.wantReminder) {
+				t.Errorf("surveyScheduleOccurrence() = %v, %v, %v, want %v, %v, %v", start, reminder, ok, tt.wantStart, tt.wantReminder, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSurveyReminderMessages(t *testing.T) {
+	reminderText := "Please respond"
+	strings := map[string]interface{}{
+		"es": map[string]interface{}{"Wellness": "Bienestar", "Please respond": "Por favor responda",
+			defaultSurveyReminderText: "Recordatorio: responda la encuesta '%s'."},
+		"fr": map[string]interface{}{"Wellness": "Bien-etre", defaultSurveyReminderText: "Rappel sans titre"},
+	}
+
+	tests := []struct {
+		name         string
+		reminderText *string
+		languages    map[string]string
+		want         map[string][]string // the subject and the body by the recipients
+	}{
+		{"default text", nil, map[string]string{"u1": "es", "u2": "", "u3": " ES"},
+			map[string][]string{"u1,u3": {"Bienestar", "Recordatorio: responda la encuesta 'Bienestar'."},
+				"u2": {"Wellness", "Reminder: please respond to the survey 'Wellness'."}}},
+		{"invalid translation of the default text", nil, map[string]string{"u1": "fr", "u2": "fr", "u3": "fr"},
+			map[string][]string{"u1,u2,u3": {"Bien-etre", "Reminder: please respond to the survey 'Bien-etre'."}}},
+		{"reminder text", &reminderText, map[string]string{"u1": "es-MX", "u2": "de"},
+			map[string][]string{"u1": {"Bienestar", "Por favor responda"}, "u2": {"Wellness", "Please respond"}, "u3": {"Wellness", "Please respond"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := model.Survey{ID: "survey1", Title: "Wellness", Strings: strings, Schedule: &model.SurveySchedule{ReminderText: tt.reminderText}}
+			messages := surveyReminderMessages(survey, []string{"u1", "u2", "u3"}, tt.languages)
+
+			got := map[string][]string{}
+			for _, message := range messages {
+				userIDs := ""
+				for i, recipient := range message.Message.Recipients {
+					if i > 0 {
+						userIDs += ","
+					}
+					userIDs += recipient.UserID
+				}
+				got[userIDs] = []string{message.Message.Subject, message.Message.Body}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("surveyReminderMessages() = %v, want %v", got, tt.want)
+			}
+			if survey.Title != "Wellness" || (tt.reminderText != nil && *survey.Schedule.ReminderText != reminderText) {
+				t.Errorf("surveyReminderMessages() changed the survey")
+			}
+		})
+	}
+}
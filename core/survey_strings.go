@@ -0,0 +1,198 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"polls/core/model"
+	"sort"
+	"strings"
+)
+
+// The strings of a survey are per language string tables: {"<language>": {"<key>": "<text>", ...}, ...}.
+// The localizable texts of a survey - its title and more info, the text, the more info and the option titles of its data, and the
+// reminder text of its schedule - are keys of the string tables, or texts in the default language which are not translated
+
+// the language of the survey texts which are not keys of the string tables and of the polls which do not specify their language
+const defaultLanguage = "en"
+
+// surveyStringTables gives the per language string tables of a survey. The entries which are not strings are ignored
+func surveyStringTables(survey model.Survey) map[string]map[string]string {
+	tables := map[string]map[string]string{}
+	for language, entries := range survey.Strings {
+		table, ok := normalizeValue(entries).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tables[strings.ToLower(language)] = map[string]string{}
+		for key, value := range table {
+			if text, ok := value.(string); ok {
+				tables[strings.ToLower(language)][key] = text
+			}
+		}
+	}
+	return tables
+}
+
+// invalidSurveyStrings gives the languages of the strings of a survey which are not string tables
+func invalidSurveyStrings(survey model.Survey) []string {
+	invalid := []string{}
+	for language, entries := range survey.Strings {
+		table, ok := normalizeValue(entries).(map[string]interface{})
+		if !ok {
+			invalid = append(invalid, language)
+			continue
+		}
+		for _, value := range table {
+			if _, ok := value.(string); !ok {
+				invalid = append(invalid, language)
+				break
+			}
+		}
+	}
+	sort.Strings(invalid)
+	return invalid
+}
+
+// localizeSurvey replaces the localizable texts of a survey with their translations in the first of the languages which has them.
+// The languages fall back to their base languages and then to the default language
+func localizeSurvey(survey *model.Survey, languages []string) {
+	tables := surveyStringTables(*survey)
+	if len(tables) == 0 {
+		return
+	}
+
+	candidates := languageCandidates(languages)
+	mapSurveyTexts(survey, func(path string, text string) string {
+		for _, language := range candidates {
+			if translated, ok := tables[language][text]; ok {
+				return translated
+			}
+		}
+		return text
+	})
+}
+
+// languageCandidates gives the languages in preference order, every language followed by its base language, and the default language last
+func languageCandidates(languages []string) []string {
+	candidates := []string{}
+	added := map[string]bool{}
+	add := func(language string) {
+		if len(language) > 0 && !added[language] {
+			added[language] = true
+			candidates = append(candidates, language)
+		}
+	}
+	for _, language := range languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		add(language)
+		if base, _, found := strings.Cut(language, "-"); found {
+			add(base)
+		}
+	}
+	add(defaultLanguage)
+	return candidates
+}
+
+// getSurveyTranslations reports the localizable texts of a survey which are not translated, for the languages of its string tables,
+// the default language and the given languages
+func (app *Application) getSurveyTranslations(user *model.User, id string, languages []string) (*model.SurveyTranslations, error) {
+	survey, err := app.storage.GetSurvey(user, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = app.resolveSurveyQuestions(survey)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := surveyStringTables(*survey)
+	keys := map[string]bool{}
+	reported := map[string]bool{defaultLanguage: true}
+	for language, table := range tables {
+		reported[language] = true
+		for key := range table {
+			keys[key] = true
+		}
+	}
+	for _, language := range languages {
+		language = strings.ToLower(strings.TrimSpace(language))
+		if len(language) > 0 {
+			reported[language] = true
+		}
+	}
+
+	translations := model.SurveyTranslations{SurveyID: survey.ID, Languages: []string{}, Untranslated: map[string][]model.SurveyUntranslatedString{}}
+	for language := range reported {
+		translations.Languages = append(translations.Languages, language)
+		translations.Untranslated[language] = []model.SurveyUntranslatedString{}
+	}
+	sort.Strings(translations.Languages)
+
+	mapSurveyTexts(survey, func(path string, text string) string {
+		if len(text) == 0 {
+			return text
+		}
+		for _, language := range translations.Languages {
+			if _, ok := tables[language][text]; ok {
+				continue
+			}
+			// the texts which are not keys are written in the default language
+			if language == defaultLanguage && !keys[text] {
+				continue
+			}
+			translations.Untranslated[language] = append(translations.Untranslated[language], model.SurveyUntranslatedString{Path: path, Text: text})
+		}
+		return text
+	})
+	for _, untranslated := range translations.Untranslated {
+		sort.Slice(untranslated, func(i, j int) bool {
+			return untranslated[i].Path < untranslated[j].Path
+		})
+	}
+	return &translations, nil
+}
+
+// mapSurveyTexts replaces every localizable text of a survey with the result of fn. The path locates the text in the survey
+func mapSurveyTexts(survey *model.Survey, fn func(path string, text string) string) {
+	survey.Title = fn("title", survey.Title)
+	if survey.MoreInfo != nil {
+		moreInfo := fn("more_info", *survey.MoreInfo)
+		survey.MoreInfo = &moreInfo
+	}
+
+	data := make(map[string]model.SurveyData, len(survey.Data))
+	for key, item := range survey.Data {
+		item.Text = fn(fmt.Sprintf("data.%s.text", key), item.Text)
+		item.MoreInfo = fn(fmt.Sprintf("data.%s.more_info", key), item.MoreInfo)
+		if len(item.Options) > 0 {
+			options := make([]model.OptionData, len(item.Options))
+			for i, option := range item.Options {
+				option.Title = fn(fmt.Sprintf("data.%s.options.%d.title", key, i), option.Title)
+				options[i] = option
+			}
+			item.Options = options
+		}
+		data[key] = item
+	}
+	survey.Data = data
+
+	if survey.Schedule != nil && survey.Schedule.ReminderText != nil {
+		schedule := *survey.Schedule
+		reminderText := fn("schedule.reminder_text", *schedule.ReminderText)
+		schedule.ReminderText = &reminderText
+		survey.Schedule = &schedule
+	}
+}
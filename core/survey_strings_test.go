@@ -0,0 +1,161 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+func stringsTestSurvey() model.Survey {
+	moreInfo := "survey.info"
+	return model.Survey{ID: "survey1", CreatorID: "creator", Status: model.SurveyStatusPublished, Title: "survey.title", MoreInfo: &moreInfo,
+		Data: map[string]model.SurveyData{
+			"q1": {Type: surveyDataTypeMultipleChoice, Text: "q1.text", Options: []model.OptionData{{Title: "yes", Value: 1}, {Title: "No", Value: 0}}},
+			"q2": {Type: surveyDataTypeText, Text: "Anything else?"},
+		},
+		Strings: map[string]interface{}{
+			"en": map[string]interface{}{"survey.title": "Wellness", "survey.info": "About you", "q1.text": "Do you sleep well?", "yes": "Yes"},
+			"ES": map[string]interface{}{"survey.title": "Bienestar", "q1.text": "¿Duerme bien?", "yes": "Sí", "No": "No", "Anything else?": "¿Algo más?"},
+			"fr": "not a table",
+		},
+	}
+}
+
+func TestLanguageCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		want      []string
+	}{
+		{"no languages", nil, []string{"en"}},
+		{"language with a region", []string{"es-MX"}, []string{"es-mx", "es", "en"}},
+		{"several languages", []string{" FR-ca ", "es", "fr"}, []string{"fr-ca", "fr", "es", "en"}},
+		{"default language", []string{"en-US", "es"}, []string{"en-us", "en", "es"}},
+		{"empty language", []string{""}, []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := languageCandidates(tt.languages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("languageCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalizeSurvey(t *testing.T) {
+	tests := []struct {
+		name         string
+		languages    []string
+		wantTitle    string
+		wantMoreInfo string
+		wantQ1       string
+		wantOptions  []string
+		wantQ2       string
+	}{
+		{"default language", nil, "Wellness", "About you", "Do you sleep well?", []string{"Yes", "No"}, "Anything else?"},
+		{"translated language", []string{"es-MX"}, "Bienestar", "About you", "¿Duerme bien?", []string{"Sí", "No"}, "¿Algo más?"},
+		{"untranslated language", []string{"de"}, "Wellness", "About you", "Do you sleep well?", []string{"Yes", "No"}, "Anything else?"},
+		{"invalid string table", []string{"fr"}, "Wellness", "About you", "Do you sleep well?", []string{"Yes", "No"}, "Anything else?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := stringsTestSurvey()
+			localizeSurvey(&survey, tt.languages)
+
+			options := []string{}
+			for _, option := range survey.Data["q1"].Options {
+				options = append(options, option.Title)
+			}
+			if survey.Title != tt.wantTitle || *survey.MoreInfo != tt.wantMoreInfo || survey.Data["q1"].Text != tt.wantQ1 ||
+				!reflect.DeepEqual(options, tt.wantOptions) || survey.Data["q2"].Text != tt.wantQ2 {
+				t.Errorf("localizeSurvey() = %s, %s, %s, %v, %s", survey.Title, *survey.MoreInfo, survey.Data["q1"].Text, options, survey.Data["q2"].Text)
+			}
+		})
+	}
+}
+
+func TestGetSurveyLocalization(t *testing.T) {
+	tests := []struct {
+		name      string
+		admin     bool
+		languages []string
+		wantTitle string
+	}{
+		{"user in the default language", false, nil, "Wellness"},
+		{"user in a requested language", false, []string{"es"}, "Bienestar"},
+		{"admin without languages", true, nil, "survey.title"},
+		{"admin in a requested language", true, []string{"es"}, "Bienestar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := newTestStorage()
+			storage.surveys["survey1"] = stringsTestSurvey()
+			app := &Application{storage: storage}
+
+			survey, err := app.getSurvey(newTestUser("respondent"), "survey1", tt.admin, tt.languages)
+			if err != nil || survey == nil {
+				t.Fatalf("getSurvey() = %v, %v", survey, err)
+			}
+			if survey.Title != tt.wantTitle {
+				t.Errorf("getSurvey() title = %s, want %s", survey.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestGetSurveyTranslations(t *testing.T) {
+	storage := newTestStorage()
+	storage.surveys["survey1"] = stringsTestSurvey()
+	app := &Application{storage: storage}
+
+	translations, err := app.getSurveyTranslations(newTestUser("admin"), "survey1", []string{"de"})
+	if err != nil {
+		t.Fatalf("getSurveyTranslations() error = %v", err)
+	}
+	if want := []string{"de", "en", "es"}; !reflect.DeepEqual(translations.Languages, want) {
+		t.Errorf("getSurveyTranslations() languages = %v, want %v", translations.Languages, want)
+	}
+
+	paths := func(language string) []string {
+		result := []string{}
+		for _, untranslated := range translations.Untranslated[language] {
+			result = append(result, untranslated.Path)
+		}
+		return result
+	}
+	want := map[string][]string{
+		"en": {"data.q1.options.1.title", "data.q2.text"},
+		"es": {"more_info"},
+		"de": {"data.q1.options.0.title", "data.q1.options.1.title", "data.q1.text", "data.q2.text", "more_info", "title"},
+	}
+	for language, wantPaths := range want {
+		if got := paths(language); !reflect.DeepEqual(got, wantPaths) {
+			t.Errorf("getSurveyTranslations() %s untranslated = %v, want %v", language, got, wantPaths)
+		}
+	}
+}
+
+func TestInvalidSurveyStrings(t *testing.T) {
+	survey := stringsTestSurvey()
+	survey.Strings["de"] = map[string]interface{}{"survey.title": 1}
+	if got, want := invalidSurveyStrings(survey), []string{"de", "fr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("invalidSurveyStrings() = %v, want %v", got, want)
+	}
+}
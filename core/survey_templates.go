@@ -0,0 +1,146 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"polls/core/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// the permission of the admins who can clone the surveys to the other apps and orgs
+const allAppsSurveysPermission = "all_apps_surveys"
+
+// cloneSurvey creates a draft copy of a survey in the same or in another app and org. The audience, the group quotas and the references
+// to the question bank are kept only in the same app and org, as the groups, the users and the questions belong to it.
+// Only the system admins and the admins with the all apps surveys permission can clone to another app or org
+func (app *Application) cloneSurvey(user *model.User, id string, clone model.SurveyClone) (*model.Survey, error) {
+	stored, err := app.storage.GetSurvey(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	survey := model.NewSurveyBundle(*stored, time.Now().UTC()).ToSurvey()
+	survey.Status = model.SurveyStatusDraft
+	survey.AppID = stored.AppID
+	survey.OrgID = stored.OrgID
+	if clone.Title != nil {
+		survey.Title = *clone.Title
+	}
+	if clone.AppID != nil {
+		survey.AppID = *clone.AppID
+	}
+	if clone.OrgID != nil {
+		survey.OrgID = *clone.OrgID
+	}
+	if (survey.AppID != user.Claims.AppID || survey.OrgID != user.Claims.OrgID) && !canAccessAllApps(user) {
+		return nil, fmt.Errorf("error on Application.cloneSurvey(%s) - %w", id, model.ErrSurveyCloneNotAllowed)
+	}
+	if survey.AppID == stored.AppID && survey.OrgID == stored.OrgID {
+		survey.GroupIDs = stored.GroupIDs
+		survey.ToMembersList = stored.ToMembersList
+		survey.Quotas = stored.Quotas
+		for key, data := range stored.Data {
+			if data.QuestionID != nil {
+				cloned := survey.Data[key]
+				cloned.QuestionID = data.QuestionID
+				survey.Data[key] = cloned
+			}
+		}
+	}
+
+	return app.insertSurvey(user, survey)
+}
+
+// canAccessAllApps checks if the admin can manage the surveys of all the apps and orgs
+func canAccessAllApps(user *model.User) bool {
+	if user.Claims.System {
+		return true
+	}
+	for _, permission := range strings.Split(user.Claims.Permissions, ",") {
+		if strings.TrimSpace(permission) == allAppsSurveysPermission {
+			return true
+		}
+	}
+	return false
+}
+
+func (app *Application) getSurveyTemplates(user *model.User, types []string) ([]model.SurveyTemplate, error) {
+	return app.storage.GetSurveyTemplates(user.Claims.AppID, user.Claims.OrgID, types)
+}
+
+func (app *Application) getSurveyTemplate(user *model.User, id string) (*model.SurveyTemplate, error) {
+	return app.storage.GetSurveyTemplate(user.Claims.AppID, user.Claims.OrgID, id)
+}
+
+func (app *Application) createSurveyTemplate(user *model.User, template model.SurveyTemplate) (*model.SurveyTemplate, error) {
+	definition, err := surveyTemplateDefinition(template)
+	if err != nil {
+		return nil, err
+	}
+
+	template.ID = uuid.NewString()
+	template.AppID = user.Claims.AppID
+	template.OrgID = user.Claims.OrgID
+	template.CreatorID = user.Claims.Subject
+	template.Survey = *definition
+	template.DateCreated = time.Now().UTC()
+	template.DateUpdated = nil
+	return app.storage.CreateSurveyTemplate(template)
+}
+
+func (app *Application) updateSurveyTemplate(user *model.User, id string, template model.SurveyTemplate) error {
+	definition, err := surveyTemplateDefinition(template)
+	if err != nil {
+		return err
+	}
+
+	template.Survey = *definition
+	return app.storage.UpdateSurveyTemplate(user.Claims.AppID, user.Claims.OrgID, id, template)
+}
+
+func (app *Application) deleteSurveyTemplate(user *model.User, id string) error {
+	return app.storage.DeleteSurveyTemplate(user.Claims.AppID, user.Claims.OrgID, id)
+}
+
+// createSurveyFromTemplate creates a draft survey of the user from a template. The survey gets the type of the template,
+// as the templates are curated by the admins
+func (app *Application) createSurveyFromTemplate(user *model.User, id string) (*model.Survey, error) {
+	template, err := app.storage.GetSurveyTemplate(user.Claims.AppID, user.Claims.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	survey := template.Survey
+	survey.Status = model.SurveyStatusDraft
+	survey.AppID = user.Claims.AppID
+	survey.OrgID = user.Claims.OrgID
+	return app.insertSurvey(user, survey)
+}
+
+// surveyTemplateDefinition checks the survey of a template and gives it without ids, tenant, audience or publication fields
+func surveyTemplateDefinition(template model.SurveyTemplate) (*model.Survey, error) {
+	lint := lintSurvey(template.Survey)
+	if !lint.Valid {
+		return nil, &model.SurveyLintError{SurveyLint: lint}
+	}
+
+	definition := model.NewSurveyBundle(template.Survey, time.Now().UTC()).ToSurvey()
+	definition.Type = template.Type
+	return &definition, nil
+}
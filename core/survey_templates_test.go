@@ -0,0 +1,127 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"testing"
+)
+
+func TestCanAccessAllApps(t *testing.T) {
+	tests := []struct {
+		name        string
+		system      bool
+		permissions string
+		want        bool
+	}{
+		{"system admin", true, "", true},
+		{"all apps permission", false, "get_surveys, all_apps_surveys", true},
+		{"other permissions", false, "get_surveys,all_apps_surveys_read", false},
+		{"no permissions", false, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser("admin")
+			user.Claims.System = tt.system
+			user.Claims.Permissions = tt.permissions
+			if got := canAccessAllApps(user); got != tt.want {
+				t.Errorf("canAccessAllApps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func templatesTestApplication() (*Application, *testStorage) {
+	storage := newTestStorage()
+	questionID := "question1"
+	storage.questions[questionID] = model.SurveyQuestion{ID: questionID, Data: model.SurveyData{Type: surveyDataTypeText, Text: "How are you?"}}
+	storage.surveys["survey1"] = model.Survey{ID: "survey1", CreatorID: "creator", AppID: "app1", OrgID: "org1", Title: "Wellness",
+		Status: model.SurveyStatusPublished, VersionID: "v3", Version: 3, GroupIDs: []string{"group1"},
+		Quotas: []model.SurveyQuota{{Key: "group1", Limit: 5, GroupID: stringPtr("group1")}},
+		Data:   map[string]model.SurveyData{"q1": {QuestionID: &questionID, Type: surveyDataTypeText, Text: "How are you?"}}}
+	return &Application{storage: storage}, storage
+}
+
+func TestCloneSurvey(t *testing.T) {
+	tests := []struct {
+		name         string
+		permissions  string
+		clone        model.SurveyClone
+		wantErr      error
+		wantApp      string
+		wantAudience bool
+	}{
+		{"same app", "", model.SurveyClone{Title: stringPtr("Wellness 2027")}, nil, "app1", true},
+		{"other app without permission", "", model.SurveyClone{AppID: stringPtr("app2")}, model.ErrSurveyCloneNotAllowed, "", false},
+		{"other app", allAppsSurveysPermission, model.SurveyClone{AppID: stringPtr("app2")}, nil, "app2", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, storage := templatesTestApplication()
+			user := newTestUser("admin")
+			user.Claims.Permissions = tt.permissions
+
+			cloned, err := app.cloneSurvey(user, "survey1", tt.clone)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cloneSurvey() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(storage.surveys) != 1 {
+					t.Errorf("cloneSurvey() stored a survey")
+				}
+				return
+			}
+
+			if cloned.ID == "survey1" || cloned.CreatorID != "admin" || cloned.Status != model.SurveyStatusDraft || cloned.Version != 1 ||
+				cloned.AppID != tt.wantApp || cloned.OrgID != "org1" {
+				t.Errorf("cloneSurvey() = %+v", cloned)
+			}
+			if tt.clone.Title != nil && cloned.Title != *tt.clone.Title {
+				t.Errorf("cloneSurvey() title = %s, want %s", cloned.Title, *tt.clone.Title)
+			}
+			hasAudience := len(cloned.GroupIDs) > 0 && len(cloned.Quotas) > 0 && cloned.Data["q1"].QuestionID != nil
+			if hasAudience != tt.wantAudience {
+				t.Errorf("cloneSurvey() groups = %v, quotas = %v, question = %v, want them kept %v", cloned.GroupIDs, cloned.Quotas,
+					cloned.Data["q1"].QuestionID, tt.wantAudience)
+			}
+			if _, ok := storage.versions[cloned.VersionID]; !ok {
+				t.Errorf("cloneSurvey() did not store the first version")
+			}
+		})
+	}
+}
+
+func TestCreateSurveyFromTemplate(t *testing.T) {
+	app, storage := templatesTestApplication()
+	storage.templates["template1"] = model.SurveyTemplate{ID: "template1", AppID: "app1", OrgID: "org1", Type: "check_in", CreatorID: "admin",
+		Survey: model.Survey{Title: "Check in", Type: "check_in", Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"}}}}
+
+	survey, err := app.createSurveyFromTemplate(newTestUser("user1"), "template1")
+	if err != nil {
+		t.Fatalf("createSurveyFromTemplate() error = %v", err)
+	}
+	if survey.CreatorID != "user1" || survey.Status != model.SurveyStatusDraft || survey.Type != "check_in" || survey.AppID != "app1" {
+		t.Errorf("createSurveyFromTemplate() = %+v", survey)
+	}
+
+	other := newTestUser("user1")
+	other.Claims.AppID = "app2"
+	if _, err := app.createSurveyFromTemplate(other, "template1"); err == nil {
+		t.Errorf("createSurveyFromTemplate() error = nil, want the templates of another app not found")
+	}
+}
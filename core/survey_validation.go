@@ -0,0 +1,207 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"math"
+	"polls/core/model"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	surveyDataTypeTrueFalse      = "survey_data.true_false"
+	surveyDataTypeMultipleChoice = "survey_data.multiple_choice"
+	surveyDataTypeDateTime       = "survey_data.date_time"
+	surveyDataTypeNumeric        = "survey_data.numeric"
+	surveyDataTypeText           = "survey_data.text"
+	surveyDataTypeEntry          = "survey_data.entry"
+)
+
+var surveyDateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// validateSurveyResponses validates the responses of the reached survey data against their constraints.
+// Gives the validation errors by survey data key
+func validateSurveyResponses(survey *model.Survey, keys []string) map[string]string {
+	invalid := map[string]string{}
+	for _, key := range keys {
+		message := validateSurveyResponse(survey.Data[key])
+		if len(message) > 0 {
+			invalid[key] = message
+		}
+	}
+	return invalid
+}
+
+// dropUnreachedSurveyResponses removes the responses to the survey data which are not reached
+func dropUnreachedSurveyResponses(survey *model.Survey, keys []string) {
+	reached := map[string]bool{}
+	for _, key := range keys {
+		reached[key] = true
+	}
+	for key, data := range survey.Data {
+		if !reached[key] && data.Response != nil {
+			data.Response = nil
+			survey.Data[key] = data
+		}
+	}
+}
+
+func validateSurveyResponse(data model.SurveyData) string {
+	if data.Type == surveyDataTypeResult || data.Type == surveyDataTypePage {
+		return ""
+	}
+
+	response := normalizeValue(data.Response)
+	if response == nil {
+		if !data.AllowSkip {
+			return "a response is required"
+		}
+		return ""
+	}
+
+	switch data.Type {
+	case surveyDataTypeTrueFalse:
+		if _, ok := response.(bool); !ok && len(data.Options) == 0 {
+			return "the response must be a boolean"
+		}
+		return validateOptions(data, []interface{}{response})
+	case surveyDataTypeMultipleChoice:
+		responses := []interface{}{response}
+		if list, ok := response.([]interface{}); ok {
+			if len(list) > 1 && (data.AllowMultiple == nil || !*data.AllowMultiple) {
+				return "only one option can be selected"
+			}
+			responses = list
+		}
+		return validateOptions(data, responses)
+	case surveyDataTypeNumeric:
+		number, ok := response.(float64)
+		if !ok {
+			return "the response must be a number"
+		}
+		return validateNumber(data, number)
+	case surveyDataTypeText:
+		text, ok := response.(string)
+		if !ok {
+			return "the response must be a text"
+		}
+		length := utf8.RuneCountInString(text)
+		if data.MinLength != nil && length < *data.MinLength {
+			return fmt.Sprintf("the response must be at least %d characters long", *data.MinLength)
+		}
+		if data.MaxLength != nil && length > *data.MaxLength {
+			return fmt.Sprintf("the response must be at most %d characters long", *data.MaxLength)
+		}
+	case surveyDataTypeDateTime:
+		dateTime, ok := parseSurveyDateTime(response)
+		if !ok {
+			return "the response must be a date"
+		}
+		if data.StartTime != nil && dateTime.Before(*data.StartTime) {
+			return fmt.Sprintf("the response must not be before %s", data.StartTime.Format(time.RFC3339))
+		}
+		if data.EndTime != nil && dateTime.After(*data.EndTime) {
+			return fmt.Sprintf("the response must not be after %s", data.EndTime.Format(time.RFC3339))
+		}
+	case surveyDataTypeEntry:
+		entries, ok := response.(map[string]interface{})
+		if !ok {
+			return "the response must be an object"
+		}
+		for key, value := range entries {
+			format, ok := data.DataFormat[key]
+			if !ok {
+				return fmt.Sprintf("%s is not part of the data format", key)
+			}
+			if !matchesDataFormat(format, value) {
+				return fmt.Sprintf("%s must be of type %s", key, format)
+			}
+		}
+	}
+	return ""
+}
+
+func validateOptions(data model.SurveyData, responses []interface{}) string {
+	if len(data.Options) == 0 {
+		return ""
+	}
+	for _, response := range responses {
+		found := false
+		for _, option := range data.Options {
+			if valuesEqual(response, option.Value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%v is not one of the options", response)
+		}
+	}
+	return ""
+}
+
+func validateNumber(data model.SurveyData, number float64) string {
+	if data.WholeNum != nil && *data.WholeNum && number != math.Trunc(number) {
+		return "the response must be a whole number"
+	}
+	if data.Minimum != nil && number < *data.Minimum {
+		return fmt.Sprintf("the response must not be less than %v", *data.Minimum)
+	}
+	if data.Maximum != nil && number > *data.Maximum {
+		return fmt.Sprintf("the response must not be greater than %v", *data.Maximum)
+	}
+	return ""
+}
+
+func parseSurveyDateTime(value interface{}) (time.Time, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range surveyDateTimeLayouts {
+		dateTime, err := time.Parse(layout, text)
+		if err == nil {
+			return dateTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func matchesDataFormat(format string, value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch format {
+	case "int":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "double", "number":
+		_, ok := value.(float64)
+		return ok
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "text", "string":
+		_, ok := value.(string)
+		return ok
+	case "date_time", "date":
+		_, ok := parseSurveyDateTime(value)
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestValidateSurveyResponse(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+	options := []model.OptionData{{Title: "Red", Value: "red"}, {Title: "Blue", Value: "blue"}}
+
+	tests := []struct {
+		name string
+		data model.SurveyData
+		want string
+	}{
+		{"required", model.SurveyData{Type: surveyDataTypeText}, "a response is required"},
+		{"skipped", model.SurveyData{Type: surveyDataTypeText, AllowSkip: true}, ""},
+		{"page", model.SurveyData{Type: surveyDataTypePage}, ""},
+		{"boolean", model.SurveyData{Type: surveyDataTypeTrueFalse, Response: true}, ""},
+		{"not a boolean", model.SurveyData{Type: surveyDataTypeTrueFalse, Response: "yes"}, "the response must be a boolean"},
+		{"option", model.SurveyData{Type: surveyDataTypeMultipleChoice, Options: options, Response: "red"}, ""},
+		{"not an option", model.SurveyData{Type: surveyDataTypeMultipleChoice, Options: options, Response: "green"}, "green is not one of the options"},
+		{"several options", model.SurveyData{Type: surveyDataTypeMultipleChoice, Options: options, Response: []interface{}{"red", "blue"}},
+			"only one option can be selected"},
+		{"multiple options", model.SurveyData{Type: surveyDataTypeMultipleChoice, Options: options, AllowMultiple: boolPtr(true),
+			Response: []interface{}{"red", "blue"}}, ""},
+		{"number", model.SurveyData{Type: surveyDataTypeNumeric, Minimum: floatPtr(1), Maximum: floatPtr(5), Response: 3}, ""},
+		{"not a number", model.SurveyData{Type: surveyDataTypeNumeric, Response: "3"}, "the response must be a number"},
+		{"number below the minimum", model.SurveyData{Type: surveyDataTypeNumeric, Minimum: floatPtr(1), Response: 0.5},
+			"the response must not be less than 1"},
+		{"number above the maximum", model.SurveyData{Type: surveyDataTypeNumeric, Maximum: floatPtr(5), Response: 6.0},
+			"the response must not be greater than 5"},
+		{"not a whole number", model.SurveyData{Type: surveyDataTypeNumeric, WholeNum: boolPtr(true), Response: 2.5},
+			"the response must be a whole number"},
+		{"text", model.SurveyData{Type: surveyDataTypeText, MinLength: intPtr(2), MaxLength: intPtr(4), Response: "café"}, ""},
+		{"text too short", model.SurveyData{Type: surveyDataTypeText, MinLength: intPtr(2), Response: "a"},
+			"the response must be at least 2 characters long"},
+		{"text too long", model.SurveyData{Type: surveyDataTypeText, MaxLength: intPtr(4), Response: "hello"},
+			"the response must be at most 4 characters long"},
+		{"date", model.SurveyData{Type: surveyDataTypeDateTime, StartTime: &start, EndTime: &end, Response: "2026-10-19"}, ""},
+		{"not a date", model.SurveyData{Type: surveyDataTypeDateTime, Response: "tomorrow"}, "the response must be a date"},
+		{"date before the start", model.SurveyData{Type: surveyDataTypeDateTime, StartTime: &start, Response: "2025-12-31T12:00:00Z"},
+			"the response must not be before 2026-01-01T00:00:00Z"},
+		{"entry", model.SurveyData{Type: surveyDataTypeEntry, DataFormat: map[string]string{"age": "int", "name": "text"},
+			Response: map[string]interface{}{"age": 30.0, "name": "Ann"}}, ""},
+		{"entry of another type", model.SurveyData{Type: surveyDataTypeEntry, DataFormat: map[string]string{"age": "int"},
+			Response: map[string]interface{}{"age": 30.5}}, "age must be of type int"},
+		{"entry not in the data format", model.SurveyData{Type: surveyDataTypeEntry, DataFormat: map[string]string{"age": "int"},
+			Response: map[string]interface{}{"name": "Ann"}}, "name is not part of the data format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateSurveyResponse(tt.data); got != tt.want {
+				t.Errorf("validateSurveyResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropUnreachedSurveyResponses(t *testing.T) {
+	survey := &model.Survey{Data: map[string]model.SurveyData{
+		"q1": {Type: surveyDataTypeText, Response: "a"},
+		"q2": {Type: surveyDataTypeText, Response: "b"},
+		"q3": {Type: surveyDataTypeText},
+	}}
+	dropUnreachedSurveyResponses(survey, []string{"q1", "q3"})
+
+	got := map[string]interface{}{}
+	for key, data := range survey.Data {
+		got[key] = data.Response
+	}
+	if want := map[string]interface{}{"q1": "a", "q2": nil, "q3": nil}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dropUnreachedSurveyResponses() = %v, want %v", got, want)
+	}
+}
+
+func validationTestApplication() *Application {
+	storage := newTestStorage()
+	first := "smoker"
+	followUp := `{"condition": {"operator": "==", "data_key": "data.smoker", "compare_to": true}, "true_result": "cigarettes", "false_result": "done"}`
+	storage.surveys["survey1"] = model.Survey{ID: "survey1", CreatorID: "creator", Status: model.SurveyStatusPublished, DefaultDataKey: &first,
+		Data: map[string]model.SurveyData{
+			"smoker":     {Type: surveyDataTypeTrueFalse, Text: "Do you smoke?", FollowUpRule: &followUp},
+			"cigarettes": {Type: surveyDataTypeNumeric, Text: "How many per day?", WholeNum: boolPtr(true), DefaultFollowUpKey: stringPtr("done")},
+			"done":       {Type: surveyDataTypeText, Text: "Anything else?", AllowSkip: true},
+		}}
+	return &Application{storage: storage}
+}
+
+func TestEvaluateSurveyResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		responses     map[string]interface{}
+		wantResponses map[string]interface{}
+		wantErrors    []string
+	}{
+		{"follow up reached", map[string]interface{}{"smoker": true, "cigarettes": 10.0},
+			map[string]interface{}{"smoker": true, "cigarettes": 10.0, "done": nil}, nil},
+		{"unreached response dropped", map[string]interface{}{"smoker": false, "cigarettes": 2.5, "done": "no"},
+			map[string]interface{}{"smoker": false, "cigarettes": nil, "done": "no"}, nil},
+		{"required response missing", map[string]interface{}{"smoker": true}, nil, []string{"cigarettes"}},
+		{"invalid responses", map[string]interface{}{"smoker": true, "cigarettes": 2.5, "age": 30.0}, nil, []string{"age", "cigarettes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			survey := model.Survey{ID: "survey1", Data: map[string]model.SurveyData{}}
+			for key, response := range tt.responses {
+				survey.Data[key] = model.SurveyData{Type: surveyDataTypeText, Text: "forged", Response: response}
+			}
+
+			evaluated, err := validationTestApplication().evaluateSurveyResponse(newTestUser("respondent"), survey)
+			if tt.wantErrors != nil {
+				var validationErr *model.SurveyResponseValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("evaluateSurveyResponse() error = %v, want a SurveyResponseValidationError", err)
+				}
+				keys := []string{}
+				for key := range validationErr.Errors {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				if !reflect.DeepEqual(keys, tt.wantErrors) {
+					t.Errorf("evaluateSurveyResponse() errors = %v, want %v", validationErr.Errors, tt.wantErrors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("evaluateSurveyResponse() error = %v", err)
+			}
+
+			got := map[string]interface{}{}
+			for key, data := range evaluated.Data {
+				got[key] = data.Response
+				if data.Text == "forged" {
+					t.Errorf("evaluateSurveyResponse() %s text = %s, want the stored definition", key, data.Text)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantResponses) {
+				t.Errorf("evaluateSurveyResponse() = %v, want %v", got, tt.wantResponses)
+			}
+		})
+	}
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"polls/core/model"
+	"reflect"
+	"sort"
+)
+
+// the fields which change with every version and are not part of the survey definition
+var surveyVersionIgnoredFields = []string{"id", "version_id", "version", "date_created", "date_updated", "stats"}
+
+// diffSurveys gives the differences between the definitions of two survey versions
+func diffSurveys(from model.Survey, to model.Survey) ([]model.SurveyVersionChange, error) {
+	fromValue, err := surveyDefinition(from)
+	if err != nil {
+		return nil, err
+	}
+	toValue, err := surveyDefinition(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []model.SurveyVersionChange{}
+	diffValues("", fromValue, toValue, &changes)
+	return changes, nil
+}
+
+func surveyDefinition(survey model.Survey) (map[string]interface{}, error) {
+	data, err := json.Marshal(survey)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition map[string]interface{}
+	err = json.Unmarshal(data, &definition)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range surveyVersionIgnoredFields {
+		delete(definition, field)
+	}
+	return definition, nil
+}
+
+func diffValues(path string, from interface{}, to interface{}, changes *[]model.SurveyVersionChange) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if !fromIsMap || !toIsMap {
+		if !reflect.DeepEqual(from, to) {
+			*changes = append(*changes, model.SurveyVersionChange{Path: path, Type: model.SurveyVersionChangeChanged, From: from, To: to})
+		}
+		return
+	}
+
+	keys := []string{}
+	for key := range fromMap {
+		keys = append(keys, key)
+	}
+	for key := range toMap {
+		if _, ok := fromMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := key
+		if len(path) > 0 {
+			keyPath = path + "." + key
+		}
+
+		fromValue, inFrom := fromMap[key]
+		toValue, inTo := toMap[key]
+		switch {
+		case !inFrom:
+			*changes = append(*changes, model.SurveyVersionChange{Path: keyPath, Type: model.SurveyVersionChangeAdded, To: toValue})
+		case !inTo:
+			*changes = append(*changes, model.SurveyVersionChange{Path: keyPath, Type: model.SurveyVersionChangeRemoved, From: fromValue})
+		default:
+			diffValues(keyPath, fromValue, toValue, changes)
+		}
+	}
+}
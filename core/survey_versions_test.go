@@ -0,0 +1,177 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"polls/core/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffSurveys(t *testing.T) {
+	now := time.Now().UTC()
+	from := model.Survey{ID: "survey1", VersionID: "v1", Version: 1, Title: "Title", DateCreated: now,
+		Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"}, "q2": {Type: surveyDataTypeText, Text: "Why?"}}}
+
+	tests := []struct {
+		name      string
+		to        func(survey model.Survey) model.Survey
+		wantPaths []string
+		wantTypes []string
+	}{
+		{"same definition of another version", func(survey model.Survey) model.Survey {
+			survey.VersionID = "v2"
+			survey.Version = 2
+			survey.DateUpdated = &now
+			return survey
+		}, []string{}, []string{}},
+		{"changed title", func(survey model.Survey) model.Survey {
+			survey.Title = "New title"
+			return survey
+		}, []string{"title"}, []string{model.SurveyVersionChangeChanged}},
+		{"added and removed data", func(survey model.Survey) model.Survey {
+			survey.Data = map[string]model.SurveyData{"q1": survey.Data["q1"], "q3": {Type: surveyDataTypeText, Text: "When?"}}
+			return survey
+		}, []string{"data.q2", "data.q3"}, []string{model.SurveyVersionChangeRemoved, model.SurveyVersionChangeAdded}},
+		{"changed question text", func(survey model.Survey) model.Survey {
+			survey.Data = map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How do you feel?"}, "q2": survey.Data["q2"]}
+			return survey
+		}, []string{"data.q1.text"}, []string{model.SurveyVersionChangeChanged}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := diffSurveys(from, tt.to(from))
+			if err != nil {
+				t.Fatalf("diffSurveys() error = %v", err)
+			}
+			paths := []string{}
+			types := []string{}
+			for _, change := range changes {
+				paths = append(paths, change.Path)
+				types = append(types, change.Type)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) || !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("diffSurveys() = %+v, want %v %v", changes, tt.wantPaths, tt.wantTypes)
+			}
+		})
+	}
+}
+
+// conflictStorage fails the survey updates, as if somebody updated the survey meanwhile
+type conflictStorage struct {
+	*testStorage
+}
+
+func (s *conflictStorage) UpdateSurvey(user *model.User, survey model.Survey, version int, admin bool) error {
+	return errors.New("invalid id or version")
+}
+
+func versionsTestStorage(versioned bool) *testStorage {
+	storage := newTestStorage()
+	survey := model.Survey{ID: "survey1", CreatorID: "creator", Title: "Title", Status: model.SurveyStatusPublished,
+		Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How are you?"}}}
+	if versioned {
+		survey.VersionID = "v1"
+		survey.Version = 1
+		storage.versions["v1"] = model.SurveyVersion{ID: "v1", SurveyID: "survey1", Version: 1, Survey: survey}
+	}
+	storage.surveys[survey.ID] = survey
+	return storage
+}
+
+func TestSaveSurveyRevision(t *testing.T) {
+	update := model.Survey{Title: "New title", Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How do you feel?"}}}
+
+	tests := []struct {
+		name         string
+		versioned    bool
+		conflict     bool
+		user         string
+		wantErr      bool
+		wantVersions []int
+	}{
+		{"versioned survey", true, false, "creator", false, []int{1, 2}},
+		{"survey created before the versioning", false, false, "creator", false, []int{1, 2}},
+		{"not the creator", true, false, "other", true, []int{1}},
+		{"concurrent update", true, true, "creator", true, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := versionsTestStorage(tt.versioned)
+			app := &Application{storage: storage, sseServer: NewSSEServer()}
+			if tt.conflict {
+				app.storage = &conflictStorage{testStorage: storage}
+			}
+
+			saved, err := app.saveSurveyRevision(newTestUser(tt.user), update, "survey1", false, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("saveSurveyRevision() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			versions := map[int]bool{}
+			for _, version := range storage.versions {
+				versions[version.Version] = true
+			}
+			if len(versions) != len(tt.wantVersions) {
+				t.Errorf("saveSurveyRevision() versions = %+v, want %v", storage.versions, tt.wantVersions)
+			}
+			for _, version := range tt.wantVersions {
+				if !versions[version] {
+					t.Errorf("saveSurveyRevision() version %d is missing", version)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			stored := storage.surveys["survey1"]
+			if stored.Version != 2 || stored.VersionID != saved.VersionID || stored.Title != "New title" || stored.CreatorID != "creator" {
+				t.Errorf("saveSurveyRevision() stored = %+v", stored)
+			}
+			if version := storage.versions[saved.VersionID]; !reflect.DeepEqual(version.Survey, stored) {
+				t.Errorf("saveSurveyRevision() version = %+v, want the stored survey", version.Survey)
+			}
+		})
+	}
+}
+
+func TestRollbackSurvey(t *testing.T) {
+	storage := versionsTestStorage(true)
+	app := &Application{storage: storage, sseServer: NewSSEServer()}
+	admin := newTestUser("admin")
+
+	update := model.Survey{Title: "New title", Data: map[string]model.SurveyData{"q1": {Type: surveyDataTypeText, Text: "How do you feel?"}}}
+	if _, err := app.saveSurveyRevision(admin, update, "survey1", true, nil); err != nil {
+		t.Fatalf("saveSurveyRevision() error = %v", err)
+	}
+	current := storage.surveys["survey1"]
+	current.Status = model.SurveyStatusClosed
+	storage.surveys["survey1"] = current
+
+	rolledBack, err := app.rollbackSurvey(admin, "survey1", "v1")
+	if err != nil {
+		t.Fatalf("rollbackSurvey() error = %v", err)
+	}
+	if rolledBack.Version != 3 || rolledBack.Title != "Title" || rolledBack.Status != model.SurveyStatusClosed {
+		t.Errorf("rollbackSurvey() = %+v, want the first definition as the third version with the current status", rolledBack)
+	}
+	if version := storage.versions[rolledBack.VersionID]; version.RolledBackFrom == nil || *version.RolledBackFrom != "v1" {
+		t.Errorf("rollbackSurvey() version rolled back from = %v, want v1", version.RolledBackFrom)
+	}
+}
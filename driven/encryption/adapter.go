@@ -0,0 +1,199 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"polls/core/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// the size of the keys and the data keys - AES-256
+const keySize = 32
+
+// Adapter encrypts data with envelope encryption. Every value is encrypted with a new data key,
+// and the data key is encrypted with a key of the service which is identified by its id
+type Adapter struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+type keysFile struct {
+	ActiveKeyID string            `json:"active_key_id"`
+	Keys        map[string]string `json:"keys"` // base64 encoded keys by key id
+}
+
+// NewEncryptionAdapter creates a new encryption adapter instance with the keys from the keys file of the config.
+// The encryption is disabled if there is no keys file
+func NewEncryptionAdapter(config *model.Config) (*Adapter, error) {
+	if len(config.EncryptionKeysFile) == 0 {
+		return &Adapter{}, nil
+	}
+
+	data, err := os.ReadFile(config.EncryptionKeysFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the encryption keys file - %s", err)
+	}
+	var file keysFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the encryption keys file - %s", err)
+	}
+
+	keys := map[string][]byte{}
+	for id, encoded := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding the encryption key %s - %s", id, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("the encryption key %s must be %d bytes long", id, keySize)
+		}
+		keys[id] = key
+	}
+	if _, ok := keys[file.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("the active encryption key %s is not in the encryption keys file", file.ActiveKeyID)
+	}
+
+	log.Printf("Encryption enabled with %d keys, active key %s", len(keys), file.ActiveKeyID)
+	return &Adapter{activeKeyID: file.ActiveKeyID, keys: keys}, nil
+}
+
+// IsEnabled tells if there are keys to encrypt with
+func (a *Adapter) IsEnabled() bool {
+	return len(a.activeKeyID) > 0
+}
+
+// ActiveKeyID gives the id of the key which encrypts the new data keys
+func (a *Adapter) ActiveKeyID() string {
+	return a.activeKeyID
+}
+
+// Encrypt encrypts the value with a new data key. The associated data is authenticated, so the encrypted data
+// can not be moved to another entity
+func (a *Adapter) Encrypt(value interface{}, associatedData string) (*model.EncryptedData, error) {
+	if !a.IsEnabled() {
+		return nil, fmt.Errorf("error encrypting - encryption is not enabled")
+	}
+
+	plaintext, err := bson.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting - %s", err)
+	}
+
+	dataKey := make([]byte, keySize)
+	_, err = rand.Read(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error generating a data key - %s", err)
+	}
+	data, err := seal(dataKey, plaintext, []byte(associatedData))
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting - %s", err)
+	}
+	encryptedKey, err := seal(a.keys[a.activeKeyID], dataKey, []byte(a.activeKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting the data key - %s", err)
+	}
+
+	return &model.EncryptedData{KeyID: a.activeKeyID, EncryptedKey: encryptedKey, Data: data}, nil
+}
+
+// Decrypt decrypts the encrypted data into the value
+func (a *Adapter) Decrypt(encrypted model.EncryptedData, associatedData string, value interface{}) error {
+	dataKey, err := a.decryptDataKey(encrypted)
+	if err != nil {
+		return err
+	}
+	plaintext, err := open(dataKey, encrypted.Data, []byte(associatedData))
+	if err != nil {
+		return fmt.Errorf("error decrypting - %s", err)
+	}
+	err = bson.Unmarshal(plaintext, value)
+	if err != nil {
+		return fmt.Errorf("error decrypting - %s", err)
+	}
+	return nil
+}
+
+// RotateKey encrypts the data key of the encrypted data with the active key. The data itself is not changed
+func (a *Adapter) RotateKey(encrypted model.EncryptedData) (*model.EncryptedData, error) {
+	if !a.IsEnabled() {
+		return nil, fmt.Errorf("error rotating key - encryption is not enabled")
+	}
+
+	dataKey, err := a.decryptDataKey(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := seal(a.keys[a.activeKeyID], dataKey, []byte(a.activeKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting the data key - %s", err)
+	}
+	return &model.EncryptedData{KeyID: a.activeKeyID, EncryptedKey: encryptedKey, Data: encrypted.Data}, nil
+}
+
+func (a *Adapter) decryptDataKey(encrypted model.EncryptedData) ([]byte, error) {
+	key, ok := a.keys[encrypted.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("error decrypting - the key %s is not available", encrypted.KeyID)
+	}
+	dataKey, err := open(key, encrypted.EncryptedKey, []byte(encrypted.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting the data key - %s", err)
+	}
+	return dataKey, nil
+}
+
+// seal encrypts with AES-GCM. The nonce is put before the cipher text
+func seal(key []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(key []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("the cipher text is too short")
+	}
+	nonce := ciphertext[:gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[gcm.NonceSize():], associatedData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
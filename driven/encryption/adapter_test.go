@@ -0,0 +1,243 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"polls/core/model"
+	"reflect"
+	"testing"
+)
+
+type testValue struct {
+	Text    string   `bson:"text"`
+	Numbers []int    `bson:"numbers"`
+	Tags    []string `bson:"tags"`
+}
+
+func testKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, keySize)
+}
+
+// newTestAdapter creates an adapter from a keys file with the given keys
+func newTestAdapter(t *testing.T, activeKeyID string, keys map[string][]byte) *Adapter {
+	t.Helper()
+	file := keysFile{ActiveKeyID: activeKeyID, Keys: map[string]string{}}
+	for id, key := range keys {
+		file.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keys.json")
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adapter, err := NewEncryptionAdapter(&model.Config{EncryptionKeysFile: path})
+	if err != nil {
+		t.Fatalf("NewEncryptionAdapter() error = %v", err)
+	}
+	return adapter
+}
+
+func TestSealOpen(t *testing.T) {
+	key := testKey(1)
+	tests := []struct {
+		name       string
+		openKey    []byte
+		sealedData []byte
+		openedData []byte
+		tamper     bool
+		wantErr    bool
+	}{
+		{"round trip", key, []byte("survey"), []byte("survey"), false, false},
+		{"no associated data", key, nil, nil, false, false},
+		{"other associated data", key, []byte("survey"), []byte("other"), false, true},
+		{"other key", testKey(2), []byte("survey"), []byte("survey"), false, true},
+		{"tampered cipher text", key, []byte("survey"), []byte("survey"), true, true},
+		{"invalid key size", key[:10], []byte("survey"), []byte("survey"), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext := []byte("the responses")
+			ciphertext, err := seal(key, plaintext, tt.sealedData)
+			if err != nil {
+				t.Fatalf("seal() error = %v", err)
+			}
+			if bytes.Contains(ciphertext, plaintext) {
+				t.Fatalf("seal() = %x contains the plain text", ciphertext)
+			}
+			if tt.tamper {
+				ciphertext[len(ciphertext)-1] ^= 0xff
+			}
+
+			opened, err := open(tt.openKey, ciphertext, tt.openedData)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !bytes.Equal(opened, plaintext) {
+				t.Errorf("open() = %s, want %s", opened, plaintext)
+			}
+		})
+	}
+
+	t.Run("different nonces", func(t *testing.T) {
+		first, _ := seal(key, []byte("value"), nil)
+		second, _ := seal(key, []byte("value"), nil)
+		if bytes.Equal(first, second) {
+			t.Errorf("seal() gives the same cipher text twice")
+		}
+	})
+
+	t.Run("short cipher text", func(t *testing.T) {
+		if _, err := open(key, []byte{1, 2, 3}, nil); err == nil {
+			t.Errorf("open() error = nil, want an error")
+		}
+	})
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	adapter := newTestAdapter(t, "k1", map[string][]byte{"k1": testKey(1)})
+	value := testValue{Text: "answer", Numbers: []int{1, 2, 3}, Tags: []string{"a"}}
+
+	encrypted, err := adapter.Encrypt(value, "response1")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if encrypted.KeyID != "k1" {
+		t.Errorf("Encrypt() key id = %s, want k1", encrypted.KeyID)
+	}
+
+	tests := []struct {
+		name           string
+		adapter        *Adapter
+		encrypted      model.EncryptedData
+		associatedData string
+		wantErr        bool
+	}{
+		{"same associated data", adapter, *encrypted, "response1", false},
+		{"other associated data", adapter, *encrypted, "response2", true},
+		{"unknown key", newTestAdapter(t, "k2", map[string][]byte{"k2": testKey(2)}), *encrypted, "response1", true},
+		{"other key with the same id", newTestAdapter(t, "k1", map[string][]byte{"k1": testKey(2)}), *encrypted, "response1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decrypted testValue
+			err := tt.adapter.Decrypt(tt.encrypted, tt.associatedData, &decrypted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(decrypted, value) {
+				t.Errorf("Decrypt() = %+v, want %+v", decrypted, value)
+			}
+		})
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	value := testValue{Text: "answer"}
+
+	encrypted, err := newTestAdapter(t, "k1", map[string][]byte{"k1": oldKey}).Encrypt(value, "response1")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	rotating := newTestAdapter(t, "k2", map[string][]byte{"k1": oldKey, "k2": newKey})
+	rotated, err := rotating.RotateKey(*encrypted)
+	if err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+	if rotated.KeyID != "k2" || !bytes.Equal(rotated.Data, encrypted.Data) || bytes.Equal(rotated.EncryptedKey, encrypted.EncryptedKey) {
+		t.Errorf("RotateKey() = %+v, want the data key encrypted with k2 and the same data", rotated)
+	}
+
+	tests := []struct {
+		name      string
+		adapter   *Adapter
+		encrypted model.EncryptedData
+		wantErr   bool
+	}{
+		{"rotated with the new key only", newTestAdapter(t, "k2", map[string][]byte{"k2": newKey}), *rotated, false},
+		{"rotated with both keys", rotating, *rotated, false},
+		{"not rotated with both keys", rotating, *encrypted, false},
+		{"not rotated without the old key", newTestAdapter(t, "k2", map[string][]byte{"k2": newKey}), *encrypted, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decrypted testValue
+			err := tt.adapter.Decrypt(tt.encrypted, "response1", &decrypted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(decrypted, value) {
+				t.Errorf("Decrypt() = %+v, want %+v", decrypted, value)
+			}
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := newTestAdapter(t, "k3", map[string][]byte{"k3": testKey(3)}).RotateKey(*encrypted)
+		if err == nil {
+			t.Errorf("RotateKey() error = nil, want an error")
+		}
+	})
+}
+
+func TestNewEncryptionAdapter(t *testing.T) {
+	disabled, err := NewEncryptionAdapter(&model.Config{})
+	if err != nil || disabled.IsEnabled() {
+		t.Fatalf("NewEncryptionAdapter() = %+v, %v, want a disabled adapter", disabled, err)
+	}
+	if _, err := disabled.Encrypt(testValue{}, ""); err == nil {
+		t.Errorf("Encrypt() error = nil, want an error when the encryption is disabled")
+	}
+	if _, err := disabled.RotateKey(model.EncryptedData{}); err == nil {
+		t.Errorf("RotateKey() error = nil, want an error when the encryption is disabled")
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"invalid JSON", `{"active_key_id": `},
+		{"invalid base64", `{"active_key_id": "k1", "keys": {"k1": "not base64!"}}`},
+		{"short key", `{"active_key_id": "k1", "keys": {"k1": "` + base64.StdEncoding.EncodeToString(testKey(1)[:16]) + `"}}`},
+		{"missing active key", `{"active_key_id": "k2", "keys": {"k1": "` + base64.StdEncoding.EncodeToString(testKey(1)) + `"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+			err := os.WriteFile(path, []byte(tt.file), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewEncryptionAdapter(&model.Config{EncryptionKeysFile: path}); err == nil {
+				t.Errorf("NewEncryptionAdapter() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"polls/core/model"
 )
 
@@ -178,6 +179,66 @@ func (a *Adapter) sendGroupNotification(groupID string, notification model.Group
 	}
 }
 
+type groupMember struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// GetGroupMemberIDs retrieves the user ids of the members and the admins of a group
+func (a *Adapter) GetGroupMemberIDs(groupID string) ([]string, error) {
+	if groupID == "" {
+		return nil, nil
+	}
+
+	// the members are listed by the group title
+	var group model.Group
+	err := a.getInternal(fmt.Sprintf("%s/api/int/group/%s", a.baseURL, groupID), &group)
+	if err != nil {
+		log.Printf("error GetGroupMemberIDs: request - %s", err)
+		return nil, fmt.Errorf("error GetGroupMemberIDs: request - %s", err)
+	}
+
+	var members []groupMember
+	err = a.getInternal(fmt.Sprintf("%s/api/int/group/title/%s/members", a.baseURL, url.PathEscape(group.Title)), &members)
+	if err != nil {
+		log.Printf("error GetGroupMemberIDs: request - %s", err)
+		return nil, fmt.Errorf("error GetGroupMemberIDs: request - %s", err)
+	}
+
+	userIDs := []string{}
+	for _, member := range members {
+		if member.Status == "member" || member.Status == "admin" {
+			userIDs = append(userIDs, member.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// getInternal loads the result of an internal API of the Groups BB into result
+func (a *Adapter) getInternal(requestURL string, result interface{}) error {
+	client := &http.Client{}
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("INTERNAL-API-KEY", a.internalAPIKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%d. Body: %s", resp.StatusCode, string(data))
+	}
+	return json.Unmarshal(data, result)
+}
+
 // UpdateGroupDateUpdated Updates group date updated
 func (a *Adapter) UpdateGroupDateUpdated(groupID string) error {
 	if groupID != "" {
@@ -27,37 +27,44 @@ func (a *Adapter) SendNotification(notification model.NotificationMessage) {
 	go a.sendNotification(notification)
 }
 
+// DeliverNotification sends a direct notification trough Notifications BB and waits for the delivery
+func (a *Adapter) DeliverNotification(notification model.NotificationMessage) error {
+	return a.sendNotification(notification)
+}
+
 // SendNotification sends notification to a user
-func (a *Adapter) sendNotification(notification model.NotificationMessage) {
+func (a *Adapter) sendNotification(notification model.NotificationMessage) error {
 	if notification.Message.Subject != "" && notification.Message.Body != "" {
 		url := fmt.Sprintf("%s/api/int/v2/message", a.baseURL)
 
 		bodyBytes, err := json.Marshal(notification)
 		if err != nil {
 			log.Printf("error creating notification request - %s", err)
-			return
+			return err
 		}
 
 		client := &http.Client{}
 		req, err := http.NewRequest("POST", url, bytes.NewReader(bodyBytes))
 		if err != nil {
 			log.Printf("error creating load user data request - %s", err)
-			return
+			return err
 		}
 		req.Header.Set("INTERNAL-API-KEY", a.internalAPIKey)
 
 		resp, err := client.Do(req)
 		if err != nil {
 			log.Printf("error loading user data - %s", err)
-			return
+			return err
 		}
 
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
 			log.Printf("error with response code - %d", resp.StatusCode)
+			return fmt.Errorf("error with response code %d", resp.StatusCode)
 		}
 	}
+	return nil
 }
 
 // SendMail sends email to a user
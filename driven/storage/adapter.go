@@ -19,6 +19,7 @@ import (
 	"log"
 	"polls/core/model"
 	"polls/driven/groups"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -43,6 +45,9 @@ const (
 
 	settingsKey   = "stadium"
 	eventInterval = 100 * time.Millisecond
+
+	// the time after which the sent survey reminders are removed
+	surveyRemindersExpirationSeconds = 35 * 24 * 60 * 60
 )
 
 // Adapter implements the Storage interface
@@ -249,8 +254,11 @@ func (sa *Adapter) UpdatePoll(user *model.User, poll model.Poll) (*model.Poll, e
 				primitive.E{Key: "poll.pin", Value: poll.Pin},
 				primitive.E{Key: "poll.question", Value: poll.Question},
 				primitive.E{Key: "poll.options", Value: poll.Options},
+				primitive.E{Key: "poll.language", Value: poll.Language},
+				primitive.E{Key: "poll.translations", Value: poll.Translations},
 				primitive.E{Key: "poll.group_id", Value: poll.GroupID},
 				primitive.E{Key: "poll.multi_choice", Value: poll.MultiChoice},
+				primitive.E{Key: "poll.shuffle_options", Value: poll.ShuffleOptions},
 				primitive.E{Key: "poll.repeat", Value: poll.Repeat},
 				primitive.E{Key: "poll.show_results", Value: poll.ShowResults},
 				primitive.E{Key: "poll.stadium", Value: poll.Stadium},
@@ -370,6 +378,11 @@ func (sa *Adapter) SetListener(listener CollectionListener) {
 	sa.db.listener = listener
 }
 
+// GetChangeStreamsStatus gives the status of the collections change stream watchers
+func (sa *Adapter) GetChangeStreamsStatus() []model.ChangeStreamStatus {
+	return sa.db.getChangeStreamsStatus()
+}
+
 // Event
 
 func (m *database) onDataChanged(changeDoc map[string]interface{}) {
@@ -419,11 +432,14 @@ func (sa *Adapter) CreateSurvey(survey model.Survey) (*model.Survey, error) {
 	return &survey, nil
 }
 
-// UpdateSurvey updates a survey
-func (sa *Adapter) UpdateSurvey(user *model.User, survey model.Survey, admin bool) error {
+// UpdateSurvey updates a survey if it is still at the given version. The surveys created before the versioning are at version 0
+func (sa *Adapter) UpdateSurvey(user *model.User, survey model.Survey, version int, admin bool) error {
 	if len(survey.ID) > 0 {
 		now := time.Now().UTC()
-		filter := bson.M{"_id": survey.ID, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}
+		filter := bson.M{"_id": survey.ID, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID, "version": version}
+		if version == 0 {
+			filter["version"] = bson.M{"$in": bson.A{nil, 0}}
+		}
 		if !admin {
 			filter["creator_id"] = user.Claims.Subject
 		}
@@ -441,6 +457,18 @@ func (sa *Adapter) UpdateSurvey(user *model.User, survey model.Survey, admin boo
 			"constants":             survey.Constants,
 			"strings":               survey.Strings,
 			"sub_rules":             survey.SubRules,
+			"version_id":            survey.VersionID,
+			"version":               survey.Version,
+			"group_ids":             survey.GroupIDs,
+			"to_members":            survey.ToMembersList,
+			"status":                survey.Status,
+			"start_date":            survey.StartDate,
+			"end_date":              survey.EndDate,
+			"response_limits":       survey.ResponseLimits,
+			"randomization":         survey.Randomization,
+			"schedule":              survey.Schedule,
+			"alert_rules":           survey.AlertRules,
+			"quotas":                survey.Quotas,
 			"date_updated":          now,
 		}}
 
@@ -450,8 +478,8 @@ func (sa *Adapter) UpdateSurvey(user *model.User, survey model.Survey, admin boo
 			return fmt.Errorf("error storage.Adapter.UpdateSurvey(%s) - %s", survey.ID, err)
 		}
 		if res.ModifiedCount != 1 {
-			fmt.Printf("storage.Adapter.UpdateSurvey(%s) invalid id", survey.ID)
-			return fmt.Errorf("storage.Adapter.UpdateSurvey(%s) invalid id", survey.ID)
+			fmt.Printf("storage.Adapter.UpdateSurvey(%s) invalid id or version", survey.ID)
+			return fmt.Errorf("storage.Adapter.UpdateSurvey(%s) invalid id or version", survey.ID)
 		}
 	}
 
@@ -473,9 +501,368 @@ func (sa *Adapter) DeleteSurvey(user *model.User, id string, admin bool) error {
 		return fmt.Errorf("storage.Adapter.DeleteSurvey(%s) invalid id", id)
 	}
 
+	_, err = sa.db.surveyVersions.DeleteMany(bson.M{"survey_id": id, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}, nil)
+	if err != nil {
+		return fmt.Errorf("error storage.Adapter.DeleteSurvey(): error while delete survey versions (%s) - %s", id, err)
+	}
+
+	_, err = sa.db.surveyResponseAttempts.DeleteMany(bson.M{"survey_id": id, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}, nil)
+	if err != nil {
+		return fmt.Errorf("error storage.Adapter.DeleteSurvey(): error while delete survey response attempts (%s) - %s", id, err)
+	}
+
+	_, err = sa.db.surveyQuotaCounts.DeleteOne(bson.M{"_id": id, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}, nil)
+	if err != nil {
+		return fmt.Errorf("error storage.Adapter.DeleteSurvey(): error while delete survey quota counts (%s) - %s", id, err)
+	}
+
+	return nil
+}
+
+// GetSurveys gets the surveys matching a filter. Admins get all the surveys in the app/org, the rest of the users get
+// their own surveys and the ones targeted at them directly, through one of the given groups or at everyone. The archived
+// surveys of the other users are returned only when they are requested explicitly
+func (sa *Adapter) GetSurveys(user *model.User, filter model.SurveysFilter, groupIDs []string, admin bool) ([]model.Survey, error) {
+	mongoFilter := bson.D{
+		primitive.E{Key: "org_id", Value: user.Claims.OrgID},
+		primitive.E{Key: "app_id", Value: user.Claims.AppID},
+	}
+
+	if !admin {
+		hiddenStatuses := []string{model.SurveyStatusDraft}
+		if !containsString(filter.Statuses, model.SurveyStatusArchived) {
+			hiddenStatuses = append(hiddenStatuses, model.SurveyStatusArchived)
+		}
+		mongoFilter = append(mongoFilter, primitive.E{Key: "$or", Value: []bson.M{
+			{"creator_id": user.Claims.Subject},
+			{"status": bson.M{"$nin": hiddenStatuses}, "$or": surveyAudienceFilter(user.Claims.Subject, groupIDs)},
+		}})
+	}
+	if len(filter.Types) > 0 {
+		mongoFilter = append(mongoFilter, primitive.E{Key: "type", Value: bson.M{"$in": filter.Types}})
+	}
+	if len(filter.CreatorIDs) > 0 {
+		mongoFilter = append(mongoFilter, primitive.E{Key: "creator_id", Value: bson.M{"$in": filter.CreatorIDs}})
+	}
+	if len(filter.Statuses) > 0 {
+		statusFilter := []bson.M{{"status": bson.M{"$in": filter.Statuses}}}
+		// surveys created before the publication states are published
+		if containsString(filter.Statuses, model.SurveyStatusPublished) {
+			statusFilter = append(statusFilter, bson.M{"status": nil})
+		}
+		mongoFilter = append(mongoFilter, primitive.E{Key: "$and", Value: []bson.M{{"$or": statusFilter}}})
+	}
+	if filter.Title != nil && len(*filter.Title) > 0 {
+		mongoFilter = append(mongoFilter, primitive.E{Key: "title", Value: primitive.Regex{Pattern: regexp.QuoteMeta(*filter.Title), Options: "i"}})
+	}
+	if filter.StartDate != nil || filter.EndDate != nil {
+		dateFilter := bson.M{}
+		if filter.StartDate != nil {
+			dateFilter["$gte"] = *filter.StartDate
+		}
+		if filter.EndDate != nil {
+			dateFilter["$lte"] = *filter.EndDate
+		}
+		mongoFilter = append(mongoFilter, primitive.E{Key: "date_created", Value: dateFilter})
+	}
+
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+	if filter.Limit != nil {
+		opts.SetLimit(*filter.Limit)
+	}
+	if filter.Offset != nil {
+		opts.SetSkip(*filter.Offset)
+	}
+
+	var result []model.Survey
+	err := sa.db.surveys.Find(mongoFilter, &result, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveys - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveys - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveysAssignedToUser gets the surveys targeted at a user directly, through one of the given groups or at everyone
+func (sa *Adapter) GetSurveysAssignedToUser(user *model.User, groupIDs []string, limit *int, offset *int) ([]model.Survey, error) {
+	filter := bson.M{
+		"org_id": user.Claims.OrgID,
+		"app_id": user.Claims.AppID,
+		"status": bson.M{"$nin": []string{model.SurveyStatusDraft, model.SurveyStatusArchived}},
+		"$or":    surveyAudienceFilter(user.Claims.Subject, groupIDs),
+	}
+
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: -1}})
+	if limit != nil {
+		opts.SetLimit(int64(*limit))
+	}
+	if offset != nil {
+		opts.SetSkip(int64(*offset))
+	}
+
+	var result []model.Survey
+	err := sa.db.surveys.Find(filter, &result, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveysAssignedToUser - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveysAssignedToUser - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveyVersions gets the versions of a survey starting from the latest one
+func (sa *Adapter) GetSurveyVersions(appID string, orgID string, surveyID string) ([]model.SurveyVersion, error) {
+	filter := bson.M{"survey_id": surveyID, "org_id": orgID, "app_id": appID}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "version", Value: -1}})
+	var result []model.SurveyVersion
+	err := sa.db.surveyVersions.Find(filter, &result, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyVersions(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyVersions(%s) - %s", surveyID, err)
+	}
+	return result, nil
+}
+
+// GetSurveyVersion gets a survey version by ID
+func (sa *Adapter) GetSurveyVersion(appID string, orgID string, surveyID string, id string) (*model.SurveyVersion, error) {
+	filter := bson.M{"_id": id, "survey_id": surveyID, "org_id": orgID, "app_id": appID}
+	var entry model.SurveyVersion
+	err := sa.db.surveyVersions.FindOne(filter, &entry, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyVersion(%s) - %s", id, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyVersion(%s) - %s", id, err)
+	}
+	return &entry, nil
+}
+
+// CreateSurveyVersion stores a new survey version
+func (sa *Adapter) CreateSurveyVersion(version model.SurveyVersion) error {
+	_, err := sa.db.surveyVersions.InsertOne(version)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CreateSurveyVersion(%s) - %s", version.ID, err)
+		return fmt.Errorf("error storage.Adapter.CreateSurveyVersion(%s) - %s", version.ID, err)
+	}
+	return nil
+}
+
+// DeleteSurveyVersion deletes a survey version
+func (sa *Adapter) DeleteSurveyVersion(appID string, orgID string, id string) error {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	_, err := sa.db.surveyVersions.DeleteOne(filter, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.DeleteSurveyVersion(%s) - %s", id, err)
+		return fmt.Errorf("error storage.Adapter.DeleteSurveyVersion(%s) - %s", id, err)
+	}
+	return nil
+}
+
+// GetSurveyVersionResponsesCounts gives the number of the responses to a survey by survey version ID
+func (sa *Adapter) GetSurveyVersionResponsesCounts(appID string, orgID string, surveyID string) (map[string]int, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"survey._id": surveyID, "org_id": orgID, "app_id": appID, "status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}},
+		{"$group": bson.M{"_id": "$survey_version_id", "count": bson.M{"$sum": 1}}},
+	}
+
+	var result []struct {
+		VersionID *string `bson:"_id"`
+		Count     int     `bson:"count"`
+	}
+	err := sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyVersionResponsesCounts(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyVersionResponsesCounts(%s) - %s", surveyID, err)
+	}
+
+	counts := map[string]int{}
+	for _, entry := range result {
+		versionID := ""
+		if entry.VersionID != nil {
+			versionID = *entry.VersionID
+		}
+		counts[versionID] += entry.Count
+	}
+	return counts, nil
+}
+
+// GetSurveyTemplates gets the survey templates of an app and org, optionally of the given survey types
+func (sa *Adapter) GetSurveyTemplates(appID string, orgID string, types []string) ([]model.SurveyTemplate, error) {
+	filter := bson.M{"org_id": orgID, "app_id": appID}
+	if len(types) > 0 {
+		filter["type"] = bson.M{"$in": types}
+	}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "name", Value: 1}})
+	var result []model.SurveyTemplate
+	err := sa.db.surveyTemplates.Find(filter, &result, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyTemplates - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyTemplates - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveyTemplate gets a survey template by ID
+func (sa *Adapter) GetSurveyTemplate(appID string, orgID string, id string) (*model.SurveyTemplate, error) {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	var entry model.SurveyTemplate
+	err := sa.db.surveyTemplates.FindOne(filter, &entry, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyTemplate(%s) - %s", id, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyTemplate(%s) - %s", id, err)
+	}
+	return &entry, nil
+}
+
+// CreateSurveyTemplate creates a survey template
+func (sa *Adapter) CreateSurveyTemplate(template model.SurveyTemplate) (*model.SurveyTemplate, error) {
+	_, err := sa.db.surveyTemplates.InsertOne(template)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CreateSurveyTemplate(%s) - %s", template.ID, err)
+		return nil, fmt.Errorf("error storage.Adapter.CreateSurveyTemplate(%s) - %s", template.ID, err)
+	}
+	return &template, nil
+}
+
+// UpdateSurveyTemplate updates a survey template
+func (sa *Adapter) UpdateSurveyTemplate(appID string, orgID string, id string, template model.SurveyTemplate) error {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	update := bson.M{"$set": bson.M{
+		"name":         template.Name,
+		"description":  template.Description,
+		"type":         template.Type,
+		"survey":       template.Survey,
+		"date_updated": time.Now().UTC(),
+	}}
+
+	res, err := sa.db.surveyTemplates.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.UpdateSurveyTemplate(%s) - %s", id, err)
+		return fmt.Errorf("error storage.Adapter.UpdateSurveyTemplate(%s) - %s", id, err)
+	}
+	if res.MatchedCount != 1 {
+		fmt.Printf("storage.Adapter.UpdateSurveyTemplate(%s) invalid id", id)
+		return fmt.Errorf("storage.Adapter.UpdateSurveyTemplate(%s) invalid id", id)
+	}
+	return nil
+}
+
+// DeleteSurveyTemplate deletes a survey template
+func (sa *Adapter) DeleteSurveyTemplate(appID string, orgID string, id string) error {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	res, err := sa.db.surveyTemplates.DeleteOne(filter, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.DeleteSurveyTemplate(%s) - %s", id, err)
+		return fmt.Errorf("error storage.Adapter.DeleteSurveyTemplate(%s) - %s", id, err)
+	}
+	if res.DeletedCount != 1 {
+		fmt.Printf("storage.Adapter.DeleteSurveyTemplate(%s) invalid id", id)
+		return fmt.Errorf("storage.Adapter.DeleteSurveyTemplate(%s) invalid id", id)
+	}
 	return nil
 }
 
+// GetSurveyQuestions gets the questions of the question bank of an app and org
+func (sa *Adapter) GetSurveyQuestions(appID string, orgID string) ([]model.SurveyQuestion, error) {
+	filter := bson.M{"org_id": orgID, "app_id": appID}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "name", Value: 1}})
+	var result []model.SurveyQuestion
+	err := sa.db.surveyQuestions.Find(filter, &result, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyQuestions - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyQuestions - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveyQuestion gets a question of the question bank by ID
+func (sa *Adapter) GetSurveyQuestion(appID string, orgID string, id string) (*model.SurveyQuestion, error) {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	var entry model.SurveyQuestion
+	err := sa.db.surveyQuestions.FindOne(filter, &entry, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyQuestion(%s) - %s", id, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyQuestion(%s) - %s", id, err)
+	}
+	return &entry, nil
+}
+
+// GetSurveyQuestionsByIDs gets the questions of the question bank with the given IDs
+func (sa *Adapter) GetSurveyQuestionsByIDs(appID string, orgID string, ids []string) ([]model.SurveyQuestion, error) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "org_id": orgID, "app_id": appID}
+	var result []model.SurveyQuestion
+	err := sa.db.surveyQuestions.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyQuestionsByIDs - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyQuestionsByIDs - %s", err)
+	}
+	return result, nil
+}
+
+// CreateSurveyQuestion creates a question of the question bank
+func (sa *Adapter) CreateSurveyQuestion(question model.SurveyQuestion) (*model.SurveyQuestion, error) {
+	_, err := sa.db.surveyQuestions.InsertOne(question)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CreateSurveyQuestion(%s) - %s", question.ID, err)
+		return nil, fmt.Errorf("error storage.Adapter.CreateSurveyQuestion(%s) - %s", question.ID, err)
+	}
+	return &question, nil
+}
+
+// UpdateSurveyQuestion updates a question of the question bank and increments its version
+func (sa *Adapter) UpdateSurveyQuestion(appID string, orgID string, id string, question model.SurveyQuestion) error {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	update := bson.M{
+		"$set": bson.M{
+			"name":         question.Name,
+			"data":         question.Data,
+			"date_updated": time.Now().UTC(),
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	res, err := sa.db.surveyQuestions.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.UpdateSurveyQuestion(%s) - %s", id, err)
+		return fmt.Errorf("error storage.Adapter.UpdateSurveyQuestion(%s) - %s", id, err)
+	}
+	if res.MatchedCount != 1 {
+		fmt.Printf("storage.Adapter.UpdateSurveyQuestion(%s) invalid id", id)
+		return fmt.Errorf("storage.Adapter.UpdateSurveyQuestion(%s) invalid id", id)
+	}
+	return nil
+}
+
+// DeleteSurveyQuestion deletes a question of the question bank
+func (sa *Adapter) DeleteSurveyQuestion(appID string, orgID string, id string) error {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID}
+	res, err := sa.db.surveyQuestions.DeleteOne(filter, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.DeleteSurveyQuestion(%s) - %s", id, err)
+		return fmt.Errorf("error storage.Adapter.DeleteSurveyQuestion(%s) - %s", id, err)
+	}
+	if res.DeletedCount != 1 {
+		fmt.Printf("storage.Adapter.DeleteSurveyQuestion(%s) invalid id", id)
+		return fmt.Errorf("storage.Adapter.DeleteSurveyQuestion(%s) invalid id", id)
+	}
+	return nil
+}
+
+// GetSurveysByQuestion gets the surveys with survey data which reference a question of the question bank
+func (sa *Adapter) GetSurveysByQuestion(appID string, orgID string, questionID string) ([]model.Survey, error) {
+	// the survey data are stored by key, so the references are collected from the values of the data object
+	questionIDs := bson.M{"$map": bson.M{
+		"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$data", bson.M{}}}},
+		"as":    "item",
+		"in":    "$$item.v.question_id",
+	}}
+	filter := bson.M{"org_id": orgID, "app_id": appID, "$expr": bson.M{"$in": bson.A{questionID, questionIDs}}}
+	var result []model.Survey
+	err := sa.db.surveys.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveysByQuestion(%s) - %s", questionID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveysByQuestion(%s) - %s", questionID, err)
+	}
+	return result, nil
+}
+
 // GetSurveyResponse gets a survey response by ID
 func (sa *Adapter) GetSurveyResponse(user *model.User, id string) (*model.SurveyResponse, error) {
 	filter := bson.M{"_id": id, "user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}
@@ -514,7 +901,8 @@ func (sa *Adapter) GetSurveysByUserID(user *model.User) ([]model.Survey, error)
 
 // GetSurveyResponses gets matching surveys for a user
 func (sa *Adapter) GetSurveyResponses(user *model.User, surveyIDs []string, surveyTypes []string, startDate *time.Time, endDate *time.Time, limit *int, offset *int) ([]model.SurveyResponse, error) {
-	filter := bson.M{"user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}
+	filter := bson.M{"user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID,
+		"status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}
 	if len(surveyIDs) > 0 {
 		filter["survey._id"] = bson.M{"$in": surveyIDs}
 	}
@@ -548,6 +936,218 @@ func (sa *Adapter) GetSurveyResponses(user *model.User, surveyIDs []string, surv
 	return results, nil
 }
 
+// GetSurveyResponseCounts aggregates the responses to a survey by question and response value
+func (sa *Adapter) GetSurveyResponseCounts(appID string, orgID string, surveyID string) (*model.SurveyResponseCounts, error) {
+	filter := bson.M{"survey._id": surveyID, "org_id": orgID, "app_id": appID, "status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}
+	total, err := sa.db.surveyResponses.CountDocuments(filter)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseCounts(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseCounts(%s) - %s", surveyID, err)
+	}
+
+	answered, values, err := sa.aggregateSurveyResponseValues(filter)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseCounts(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseCounts(%s) - %s", surveyID, err)
+	}
+	return &model.SurveyResponseCounts{Total: int(total), Answered: answered, Values: values}, nil
+}
+
+// GetSurveyResponsesBySurvey gets all the responses to a survey matching the filter for the survey results, including the responses in progress
+func (sa *Adapter) GetSurveyResponsesBySurvey(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) ([]model.SurveyResponse, error) {
+	var results []model.SurveyResponse
+	err := sa.db.surveyResponses.Find(surveyResultsMatch(appID, orgID, surveyID, filter), &results, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponsesBySurvey(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponsesBySurvey(%s) - %s", surveyID, err)
+	}
+	return results, nil
+}
+
+// CountSurveyResponses counts the completed responses to a survey matching the filter
+func (sa *Adapter) CountSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (int, error) {
+	match := surveyResultsMatch(appID, orgID, surveyID, filter)
+	match["status"] = bson.M{"$ne": model.SurveyResponseStatusInProgress}
+	count, err := sa.db.surveyResponses.CountDocuments(match)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CountSurveyResponses(%s) - %s", surveyID, err)
+		return 0, fmt.Errorf("error storage.Adapter.CountSurveyResponses(%s) - %s", surveyID, err)
+	}
+	return int(count), nil
+}
+
+// StreamSurveyResponses passes the completed responses to a survey matching the filter to the handler one by one, sorted by creation date
+func (sa *Adapter) StreamSurveyResponses(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter, handler func(surveyResponse model.SurveyResponse) error) error {
+	match := surveyResultsMatch(appID, orgID, surveyID, filter)
+	match["status"] = bson.M{"$ne": model.SurveyResponseStatusInProgress}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "date_created", Value: 1}, primitive.E{Key: "_id", Value: 1}})
+	err := sa.db.surveyResponses.FindEach(match, opts, func(cur *mongo.Cursor) error {
+		var entry model.SurveyResponse
+		err := cur.Decode(&entry)
+		if err != nil {
+			return err
+		}
+		return handler(entry)
+	})
+	if err != nil {
+		fmt.Printf("error storage.Adapter.StreamSurveyResponses(%s) - %s", surveyID, err)
+		return fmt.Errorf("error storage.Adapter.StreamSurveyResponses(%s) - %s", surveyID, err)
+	}
+	return nil
+}
+
+// GetSurveyResponseAggregates aggregates the responses to a survey matching the filter for the survey results
+func (sa *Adapter) GetSurveyResponseAggregates(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) (*model.SurveyResponseAggregates, error) {
+	match := surveyResultsMatch(appID, orgID, surveyID, filter)
+
+	var statuses []struct {
+		InProgress bool `bson:"_id"`
+		Count      int  `bson:"count"`
+	}
+	statusesPipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": bson.M{"$eq": bson.A{"$status", model.SurveyResponseStatusInProgress}}, "count": bson.M{"$sum": 1}}},
+	}
+	err := sa.db.surveyResponses.Aggregate(statusesPipeline, &statuses, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+	}
+	aggregates := model.SurveyResponseAggregates{}
+	for _, entry := range statuses {
+		if entry.InProgress {
+			aggregates.InProgress = entry.Count
+		} else {
+			aggregates.Completed = entry.Count
+		}
+	}
+
+	match["status"] = bson.M{"$ne": model.SurveyResponseStatusInProgress}
+	aggregates.Answered, aggregates.Values, err = sa.aggregateSurveyResponseValues(match)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+	}
+
+	aggregates.Numbers, err = sa.aggregateSurveyResponseNumbers([]bson.M{
+		{"$match": match},
+		{"$project": bson.M{"data": bson.M{"$objectToArray": "$survey.data"}}},
+		{"$unwind": "$data"},
+		{"$match": bson.M{"data.v.type": "survey_data.numeric"}},
+		{"$project": bson.M{"key": "$data.k", "value": "$data.v.response"}},
+	})
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+	}
+
+	aggregates.Scores, err = sa.aggregateSurveyResponseNumbers([]bson.M{
+		{"$match": match},
+		{"$project": bson.M{"scores": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$survey.stats.scores", bson.M{}}}}}},
+		{"$unwind": "$scores"},
+		{"$project": bson.M{"key": "$scores.k", "value": "$scores.v"}},
+	})
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseAggregates(%s) - %s", surveyID, err)
+	}
+	return &aggregates, nil
+}
+
+func surveyResultsMatch(appID string, orgID string, surveyID string, filter model.SurveyResultsFilter) bson.M {
+	match := bson.M{"survey._id": surveyID, "org_id": orgID, "app_id": appID}
+	if filter.StartDate != nil || filter.EndDate != nil {
+		dateFilter := bson.M{}
+		if filter.StartDate != nil {
+			dateFilter["$gte"] = filter.StartDate
+		}
+		if filter.EndDate != nil {
+			dateFilter["$lt"] = filter.EndDate
+		}
+		match["date_created"] = dateFilter
+	}
+	if len(filter.VersionIDs) > 0 {
+		match["survey_version_id"] = bson.M{"$in": filter.VersionIDs}
+	}
+	return match
+}
+
+// aggregateSurveyResponseValues counts the answered questions and the response values of the matching responses by question
+func (sa *Adapter) aggregateSurveyResponseValues(filter bson.M) (map[string]int, map[string][]model.SurveyResponseValueCount, error) {
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{"data": bson.M{"$objectToArray": "$survey.data"}}},
+		{"$unwind": "$data"},
+		{"$match": bson.M{"data.v.response": bson.M{"$ne": nil}}},
+		{"$project": bson.M{"key": "$data.k", "response": "$data.v.response"}},
+		{"$facet": bson.M{
+			"answered": []bson.M{
+				{"$group": bson.M{"_id": "$key", "count": bson.M{"$sum": 1}}},
+			},
+			"values": []bson.M{
+				{"$unwind": "$response"},
+				{"$group": bson.M{"_id": bson.M{"key": "$key", "value": "$response"}, "count": bson.M{"$sum": 1}}},
+			},
+		}},
+	}
+
+	type answeredCount struct {
+		Key   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	type valueCount struct {
+		ID struct {
+			Key   string      `bson:"key"`
+			Value interface{} `bson:"value"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	var result []struct {
+		Answered []answeredCount `bson:"answered"`
+		Values   []valueCount    `bson:"values"`
+	}
+	err := sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	answered := map[string]int{}
+	values := map[string][]model.SurveyResponseValueCount{}
+	if len(result) > 0 {
+		for _, entry := range result[0].Answered {
+			answered[entry.Key] = entry.Count
+		}
+		for _, entry := range result[0].Values {
+			values[entry.ID.Key] = append(values[entry.ID.Key], model.SurveyResponseValueCount{Value: entry.ID.Value, Count: entry.Count})
+		}
+	}
+	return answered, values, nil
+}
+
+// aggregateSurveyResponseNumbers collects the numeric values projected as key and value by the pipeline, sorted by key and value
+func (sa *Adapter) aggregateSurveyResponseNumbers(pipeline []bson.M) (map[string][]float64, error) {
+	pipeline = append(pipeline,
+		bson.M{"$match": bson.M{"value": bson.M{"$type": "number"}}},
+		bson.M{"$sort": bson.M{"value": 1}},
+		bson.M{"$group": bson.M{"_id": "$key", "values": bson.M{"$push": bson.M{"$toDouble": "$value"}}}},
+	)
+
+	var result []struct {
+		Key    string    `bson:"_id"`
+		Values []float64 `bson:"values"`
+	}
+	err := sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := map[string][]float64{}
+	for _, entry := range result {
+		numbers[entry.Key] = entry.Values
+	}
+	return numbers, nil
+}
+
 // CreateSurveyResponse creates a new survey response
 func (sa *Adapter) CreateSurveyResponse(surveyResponse model.SurveyResponse) (*model.SurveyResponse, error) {
 	_, err := sa.db.surveyResponses.InsertOne(surveyResponse)
@@ -558,14 +1158,19 @@ func (sa *Adapter) CreateSurveyResponse(surveyResponse model.SurveyResponse) (*m
 	return &surveyResponse, nil
 }
 
-// UpdateSurveyResponse updates an existing service response
-func (sa *Adapter) UpdateSurveyResponse(user *model.User, id string, survey model.Survey) error {
+// UpdateSurveyResponse updates an existing completed survey response. The responses in progress are completed only by CompleteSurveyResponseProgress
+func (sa *Adapter) UpdateSurveyResponse(user *model.User, surveyResponse model.SurveyResponse) error {
+	id := surveyResponse.ID
 	if len(id) > 0 {
 		now := time.Now().UTC()
-		filter := bson.M{"_id": id, "user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}
+		filter := bson.M{"_id": id, "user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID,
+			"survey._id": surveyResponse.Survey.ID, "status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}
 		update := bson.M{"$set": bson.M{
-			"survey":       survey,
-			"date_updated": now,
+			"survey":            surveyResponse.Survey,
+			"survey_version_id": surveyResponse.Survey.VersionID,
+			"encrypted":         surveyResponse.Encrypted,
+			"presentation":      surveyResponse.Presentation,
+			"date_updated":      now,
 		}}
 
 		res, err := sa.db.surveyResponses.UpdateOne(filter, update, nil)
@@ -581,6 +1186,455 @@ func (sa *Adapter) UpdateSurveyResponse(user *model.User, id string, survey mode
 	return nil
 }
 
+// CompleteSurveyResponseProgress stores the evaluated survey of a response in progress and completes the response
+func (sa *Adapter) CompleteSurveyResponseProgress(user *model.User, surveyResponse model.SurveyResponse) error {
+	now := time.Now().UTC()
+	filter := bson.M{"_id": surveyResponse.ID, "user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID,
+		"status": model.SurveyResponseStatusInProgress}
+	update := bson.M{"$set": bson.M{
+		"survey":            surveyResponse.Survey,
+		"survey_version_id": surveyResponse.Survey.VersionID,
+		"encrypted":         surveyResponse.Encrypted,
+		"presentation":      surveyResponse.Presentation,
+		"status":            model.SurveyResponseStatusCompleted,
+		"current_data_key":  nil,
+		"date_expires":      nil,
+		"date_updated":      now,
+	}}
+
+	res, err := sa.db.surveyResponses.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CompleteSurveyResponseProgress(%s) - %s", surveyResponse.ID, err)
+		return fmt.Errorf("error storage.Adapter.CompleteSurveyResponseProgress(%s) - %s", surveyResponse.ID, err)
+	}
+	if res.ModifiedCount != 1 {
+		fmt.Printf("storage.Adapter.CompleteSurveyResponseProgress(%s) invalid id", surveyResponse.ID)
+		return fmt.Errorf("storage.Adapter.CompleteSurveyResponseProgress(%s) invalid id", surveyResponse.ID)
+	}
+	return nil
+}
+
+// GetSurveyResponseProgress gets the survey response in progress of the user for a survey
+func (sa *Adapter) GetSurveyResponseProgress(user *model.User, surveyID string) (*model.SurveyResponse, error) {
+	filter := bson.M{"user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID, "survey._id": surveyID,
+		"status": model.SurveyResponseStatusInProgress, "date_expires": bson.M{"$gt": time.Now().UTC()}}
+	var results []model.SurveyResponse
+	err := sa.db.surveyResponses.Find(filter, &results, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponseProgress(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponseProgress(%s) - %s", surveyID, err)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// SaveSurveyResponseProgress creates or replaces the survey response in progress of the user for a survey.
+// The expired responses which are not removed yet are replaced as well
+func (sa *Adapter) SaveSurveyResponseProgress(surveyResponse model.SurveyResponse) error {
+	filter := bson.M{"user_id": surveyResponse.UserID, "org_id": surveyResponse.OrgID, "app_id": surveyResponse.AppID,
+		"survey._id": surveyResponse.Survey.ID, "status": model.SurveyResponseStatusInProgress}
+	update := bson.M{
+		"$set": bson.M{
+			"survey":            surveyResponse.Survey,
+			"survey_version_id": surveyResponse.SurveyVersionID,
+			"encrypted":         surveyResponse.Encrypted,
+			"current_data_key":  surveyResponse.CurrentDataKey,
+			"presentation":      surveyResponse.Presentation,
+			"date_expires":      surveyResponse.DateExpires,
+			"date_updated":      surveyResponse.DateUpdated,
+		},
+		"$setOnInsert": bson.M{
+			"_id":          surveyResponse.ID,
+			"date_created": surveyResponse.DateCreated,
+		},
+	}
+
+	_, err := sa.db.surveyResponses.UpdateOne(filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("error storage.Adapter.SaveSurveyResponseProgress(%s) - %s", surveyResponse.Survey.ID, err)
+		return fmt.Errorf("error storage.Adapter.SaveSurveyResponseProgress(%s) - %s", surveyResponse.Survey.ID, err)
+	}
+	return nil
+}
+
+// GetSurveyResponsesToEncrypt gets the responses to the sensitive surveys which are not encrypted yet
+// and the responses which are encrypted with another key than the active key, in pages sorted by id
+func (sa *Adapter) GetSurveyResponsesToEncrypt(activeKeyID string, afterID *string, limit int) ([]model.SurveyResponse, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"survey.sensitive": true, "encrypted": nil},
+		bson.M{"encrypted.key_id": bson.M{"$exists": true, "$ne": activeKeyID}},
+	}}
+	if afterID != nil {
+		filter["_id"] = bson.M{"$gt": *afterID}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit))
+	var results []model.SurveyResponse
+	err := sa.db.surveyResponses.Find(filter, &results, opts)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyResponsesToEncrypt(%s) - %s", activeKeyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyResponsesToEncrypt(%s) - %s", activeKeyID, err)
+	}
+	return results, nil
+}
+
+// UpdateSurveyResponseEncryption stores the survey and the encrypted data of a response if the response is not changed since it was loaded as previous.
+// Gives if the response is updated
+func (sa *Adapter) UpdateSurveyResponseEncryption(previous model.SurveyResponse, surveyResponse model.SurveyResponse) (bool, error) {
+	filter := bson.M{"_id": previous.ID}
+	if previous.Encrypted != nil {
+		filter["encrypted.encrypted_key"] = previous.Encrypted.EncryptedKey
+	} else {
+		filter["encrypted"] = nil
+		filter["date_updated"] = previous.DateUpdated
+	}
+	update := bson.M{"$set": bson.M{
+		"survey":    surveyResponse.Survey,
+		"encrypted": surveyResponse.Encrypted,
+	}}
+
+	res, err := sa.db.surveyResponses.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.UpdateSurveyResponseEncryption(%s) - %s", previous.ID, err)
+		return false, fmt.Errorf("error storage.Adapter.UpdateSurveyResponseEncryption(%s) - %s", previous.ID, err)
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// ReserveSurveyResponseAttempt counts a new response of the user to a survey if the response limits allow it.
+// The check and the count are done in a single update, so concurrent responses can not exceed the limits.
+// Gives the attempts before the reservation
+func (sa *Adapter) ReserveSurveyResponseAttempt(user *model.User, surveyID string, limits model.SurveyResponseLimits, now time.Time) (*model.SurveyResponseAttempts, error) {
+	id := fmt.Sprintf("%s_%s", surveyID, user.Claims.Subject)
+	err := sa.initSurveyResponseAttempts(user, id, surveyID)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReserveSurveyResponseAttempt(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyResponseAttempt(%s) - %s", surveyID, err)
+	}
+
+	filter := bson.M{"_id": id}
+	if limits.MaxAttempts != nil {
+		filter["count"] = bson.M{"$lt": *limits.MaxAttempts}
+	}
+	if limits.CooldownSeconds != nil && *limits.CooldownSeconds > 0 {
+		filter["$or"] = []bson.M{
+			{"date_last_attempt": nil},
+			{"date_last_attempt": bson.M{"$lte": now.Add(-time.Duration(*limits.CooldownSeconds) * time.Second)}},
+		}
+	}
+	update := bson.M{"$inc": bson.M{"count": 1}, "$set": bson.M{"date_last_attempt": now}}
+
+	var previous model.SurveyResponseAttempts
+	err = sa.db.surveyResponseAttempts.FindOneAndUpdate(filter, update, &previous, options.FindOneAndUpdate().SetReturnDocument(options.Before))
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyResponseAttempt(%s) - %w", surveyID, model.ErrSurveyResponseLimitReached)
+	}
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReserveSurveyResponseAttempt(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyResponseAttempt(%s) - %s", surveyID, err)
+	}
+	return &previous, nil
+}
+
+// initSurveyResponseAttempts creates the attempts of a user for a survey from the responses stored before the attempts were counted
+func (sa *Adapter) initSurveyResponseAttempts(user *model.User, id string, surveyID string) error {
+	count, err := sa.db.surveyResponseAttempts.CountDocuments(bson.M{"_id": id})
+	if err != nil || count > 0 {
+		return err
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID, "survey._id": surveyID,
+			"status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}},
+		{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "date_last_attempt": bson.M{"$max": "$date_created"}}},
+	}
+	var result []struct {
+		Count           int        `bson:"count"`
+		DateLastAttempt *time.Time `bson:"date_last_attempt"`
+	}
+	err = sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		return err
+	}
+
+	attempts := model.SurveyResponseAttempts{ID: id, SurveyID: surveyID, UserID: user.Claims.Subject, OrgID: user.Claims.OrgID, AppID: user.Claims.AppID}
+	if len(result) > 0 {
+		attempts.Count = result[0].Count
+		attempts.DateLastAttempt = result[0].DateLastAttempt
+	}
+	_, err = sa.db.surveyResponseAttempts.InsertOne(attempts)
+	if mongo.IsDuplicateKeyError(err) {
+		// created by a concurrent response
+		return nil
+	}
+	return err
+}
+
+// ReleaseSurveyResponseAttempt restores the attempts of a user for a survey when the reserved response could not be stored
+func (sa *Adapter) ReleaseSurveyResponseAttempt(previous model.SurveyResponseAttempts) error {
+	filter := bson.M{"_id": previous.ID, "count": previous.Count + 1}
+	update := bson.M{"$set": bson.M{"count": previous.Count, "date_last_attempt": previous.DateLastAttempt}}
+	_, err := sa.db.surveyResponseAttempts.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReleaseSurveyResponseAttempt(%s) - %s", previous.ID, err)
+		return fmt.Errorf("error storage.Adapter.ReleaseSurveyResponseAttempt(%s) - %s", previous.ID, err)
+	}
+	return nil
+}
+
+// ReserveSurveyQuotas counts a new response in the given quotas of a survey if none of them is full.
+// The check and the count are done in a single update, so concurrent responses can not exceed the quotas.
+// Gives the counts after the reservation
+func (sa *Adapter) ReserveSurveyQuotas(survey model.Survey, quotas []model.SurveyQuota) (*model.SurveyQuotaCounts, error) {
+	err := sa.initSurveyQuotaCounts(survey, quotas)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReserveSurveyQuotas(%s) - %s", survey.ID, err)
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyQuotas(%s) - %s", survey.ID, err)
+	}
+
+	filter := bson.M{"_id": survey.ID}
+	increments := bson.M{}
+	for _, quota := range quotas {
+		// the missing counts are not full as well
+		filter["counts."+quota.Key] = bson.M{"$not": bson.M{"$gte": quota.Limit}}
+		increments["counts."+quota.Key] = 1
+	}
+
+	var counts model.SurveyQuotaCounts
+	err = sa.db.surveyQuotaCounts.FindOneAndUpdate(filter, bson.M{"$inc": increments}, &counts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyQuotas(%s) - %w", survey.ID, model.ErrSurveyQuotaReached)
+	}
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReserveSurveyQuotas(%s) - %s", survey.ID, err)
+		return nil, fmt.Errorf("error storage.Adapter.ReserveSurveyQuotas(%s) - %s", survey.ID, err)
+	}
+	return &counts, nil
+}
+
+// initSurveyQuotaCounts creates the quota counts of a survey. The overall quotas start from the responses stored before the quotas were counted
+func (sa *Adapter) initSurveyQuotaCounts(survey model.Survey, quotas []model.SurveyQuota) error {
+	var existing []model.SurveyQuotaCounts
+	err := sa.db.surveyQuotaCounts.Find(bson.M{"_id": survey.ID}, &existing, nil)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	if len(existing) > 0 {
+		counts = existing[0].Counts
+	}
+	initial := bson.M{}
+	for _, quota := range quotas {
+		if _, ok := counts[quota.Key]; ok || quota.GroupID != nil {
+			continue
+		}
+		if _, ok := initial["counts."+quota.Key]; ok {
+			continue
+		}
+		total, err := sa.db.surveyResponses.CountDocuments(bson.M{"survey._id": survey.ID, "org_id": survey.OrgID, "app_id": survey.AppID,
+			"status": bson.M{"$ne": model.SurveyResponseStatusInProgress}})
+		if err != nil {
+			return err
+		}
+		initial["counts."+quota.Key] = int(total)
+	}
+	if len(existing) > 0 && len(initial) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"_id": survey.ID}
+	for key := range initial {
+		filter[key] = bson.M{"$exists": false}
+	}
+	update := bson.M{"$setOnInsert": bson.M{"org_id": survey.OrgID, "app_id": survey.AppID}}
+	if len(initial) > 0 {
+		update["$set"] = initial
+	}
+	_, err = sa.db.surveyQuotaCounts.UpdateOne(filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// initialized by a concurrent response
+		return nil
+	}
+	return err
+}
+
+// ReleaseSurveyQuotas gives back the counts reserved in the quotas of a survey for a response which could not be stored
+func (sa *Adapter) ReleaseSurveyQuotas(surveyID string, quotas []model.SurveyQuota) error {
+	decrements := bson.M{}
+	for _, quota := range quotas {
+		decrements["counts."+quota.Key] = -1
+	}
+	_, err := sa.db.surveyQuotaCounts.UpdateOne(bson.M{"_id": surveyID}, bson.M{"$inc": decrements}, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.ReleaseSurveyQuotas(%s) - %s", surveyID, err)
+		return fmt.Errorf("error storage.Adapter.ReleaseSurveyQuotas(%s) - %s", surveyID, err)
+	}
+	return nil
+}
+
+// CloseSurvey closes a published survey. Gives true if the survey was closed by this call
+func (sa *Adapter) CloseSurvey(appID string, orgID string, id string) (bool, error) {
+	filter := bson.M{"_id": id, "org_id": orgID, "app_id": appID,
+		"status": bson.M{"$in": []interface{}{model.SurveyStatusPublished, "", nil}}}
+	update := bson.M{"$set": bson.M{"status": model.SurveyStatusClosed, "date_updated": time.Now().UTC()}}
+	res, err := sa.db.surveys.UpdateOne(filter, update, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CloseSurvey(%s) - %s", id, err)
+		return false, fmt.Errorf("error storage.Adapter.CloseSurvey(%s) - %s", id, err)
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// GetScheduledSurveys gets the published surveys of all the apps and orgs which have a schedule and have not ended
+func (sa *Adapter) GetScheduledSurveys(now time.Time) ([]model.Survey, error) {
+	filter := bson.M{
+		"schedule": bson.M{"$ne": nil},
+		"status":   bson.M{"$in": []interface{}{model.SurveyStatusPublished, "", nil}},
+		"$or":      []bson.M{{"end_date": nil}, {"end_date": bson.M{"$gt": now}}},
+	}
+
+	var result []model.Survey
+	err := sa.db.surveys.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetScheduledSurveys - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetScheduledSurveys - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveyRespondents gives the users who completed a response to a survey
+func (sa *Adapter) GetSurveyRespondents(appID string, orgID string, surveyID string) ([]string, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"survey._id": surveyID, "org_id": orgID, "app_id": appID, "status": bson.M{"$ne": model.SurveyResponseStatusInProgress}}},
+		{"$group": bson.M{"_id": "$user_id"}},
+	}
+
+	var result []struct {
+		UserID string `bson:"_id"`
+	}
+	err := sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyRespondents(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyRespondents(%s) - %s", surveyID, err)
+	}
+
+	userIDs := make([]string, len(result))
+	for i, entry := range result {
+		userIDs[i] = entry.UserID
+	}
+	return userIDs, nil
+}
+
+// GetSurveyLastResponseDates gives the date of the last response completed by each of the users to a survey since a date
+func (sa *Adapter) GetSurveyLastResponseDates(appID string, orgID string, surveyID string, userIDs []string, since time.Time) (map[string]time.Time, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"survey._id": surveyID, "org_id": orgID, "app_id": appID, "user_id": bson.M{"$in": userIDs},
+			"status": bson.M{"$ne": model.SurveyResponseStatusInProgress}, "date_created": bson.M{"$gte": since}}},
+		{"$group": bson.M{"_id": "$user_id", "date": bson.M{"$max": "$date_created"}}},
+	}
+
+	var result []struct {
+		UserID string    `bson:"_id"`
+		Date   time.Time `bson:"date"`
+	}
+	err := sa.db.surveyResponses.Aggregate(pipeline, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyLastResponseDates(%s) - %s", surveyID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyLastResponseDates(%s) - %s", surveyID, err)
+	}
+
+	dates := make(map[string]time.Time, len(result))
+	for _, entry := range result {
+		dates[entry.UserID] = entry.Date
+	}
+	return dates, nil
+}
+
+// GetSurveyReminderSettings gets the survey reminder settings of a user, or nil if the user has not set them
+func (sa *Adapter) GetSurveyReminderSettings(appID string, orgID string, userID string) (*model.SurveyReminderSettings, error) {
+	filter := bson.M{"org_id": orgID, "app_id": appID, "user_id": userID}
+	var result []model.SurveyReminderSettings
+	err := sa.db.surveyReminderSettings.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyReminderSettings(%s) - %s", userID, err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyReminderSettings(%s) - %s", userID, err)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+// FindSurveyReminderSettings gets the survey reminder settings of the users which have set them
+func (sa *Adapter) FindSurveyReminderSettings(appID string, orgID string, userIDs []string) ([]model.SurveyReminderSettings, error) {
+	filter := bson.M{"org_id": orgID, "app_id": appID, "user_id": bson.M{"$in": userIDs}}
+	var result []model.SurveyReminderSettings
+	err := sa.db.surveyReminderSettings.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.FindSurveyReminderSettings - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.FindSurveyReminderSettings - %s", err)
+	}
+	return result, nil
+}
+
+// GetSurveyReminderUserIDs gives the users who set their survey reminder settings and did not opt out of all the reminders
+func (sa *Adapter) GetSurveyReminderUserIDs(appID string, orgID string) ([]string, error) {
+	filter := bson.M{"org_id": orgID, "app_id": appID, "opt_out": bson.M{"$ne": true}}
+	var result []model.SurveyReminderSettings
+	err := sa.db.surveyReminderSettings.Find(filter, &result, nil)
+	if err != nil {
+		fmt.Printf("error storage.Adapter.GetSurveyReminderUserIDs - %s", err)
+		return nil, fmt.Errorf("error storage.Adapter.GetSurveyReminderUserIDs - %s", err)
+	}
+
+	userIDs := make([]string, len(result))
+	for i, settings := range result {
+		userIDs[i] = settings.UserID
+	}
+	return userIDs, nil
+}
+
+// SaveSurveyReminderSettings creates or replaces the survey reminder settings of a user
+func (sa *Adapter) SaveSurveyReminderSettings(settings model.SurveyReminderSettings) error {
+	filter := bson.M{"org_id": settings.OrgID, "app_id": settings.AppID, "user_id": settings.UserID}
+	update := bson.M{
+		"$set": bson.M{
+			"timezone":           settings.Timezone,
+			"language":           settings.Language,
+			"opt_out":            settings.OptOut,
+			"opt_out_survey_ids": settings.OptOutSurveyIDs,
+			"date_updated":       settings.DateUpdated,
+		},
+		"$setOnInsert": bson.M{
+			"_id":          settings.ID,
+			"date_created": settings.DateCreated,
+		},
+	}
+
+	_, err := sa.db.surveyReminderSettings.UpdateOne(filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		fmt.Printf("error storage.Adapter.SaveSurveyReminderSettings(%s) - %s", settings.UserID, err)
+		return fmt.Errorf("error storage.Adapter.SaveSurveyReminderSettings(%s) - %s", settings.UserID, err)
+	}
+	return nil
+}
+
+// CreateSurveyReminder records a survey reminder. Gives false if the reminder is already recorded
+func (sa *Adapter) CreateSurveyReminder(reminder model.SurveyReminder) (bool, error) {
+	_, err := sa.db.surveyReminders.InsertOne(reminder)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		fmt.Printf("error storage.Adapter.CreateSurveyReminder(%s) - %s", reminder.ID, err)
+		return false, fmt.Errorf("error storage.Adapter.CreateSurveyReminder(%s) - %s", reminder.ID, err)
+	}
+	return true, nil
+}
+
 // DeleteSurveyResponse deletes a survey response
 func (sa *Adapter) DeleteSurveyResponse(user *model.User, id string) error {
 	filter := bson.M{"_id": id, "user_id": user.Claims.Subject, "org_id": user.Claims.OrgID, "app_id": user.Claims.AppID}
@@ -639,6 +1693,21 @@ func (sa Adapter) DeleteSurveyResponsesWithIDs(appID string, orgID string, accou
 	if err != nil {
 		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
 	}
+
+	_, err = sa.db.surveyResponseAttempts.DeleteMany(filter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
+	}
+
+	_, err = sa.db.surveyReminderSettings.DeleteMany(filter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
+	}
+
+	_, err = sa.db.surveyReminders.DeleteMany(filter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
+	}
 	return nil
 }
 
@@ -654,6 +1723,16 @@ func (sa Adapter) DeleteSurveysWithIDs(appID string, orgID string, accountsIDs [
 	if err != nil {
 		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
 	}
+
+	versionsFilter := bson.D{
+		primitive.E{Key: "app_id", Value: appID},
+		primitive.E{Key: "org_id", Value: orgID},
+		primitive.E{Key: "survey.creator_id", Value: bson.M{"$in": accountsIDs}},
+	}
+	_, err = sa.db.surveyVersions.DeleteMany(versionsFilter, nil)
+	if err != nil {
+		return errors.WrapErrorAction(logutils.ActionDelete, "user", nil, err)
+	}
 	return nil
 }
 
@@ -765,3 +1844,24 @@ func (sa *Adapter) GetAllPolls() ([]model.Poll, error) {
 
 	return results, nil
 }
+
+// surveyAudienceFilter matches the surveys targeted at a user directly, through one of the given groups or at everyone
+func surveyAudienceFilter(userID string, groupIDs []string) []bson.M {
+	audience := []bson.M{
+		{"to_members.user_id": userID},
+		{"group_ids": bson.M{"$in": bson.A{nil, bson.A{}}}, "to_members": bson.M{"$in": bson.A{nil, bson.A{}}}},
+	}
+	if len(groupIDs) > 0 {
+		audience = append(audience, bson.M{"group_ids": bson.M{"$in": groupIDs}})
+	}
+	return audience
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
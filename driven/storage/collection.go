@@ -25,6 +25,16 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	watchMinBackoff            = time.Second
+	watchMaxBackoff            = 5 * time.Minute
+	resumeTokenPersistInterval = time.Second
+
+	errorCodeInvalidResumeToken      = 260
+	errorCodeChangeStreamFatal       = 280
+	errorCodeChangeStreamHistoryLost = 286
+)
+
 type collectionWrapper struct {
 	database *database
 	coll     *mongo.Collection
@@ -52,6 +62,31 @@ func (collWrapper *collectionWrapper) FindWithContext(ctx context.Context, filte
 	return err
 }
 
+// FindEach passes the matching documents to the handler one by one, so that they are not loaded into memory at once.
+// There is no timeout as the handler may stream the documents to a slow client
+func (collWrapper *collectionWrapper) FindEach(filter interface{}, findOptions *options.FindOptions, handler func(cur *mongo.Cursor) error) error {
+	ctx := context.Background()
+
+	if filter == nil {
+		// Passing bson.D{} as the filter matches all documents in the collection
+		filter = bson.D{}
+	}
+
+	cur, err := collWrapper.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		err = handler(cur)
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
 func (collWrapper *collectionWrapper) FindOne(filter interface{}, result interface{}, findOptions *options.FindOneOptions) error {
 	return collWrapper.FindOneWithContext(context.Background(), filter, result, findOptions)
 }
@@ -187,6 +222,21 @@ func (collWrapper *collectionWrapper) UpdateOneWithContext(ctx context.Context,
 	return updateResult, nil
 }
 
+func (collWrapper *collectionWrapper) FindOneAndUpdate(filter interface{}, update interface{}, result interface{}, opts *options.FindOneAndUpdateOptions) error {
+	return collWrapper.FindOneAndUpdateWithContext(context.Background(), filter, update, result, opts)
+}
+
+func (collWrapper *collectionWrapper) FindOneAndUpdateWithContext(ctx context.Context, filter interface{}, update interface{}, result interface{}, opts *options.FindOneAndUpdateOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, collWrapper.database.mongoTimeout)
+	defer cancel()
+
+	singleResult := collWrapper.coll.FindOneAndUpdate(ctx, filter, update, opts)
+	if singleResult.Err() != nil {
+		return singleResult.Err()
+	}
+	return singleResult.Decode(result)
+}
+
 func (collWrapper *collectionWrapper) UpdateMany(filter interface{}, update interface{}, opts *options.UpdateOptions) (*mongo.UpdateResult, error) {
 	return collWrapper.UpdateManyWithContext(context.Background(), filter, update, opts)
 }
@@ -219,37 +269,101 @@ func (collWrapper *collectionWrapper) CountDocuments(filter interface{}) (int64,
 	return count, nil
 }
 
-func (collWrapper *collectionWrapper) Watch(pipeline interface{}) error {
+// Watch watches the collection for changes. It starts after the last persisted resume token if there is one
+// and reconnects with an exponential backoff when the change stream fails, so it never returns.
+func (collWrapper *collectionWrapper) Watch(pipeline interface{}) {
 	if pipeline == nil {
 		pipeline = []bson.M{}
 	}
 
-	var opts *options.ChangeStreamOptions
-	opts = options.ChangeStream()
+	name := collWrapper.coll.Name()
+	collWrapper.database.onWatchStarted(name)
+	resumeToken := collWrapper.database.loadResumeToken(name)
+
+	backoff := watchMinBackoff
+	for {
+		lastToken, connected, err := collWrapper.watchFrom(pipeline, resumeToken)
+		if lastToken != nil {
+			resumeToken = lastToken
+		}
+		if err == nil {
+			err = errors.New("change stream closed")
+		}
+
+		if isResumeTokenInvalid(err) {
+			log.Printf("resume token for %s can no longer be used, starting from now: %s\n", name, err)
+			resumeToken = nil
+			collWrapper.database.deleteResumeToken(name)
+		}
+
+		//the stream connected, so this is a new failure and not a failing reconnect
+		if connected {
+			backoff = watchMinBackoff
+		}
+
+		collWrapper.database.onWatchFailed(name, err)
+		log.Printf("error watching %s, reconnecting in %s: %s\n", name, backoff, err)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > watchMaxBackoff {
+			backoff = watchMaxBackoff
+		}
+	}
+}
+
+// watchFrom opens a change stream starting after the resume token and processes it until it fails.
+// It gives the last seen resume token and if the change stream connected
+func (collWrapper *collectionWrapper) watchFrom(pipeline interface{}, resumeToken bson.Raw) (bson.Raw, bool, error) {
+	name := collWrapper.coll.Name()
+
+	opts := options.ChangeStream()
 	opts.SetFullDocument(options.UpdateLookup)
+	if resumeToken != nil {
+		opts.SetStartAfter(resumeToken)
+	}
 
 	ctx := context.Background()
 	cur, err := collWrapper.coll.Watch(ctx, pipeline, opts)
 	if err != nil {
-		log.Printf("error watching: %s\n", err)
-		return err
+		return nil, false, err
 	}
 	defer cur.Close(ctx)
 
-	var changeDoc map[string]interface{}
-	log.Println("waiting for changes")
+	collWrapper.database.onWatchConnected(name, resumeToken != nil)
+	log.Printf("waiting for %s changes\n", name)
+
+	var lastToken bson.Raw
+	lastPersisted := time.Now()
 	for cur.Next(ctx) {
+		var changeDoc map[string]interface{}
 		if e := cur.Decode(&changeDoc); e != nil {
 			log.Printf("error decoding: %s\n", e)
+		} else {
+			collWrapper.database.onDataChanged(changeDoc)
+		}
+
+		lastToken = cur.ResumeToken()
+		collWrapper.database.onWatchEvent(name)
+		if time.Since(lastPersisted) >= resumeTokenPersistInterval {
+			collWrapper.database.saveResumeToken(name, lastToken)
+			lastPersisted = time.Now()
 		}
-		collWrapper.database.onDataChanged(changeDoc)
 	}
 
-	if err := cur.Err(); err != nil {
-		log.Printf("error cur.Err(): %s\n", err)
-		return err
+	if lastToken != nil {
+		collWrapper.database.saveResumeToken(name, lastToken)
 	}
-	return nil
+	return lastToken, true, cur.Err()
+}
+
+func isResumeTokenInvalid(err error) bool {
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.HasErrorCode(errorCodeInvalidResumeToken) || serverErr.HasErrorCode(errorCodeChangeStreamFatal) ||
+			serverErr.HasErrorCode(errorCodeChangeStreamHistoryLost)
+	}
+	return false
 }
 
 func (collWrapper *collectionWrapper) ListIndexes() ([]bson.M, error) {
@@ -17,6 +17,9 @@ package storage
 import (
 	"context"
 	"log"
+	"polls/core/model"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/rokwire/logging-library-go/v2/logs"
@@ -48,7 +51,25 @@ type database struct {
 	settings        *collectionWrapper
 	surveys         *collectionWrapper
 	surveyResponses *collectionWrapper
+	surveyVersions  *collectionWrapper
+	surveyTemplates *collectionWrapper
+	surveyQuestions *collectionWrapper
 	alertContacts   *collectionWrapper
+	resumeTokens    *collectionWrapper
+
+	surveyResponseAttempts *collectionWrapper
+	surveyQuotaCounts      *collectionWrapper
+	surveyReminderSettings *collectionWrapper
+	surveyReminders        *collectionWrapper
+
+	changeStreamsLock   sync.RWMutex
+	changeStreamsStatus map[string]*model.ChangeStreamStatus
+}
+
+type resumeToken struct {
+	Collection  string    `bson:"_id"`
+	Token       bson.Raw  `bson:"token"`
+	DateUpdated time.Time `bson:"date_updated"`
 }
 
 func (m *database) start() error {
@@ -79,6 +100,9 @@ func (m *database) start() error {
 	m.db = db
 	m.dbClient = client
 
+	m.changeStreamsStatus = map[string]*model.ChangeStreamStatus{}
+	m.resumeTokens = &collectionWrapper{database: m, coll: db.Collection("change_stream_tokens")}
+
 	settings := &collectionWrapper{database: m, coll: db.Collection("pollsettings")}
 	err = m.applySettingsChecks(settings)
 	if err != nil {
@@ -103,6 +127,45 @@ func (m *database) start() error {
 	if err != nil {
 		return err
 	}
+	go surveyResponses.Watch(nil)
+
+	surveyVersions := &collectionWrapper{database: m, coll: db.Collection("survey_versions")}
+	err = m.applySurveyVersionsChecks(surveyVersions)
+	if err != nil {
+		return err
+	}
+
+	surveyTemplates := &collectionWrapper{database: m, coll: db.Collection("survey_templates")}
+	err = m.applySurveyTemplatesChecks(surveyTemplates)
+	if err != nil {
+		return err
+	}
+
+	surveyQuestions := &collectionWrapper{database: m, coll: db.Collection("survey_questions")}
+	err = m.applySurveyQuestionsChecks(surveyQuestions)
+	if err != nil {
+		return err
+	}
+
+	surveyResponseAttempts := &collectionWrapper{database: m, coll: db.Collection("survey_response_attempts")}
+	err = m.applySurveyResponseAttemptsChecks(surveyResponseAttempts)
+	if err != nil {
+		return err
+	}
+
+	surveyQuotaCounts := &collectionWrapper{database: m, coll: db.Collection("survey_quota_counts")}
+
+	surveyReminderSettings := &collectionWrapper{database: m, coll: db.Collection("survey_reminder_settings")}
+	err = m.applySurveyReminderSettingsChecks(surveyReminderSettings)
+	if err != nil {
+		return err
+	}
+
+	surveyReminders := &collectionWrapper{database: m, coll: db.Collection("survey_reminders")}
+	err = m.applySurveyRemindersChecks(surveyReminders)
+	if err != nil {
+		return err
+	}
 
 	alertContacts := &collectionWrapper{database: m, coll: db.Collection("alert_contacts")}
 	err = m.applyAlertContactsChecks(surveyResponses)
@@ -114,7 +177,14 @@ func (m *database) start() error {
 	m.settings = settings
 	m.surveys = surveys
 	m.surveyResponses = surveyResponses
+	m.surveyVersions = surveyVersions
+	m.surveyTemplates = surveyTemplates
+	m.surveyQuestions = surveyQuestions
 	m.alertContacts = alertContacts
+	m.surveyResponseAttempts = surveyResponseAttempts
+	m.surveyQuotaCounts = surveyQuotaCounts
+	m.surveyReminderSettings = surveyReminderSettings
+	m.surveyReminders = surveyReminders
 
 	return nil
 }
@@ -247,6 +317,16 @@ func (m *database) applySurveysChecks(surveys *collectionWrapper) error {
 		return err
 	}
 
+	err = surveys.AddIndex(bson.D{primitive.E{Key: "to_members.user_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	err = surveys.AddIndex(bson.D{primitive.E{Key: "group_ids", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
 	log.Println("surveys passed")
 	return nil
 }
@@ -264,10 +344,123 @@ func (m *database) applySurveyResponsesChecks(surveyResponses *collectionWrapper
 		return err
 	}
 
+	err = surveyResponses.AddIndex(bson.D{primitive.E{Key: "survey._id", Value: 1}, primitive.E{Key: "survey_version_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	// the responses in progress are removed when they expire. The completed responses do not have an expiration date
+	err = surveyResponses.AddIndexWithOptions(bson.D{primitive.E{Key: "date_expires", Value: 1}}, options.Index().SetExpireAfterSeconds(0))
+	if err != nil {
+		return err
+	}
+
+	// a user has only one response in progress for a survey
+	err = surveyResponses.AddIndexWithOptions(bson.D{primitive.E{Key: "user_id", Value: 1}, primitive.E{Key: "survey._id", Value: 1}},
+		options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"status": model.SurveyResponseStatusInProgress}))
+	if err != nil {
+		return err
+	}
+
+	// the encryption job looks for the responses to the sensitive surveys and for the responses encrypted with the previous keys
+	err = surveyResponses.AddIndex(bson.D{primitive.E{Key: "survey.sensitive", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	err = surveyResponses.AddIndex(bson.D{primitive.E{Key: "encrypted.key_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
 	log.Println("survey responses passed")
 	return nil
 }
 
+func (m *database) applySurveyVersionsChecks(surveyVersions *collectionWrapper) error {
+	log.Println("apply survey versions checks.....")
+
+	err := surveyVersions.AddIndex(bson.D{primitive.E{Key: "survey_id", Value: 1}, primitive.E{Key: "version", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey versions passed")
+	return nil
+}
+
+func (m *database) applySurveyTemplatesChecks(surveyTemplates *collectionWrapper) error {
+	log.Println("apply survey templates checks.....")
+
+	err := surveyTemplates.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "type", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey templates passed")
+	return nil
+}
+
+func (m *database) applySurveyQuestionsChecks(surveyQuestions *collectionWrapper) error {
+	log.Println("apply survey questions checks.....")
+
+	err := surveyQuestions.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey questions passed")
+	return nil
+}
+
+func (m *database) applySurveyResponseAttemptsChecks(surveyResponseAttempts *collectionWrapper) error {
+	log.Println("apply survey response attempts checks.....")
+
+	err := surveyResponseAttempts.AddIndex(bson.D{primitive.E{Key: "survey_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	err = surveyResponseAttempts.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "user_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey response attempts passed")
+	return nil
+}
+
+func (m *database) applySurveyReminderSettingsChecks(surveyReminderSettings *collectionWrapper) error {
+	log.Println("apply survey reminder settings checks.....")
+
+	err := surveyReminderSettings.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "user_id", Value: 1}}, true)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey reminder settings passed")
+	return nil
+}
+
+func (m *database) applySurveyRemindersChecks(surveyReminders *collectionWrapper) error {
+	log.Println("apply survey reminders checks.....")
+
+	// the reminders are kept longer than the longest schedule period, as they are needed only to send one reminder per period
+	err := surveyReminders.AddIndexWithOptions(bson.D{primitive.E{Key: "date_created", Value: 1}},
+		options.Index().SetExpireAfterSeconds(surveyRemindersExpirationSeconds))
+	if err != nil {
+		return err
+	}
+
+	err = surveyReminders.AddIndex(bson.D{primitive.E{Key: "org_id", Value: 1}, primitive.E{Key: "app_id", Value: 1}, primitive.E{Key: "user_id", Value: 1}}, false)
+	if err != nil {
+		return err
+	}
+
+	log.Println("survey reminders passed")
+	return nil
+}
+
 func (m *database) applyAlertContactsChecks(alertContacts *collectionWrapper) error {
 	log.Println("apply alert contacts checks.....")
 
@@ -279,3 +472,98 @@ func (m *database) applyAlertContactsChecks(alertContacts *collectionWrapper) er
 	log.Println("survey alert contacts passed")
 	return nil
 }
+
+func (m *database) loadResumeToken(collection string) bson.Raw {
+	var token resumeToken
+	err := m.resumeTokens.FindOne(bson.M{"_id": collection}, &token, nil)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("error loading the resume token for %s - %s\n", collection, err)
+		}
+		return nil
+	}
+	return token.Token
+}
+
+func (m *database) saveResumeToken(collection string, token bson.Raw) {
+	entry := resumeToken{Collection: collection, Token: token, DateUpdated: time.Now().UTC()}
+	_, err := m.resumeTokens.coll.ReplaceOne(context.Background(), bson.M{"_id": collection}, entry, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("error saving the resume token for %s - %s\n", collection, err)
+	}
+}
+
+func (m *database) deleteResumeToken(collection string) {
+	_, err := m.resumeTokens.DeleteOne(bson.M{"_id": collection}, nil)
+	if err != nil {
+		log.Printf("error deleting the resume token for %s - %s\n", collection, err)
+	}
+}
+
+func (m *database) getChangeStreamStatus(collection string) *model.ChangeStreamStatus {
+	status, ok := m.changeStreamsStatus[collection]
+	if !ok {
+		status = &model.ChangeStreamStatus{Collection: collection}
+		m.changeStreamsStatus[collection] = status
+	}
+	return status
+}
+
+func (m *database) onWatchStarted(collection string) {
+	m.changeStreamsLock.Lock()
+	defer m.changeStreamsLock.Unlock()
+
+	status := m.getChangeStreamStatus(collection)
+	status.Starting = true
+}
+
+func (m *database) onWatchConnected(collection string, resumed bool) {
+	m.changeStreamsLock.Lock()
+	defer m.changeStreamsLock.Unlock()
+
+	now := time.Now().UTC()
+	status := m.getChangeStreamStatus(collection)
+	status.Healthy = true
+	status.Starting = false
+	status.Resumed = resumed
+	status.ReconnectAttempts = 0
+	status.ConnectedSince = &now
+}
+
+func (m *database) onWatchEvent(collection string) {
+	m.changeStreamsLock.Lock()
+	defer m.changeStreamsLock.Unlock()
+
+	now := time.Now().UTC()
+	status := m.getChangeStreamStatus(collection)
+	status.LastEventAt = &now
+}
+
+func (m *database) onWatchFailed(collection string, err error) {
+	m.changeStreamsLock.Lock()
+	defer m.changeStreamsLock.Unlock()
+
+	now := time.Now().UTC()
+	errMessage := err.Error()
+	status := m.getChangeStreamStatus(collection)
+	status.Healthy = false
+	status.Starting = false
+	status.ConnectedSince = nil
+	status.ReconnectAttempts++
+	status.LastError = &errMessage
+	status.LastErrorAt = &now
+}
+
+func (m *database) getChangeStreamsStatus() []model.ChangeStreamStatus {
+	m.changeStreamsLock.RLock()
+	defer m.changeStreamsLock.RUnlock()
+
+	list := make([]model.ChangeStreamStatus, 0, len(m.changeStreamsStatus))
+	for _, status := range m.changeStreamsStatus {
+		list = append(list, *status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Collection < list[j].Collection
+	})
+	return list
+}
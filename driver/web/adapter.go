@@ -86,40 +86,84 @@ func (we Adapter) Start() {
 	// Client APIs
 	apiRouter.HandleFunc("/polls", we.userAuthWrapFunc(we.apisHandler.GetPolls)).Methods("GET")
 	apiRouter.HandleFunc("/polls/load", we.userAuthWrapFunc(we.apisHandler.LoadPolls)).Methods("POST")
+	apiRouter.HandleFunc("/polls/export", we.userAuthWrapFunc(we.apisHandler.ExportPolls)).Methods("POST")
 	apiRouter.HandleFunc("/polls", we.userAuthWrapFunc(we.apisHandler.CreatePoll)).Methods("POST")
 	apiRouter.HandleFunc("/polls/{id}", we.userAuthWrapFunc(we.apisHandler.GetPoll)).Methods("GET")
 	apiRouter.HandleFunc("/polls/{id}", we.userAuthWrapFunc(we.apisHandler.UpdatePoll)).Methods("PUT")
 	apiRouter.HandleFunc("/polls/{id}", we.userAuthWrapFunc(we.apisHandler.DeletePoll)).Methods("DELETE")
 	apiRouter.HandleFunc("/polls/{id}/events", we.userAuthWrapFunc(we.apisHandler.GetPollEvents)).Methods("GET")
+	apiRouter.HandleFunc("/polls/{id}/export", we.userAuthWrapFunc(we.apisHandler.ExportPoll)).Methods("GET")
 	apiRouter.HandleFunc("/polls/{id}/vote", we.userAuthWrapFunc(we.apisHandler.VotePoll)).Methods("PUT")
 	apiRouter.HandleFunc("/polls/{id}/start", we.userAuthWrapFunc(we.apisHandler.StartPoll)).Methods("PUT")
 	apiRouter.HandleFunc("/polls/{id}/end", we.userAuthWrapFunc(we.apisHandler.EndPoll)).Methods("PUT")
+	apiRouter.HandleFunc("/surveys", we.userAuthWrapFunc(we.apisHandler.GetSurveys)).Methods("GET")
+	apiRouter.HandleFunc("/surveys/assigned", we.userAuthWrapFunc(we.apisHandler.GetSurveysAssignedToUser)).Methods("GET")
 	apiRouter.HandleFunc("/surveys/{id}", we.userAuthWrapFunc(we.apisHandler.GetSurvey)).Methods("GET")
 	apiRouter.HandleFunc("/surveys", we.userAuthWrapFunc(we.apisHandler.CreateSurvey)).Methods("POST")
+	apiRouter.HandleFunc("/surveys/lint", we.userAuthWrapFunc(we.apisHandler.LintSurvey)).Methods("POST")
 	apiRouter.HandleFunc("/surveys/{id}", we.userAuthWrapFunc(we.apisHandler.UpdateSurvey)).Methods("PUT")
 	apiRouter.HandleFunc("/surveys/{id}", we.userAuthWrapFunc(we.apisHandler.DeleteSurvey)).Methods("DELETE")
+	apiRouter.HandleFunc("/surveys/{id}/stats/events", we.userAuthWrapFunc(we.apisHandler.GetSurveyStatsEvents)).Methods("GET")
+	apiRouter.HandleFunc("/surveys/{id}/results", we.userAuthWrapFunc(we.apisHandler.GetSurveyResults)).Methods("GET")
+	apiRouter.HandleFunc("/surveys/{id}/responses/export", we.userAuthWrapFunc(we.apisHandler.ExportSurveyResponses)).Methods("GET")
+	apiRouter.HandleFunc("/surveys/{id}/progress", we.userAuthWrapFunc(we.apisHandler.GetSurveyResponseProgress)).Methods("GET")
+	apiRouter.HandleFunc("/surveys/{id}/progress", we.userAuthWrapFunc(we.apisHandler.SaveSurveyResponseProgress)).Methods("PUT")
+	apiRouter.HandleFunc("/surveys/{id}/progress/finalize", we.userAuthWrapFunc(we.apisHandler.FinalizeSurveyResponseProgress)).Methods("POST")
+	apiRouter.HandleFunc("/survey-templates", we.userAuthWrapFunc(we.apisHandler.GetSurveyTemplates)).Methods("GET")
+	apiRouter.HandleFunc("/survey-templates/{id}", we.userAuthWrapFunc(we.apisHandler.GetSurveyTemplate)).Methods("GET")
+	apiRouter.HandleFunc("/survey-templates/{id}/surveys", we.userAuthWrapFunc(we.apisHandler.CreateSurveyFromTemplate)).Methods("POST")
+	apiRouter.HandleFunc("/survey-questions", we.userAuthWrapFunc(we.apisHandler.GetSurveyQuestions)).Methods("GET")
+	apiRouter.HandleFunc("/survey-questions/{id}", we.userAuthWrapFunc(we.apisHandler.GetSurveyQuestion)).Methods("GET")
 	apiRouter.HandleFunc("/survey-responses/{id}", we.userAuthWrapFunc(we.apisHandler.GetSurveyResponse)).Methods("GET")
 	apiRouter.HandleFunc("/survey-responses", we.userAuthWrapFunc(we.apisHandler.GetSurveyResponses)).Methods("GET")
 	apiRouter.HandleFunc("/survey-responses", we.userAuthWrapFunc(we.apisHandler.CreateSurveyResponse)).Methods("POST")
 	apiRouter.HandleFunc("/survey-responses/{id}", we.userAuthWrapFunc(we.apisHandler.UpdateSurveyResponse)).Methods("PUT")
 	apiRouter.HandleFunc("/survey-responses/{id}", we.userAuthWrapFunc(we.apisHandler.DeleteSurveyResponse)).Methods("DELETE")
 	apiRouter.HandleFunc("/survey-responses", we.userAuthWrapFunc(we.apisHandler.DeleteSurveyResponses)).Methods("DELETE")
+	apiRouter.HandleFunc("/survey-reminder-settings", we.userAuthWrapFunc(we.apisHandler.GetSurveyReminderSettings)).Methods("GET")
+	apiRouter.HandleFunc("/survey-reminder-settings", we.userAuthWrapFunc(we.apisHandler.UpdateSurveyReminderSettings)).Methods("PUT")
 	apiRouter.HandleFunc("/survey-alerts", we.userAuthWrapFunc(we.apisHandler.CreateSurveyAlert)).Methods("POST")
 	apiRouter.HandleFunc("/user-data", we.userAuthWrapFunc(we.apisHandler.GetUserData)).Methods("GET")
 
 	// handle admin apis
 	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
 
+	adminRouter.HandleFunc("/surveys", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveys)).Methods("GET")
 	adminRouter.HandleFunc("/surveys/{id}", we.adminAuthWrapFunc(we.adminApisHandler.GetSurvey)).Methods("GET")
 	adminRouter.HandleFunc("/surveys", we.adminAuthWrapFunc(we.adminApisHandler.CreateSurvey)).Methods("POST")
 	adminRouter.HandleFunc("/surveys/{id}", we.adminAuthWrapFunc(we.adminApisHandler.UpdateSurvey)).Methods("PUT")
 	adminRouter.HandleFunc("/surveys/{id}", we.adminAuthWrapFunc(we.adminApisHandler.DeleteSurvey)).Methods("DELETE")
+	adminRouter.HandleFunc("/surveys/{id}/versions", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyVersions)).Methods("GET")
+	adminRouter.HandleFunc("/surveys/{id}/versions/diff", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyVersionsDiff)).Methods("GET")
+	adminRouter.HandleFunc("/surveys/{id}/versions/{version_id}/rollback", we.adminAuthWrapFunc(we.adminApisHandler.RollbackSurvey)).Methods("POST")
+	adminRouter.HandleFunc("/surveys/{id}/bundle", we.adminAuthWrapFunc(we.adminApisHandler.ExportSurveyBundle)).Methods("GET")
+	adminRouter.HandleFunc("/surveys/import", we.adminAuthWrapFunc(we.adminApisHandler.ImportSurveyBundle)).Methods("POST")
+	adminRouter.HandleFunc("/surveys/import/surveyjs", we.adminAuthWrapFunc(we.adminApisHandler.ImportSurveyJS)).Methods("POST")
+	adminRouter.HandleFunc("/surveys/{id}/clone", we.adminAuthWrapFunc(we.adminApisHandler.CloneSurvey)).Methods("POST")
+	adminRouter.HandleFunc("/surveys/{id}/strings/untranslated", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyUntranslatedStrings)).Methods("GET")
+	adminRouter.HandleFunc("/surveys/{id}/results", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyResults)).Methods("GET")
+	adminRouter.HandleFunc("/surveys/{id}/responses/export", we.adminAuthWrapFunc(we.adminApisHandler.ExportSurveyResponses)).Methods("GET")
+	adminRouter.HandleFunc("/survey-templates", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyTemplates)).Methods("GET")
+	adminRouter.HandleFunc("/survey-templates/{id}", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyTemplate)).Methods("GET")
+	adminRouter.HandleFunc("/survey-templates", we.adminAuthWrapFunc(we.adminApisHandler.CreateSurveyTemplate)).Methods("POST")
+	adminRouter.HandleFunc("/survey-templates/{id}", we.adminAuthWrapFunc(we.adminApisHandler.UpdateSurveyTemplate)).Methods("PUT")
+	adminRouter.HandleFunc("/survey-templates/{id}", we.adminAuthWrapFunc(we.adminApisHandler.DeleteSurveyTemplate)).Methods("DELETE")
+	adminRouter.HandleFunc("/survey-questions", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyQuestions)).Methods("GET")
+	adminRouter.HandleFunc("/survey-questions/{id}", we.adminAuthWrapFunc(we.adminApisHandler.GetSurveyQuestion)).Methods("GET")
+	adminRouter.HandleFunc("/survey-questions", we.adminAuthWrapFunc(we.adminApisHandler.CreateSurveyQuestion)).Methods("POST")
+	adminRouter.HandleFunc("/survey-questions/{id}", we.adminAuthWrapFunc(we.adminApisHandler.UpdateSurveyQuestion)).Methods("PUT")
+	adminRouter.HandleFunc("/survey-questions/{id}", we.adminAuthWrapFunc(we.adminApisHandler.DeleteSurveyQuestion)).Methods("DELETE")
 	adminRouter.HandleFunc("/alert-contacts", we.adminAuthWrapFunc(we.adminApisHandler.GetAlertContacts)).Methods("GET")
 	adminRouter.HandleFunc("/alert-contacts/{id}", we.adminAuthWrapFunc(we.adminApisHandler.GetAlertContact)).Methods("GET")
 	adminRouter.HandleFunc("/alert-contacts", we.adminAuthWrapFunc(we.adminApisHandler.CreateAlertContact)).Methods("POST")
 	adminRouter.HandleFunc("/alert-contacts/{id}", we.adminAuthWrapFunc(we.adminApisHandler.UpdateAlertContact)).Methods("PUT")
 	adminRouter.HandleFunc("/alert-contacts/{id}", we.adminAuthWrapFunc(we.adminApisHandler.DeleteAlertContact)).Methods("DELETE")
 
+	// handle internal apis
+	internalRouter := apiRouter.PathPrefix("/int").Subrouter()
+
+	internalRouter.HandleFunc("/health", we.internalAPIKeyAuthWrapFunc(we.internalApisHandler.GetHealth)).Methods("GET")
+
 	var handler http.Handler = router
 	if len(we.corsAllowedOrigins) > 0 {
 		handler = webauth.SetupCORS(we.corsAllowedOrigins, we.corsAllowedHeaders, router)
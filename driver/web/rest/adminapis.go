@@ -16,11 +16,14 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"polls/core"
 	"polls/core/model"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -37,6 +40,7 @@ type AdminApisHandler struct {
 // @ID GetSurvey
 // @Accept json
 // @Produce json
+// @Param lang query string false "Comma separated languages of the survey texts. The string table keys are returned when no language is requested"
 // @Success 200 {object} model.Survey
 // @Failure 401
 // @Security UserAuth
@@ -46,7 +50,8 @@ func (h AdminApisHandler) GetSurvey(user *model.User, w http.ResponseWriter, r *
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetSurvey(user, id)
+	// the admins edit the survey as it is defined, so the browser languages are ignored
+	resData, err := h.app.Services.GetSurvey(user, id, true, explicitLanguagesFromRequest(r))
 	if err != nil {
 		log.Printf("Error on apis.GetSurvey(%s): %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -100,6 +105,12 @@ func (h AdminApisHandler) CreateSurvey(user *model.User, w http.ResponseWriter,
 	}
 
 	createdItem, err := h.app.Services.CreateSurvey(user, item, true)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.CreateSurvey: %s", err)
+		writeValidationError(w, lintErr)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.CreateSurvey: %s", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -151,6 +162,12 @@ func (h AdminApisHandler) UpdateSurvey(user *model.User, w http.ResponseWriter,
 	}
 
 	err = h.app.Services.UpdateSurvey(user, item, id, true)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.UpdateSurvey(%s): %s", id, err)
+		writeValidationError(w, lintErr)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.UpdateSurvey(%s): %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -162,6 +179,731 @@ func (h AdminApisHandler) UpdateSurvey(user *model.User, w http.ResponseWriter,
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetSurveys Retrieves all the surveys in the app/org by a filter params
+// @Description Retrieves all the surveys in the app/org by a filter params
+// @Tags Admin
+// @ID GetSurveys
+// @Param types query string false "Comma separated survey types"
+// @Param creator_ids query string false "Comma separated creator IDs"
+// @Param statuses query string false "Comma separated statuses"
+// @Param title query string false "Text contained in the title"
+// @Param start_date query string false "Created at or after this date (RFC3339)"
+// @Param end_date query string false "Created at or before this date (RFC3339)"
+// @Param limit query integer false "The number of results to be loaded. Default is 20"
+// @Param offset query integer false "The number of results previously loaded. Default is 0"
+// @Produce json
+// @Success 200 {array} model.Survey
+// @Security UserAuth
+// @Router /surveys [get]
+func (h AdminApisHandler) GetSurveys(user *model.User, w http.ResponseWriter, r *http.Request) {
+	filter, err := surveysFilterFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.GetSurveys: %v", err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.GetSurveys(user, *filter, true)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveys: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.Survey{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveys: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetSurveyVersions Retrieves the versions of a survey
+// @Description Retrieves the versions of a survey starting from the latest one, with the number of the responses to each version
+// @Tags Admin
+// @ID GetSurveyVersions
+// @Produce json
+// @Success 200 {array} model.SurveyVersion
+// @Security UserAuth
+// @Router /surveys/{id}/versions [get]
+func (h AdminApisHandler) GetSurveyVersions(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	versions, err := h.app.Services.GetSurveyVersions(user, id)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyVersions(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(versions)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyVersions(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// GetSurveyVersionsDiff Retrieves the differences between two versions of a survey
+// @Description Retrieves the differences between two versions of a survey
+// @Tags Admin
+// @ID GetSurveyVersionsDiff
+// @Param from query string true "ID of the older version"
+// @Param to query string true "ID of the newer version"
+// @Produce json
+// @Success 200 {object} model.SurveyVersionDiff
+// @Security UserAuth
+// @Router /surveys/{id}/versions/diff [get]
+func (h AdminApisHandler) GetSurveyVersionsDiff(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if len(from) == 0 || len(to) == 0 {
+		log.Printf("Error on apis.GetSurveyVersionsDiff(%s): missing from or to", id)
+		http.Error(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.app.Services.GetSurveyVersionsDiff(user, id, from, to)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyVersionsDiff(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	jsonData, err := json.Marshal(diff)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyVersionsDiff(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// RollbackSurvey Rolls back a survey to one of its versions
+// @Description Rolls back a survey to one of its versions. The rollback is stored as a new version
+// @Tags Admin
+// @ID RollbackSurvey
+// @Produce json
+// @Success 200 {object} model.Survey
+// @Security UserAuth
+// @Router /surveys/{id}/versions/{version_id}/rollback [post]
+func (h AdminApisHandler) RollbackSurvey(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	versionID := vars["version_id"]
+
+	survey, err := h.app.Services.RollbackSurvey(user, id, versionID)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.RollbackSurvey(%s, %s): %s", id, versionID, err)
+		writeValidationError(w, lintErr)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.RollbackSurvey(%s, %s): %s", id, versionID, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(survey)
+	if err != nil {
+		log.Printf("Error on apis.RollbackSurvey(%s, %s): %s", id, versionID, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ExportSurveyBundle Exports the definition of a survey
+// @Description Exports the definition of a survey as a self-contained bundle with a schema version and without ids, tenant or audience, so it can be imported to another app, org or environment
+// @Tags Admin
+// @ID ExportSurveyBundle
+// @Param id path string true "Survey ID"
+// @Produce json
+// @Success 200 {object} model.SurveyBundle
+// @Failure 401
+// @Security UserAuth
+// @Router /surveys/{id}/bundle [get]
+func (h AdminApisHandler) ExportSurveyBundle(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	bundle, err := h.app.Services.ExportSurveyBundle(user, id, true)
+	if err != nil {
+		log.Printf("Error on apis.ExportSurveyBundle(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(bundle)
+	if err != nil {
+		log.Printf("Error on apis.ExportSurveyBundle(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("survey-%s.json", id)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// ImportSurveyBundle Imports a survey definition
+// @Description Creates a draft survey from a survey bundle. The survey gets new ids and the app and org of the admin
+// @Tags Admin
+// @ID ImportSurveyBundle
+// @Param data body model.SurveyBundle true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Survey
+// @Failure 400 {object} model.SurveyImportError
+// @Failure 401
+// @Security UserAuth
+// @Router /surveys/import [post]
+func (h AdminApisHandler) ImportSurveyBundle(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.ImportSurveyBundle: %s", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var bundle model.SurveyBundle
+	err = json.Unmarshal(data, &bundle)
+	if err != nil {
+		log.Printf("Error on apis.ImportSurveyBundle: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	survey, err := h.app.Services.ImportSurveyBundle(user, bundle, true)
+	writeImportedSurvey(w, "ImportSurveyBundle", survey, err)
+}
+
+// ImportSurveyJS Imports a SurveyJS survey
+// @Description Creates a draft survey from a SurveyJS survey JSON. The pages become pages, the panels are flattened into their pages and the questions are mapped to the survey data types which accept the same responses. The content elements (html, image and expression) are skipped
+// @Tags Admin
+// @ID ImportSurveyJS
+// @Param data body object true "SurveyJS survey JSON"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Survey
+// @Failure 400 {object} model.SurveyImportError
+// @Failure 401
+// @Security UserAuth
+// @Router /surveys/import/surveyjs [post]
+func (h AdminApisHandler) ImportSurveyJS(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.ImportSurveyJS: %s", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	survey, err := h.app.Services.ImportSurveyJS(user, data, true)
+	writeImportedSurvey(w, "ImportSurveyJS", survey, err)
+}
+
+// CloneSurvey Clones a survey
+// @Description Creates a draft copy of a survey in the same or in another app and org. The audience and the group quotas are copied only within the same app and org.
+// @Description Cloning to another app or org requires a system admin or the all_apps_surveys permission
+// @Tags Admin
+// @ID CloneSurvey
+// @Param id path string true "Survey ID"
+// @Param data body model.SurveyClone false "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Survey
+// @Failure 400 {object} model.SurveyLint
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/clone [post]
+func (h AdminApisHandler) CloneSurvey(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.CloneSurvey(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var clone model.SurveyClone
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &clone)
+		if err != nil {
+			log.Printf("Error on apis.CloneSurvey(%s): %s", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	survey, err := h.app.Services.CloneSurvey(user, id, clone)
+	if errors.Is(err, model.ErrSurveyCloneNotAllowed) {
+		log.Printf("Error on apis.CloneSurvey(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyCloneNotAllowed.Error(), http.StatusForbidden)
+		return
+	}
+	writeImportedSurvey(w, fmt.Sprintf("CloneSurvey(%s)", id), survey, err)
+}
+
+// GetSurveyUntranslatedStrings Retrieves the untranslated strings of a survey
+// @Description Retrieves the localizable texts of a survey which are not translated, per language of its string tables
+// @Tags Admin
+// @ID GetSurveyUntranslatedStrings
+// @Param id path string true "Survey ID"
+// @Param languages query string false "Comma separated languages to report besides the languages of the string tables"
+// @Produce json
+// @Success 200 {object} model.SurveyTranslations
+// @Failure 401
+// @Security UserAuth
+// @Router /surveys/{id}/strings/untranslated [get]
+func (h AdminApisHandler) GetSurveyUntranslatedStrings(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var languages []string
+	if languagesRaw := r.URL.Query().Get("languages"); len(languagesRaw) > 0 {
+		languages = strings.Split(languagesRaw, ",")
+	}
+
+	translations, err := h.app.Services.GetSurveyTranslations(user, id, languages)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyUntranslatedStrings(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(translations)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyUntranslatedStrings(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetSurveyTemplates Retrieves the survey templates
+// @Description Retrieves the survey templates of the app and org
+// @Tags Admin
+// @ID GetSurveyTemplates
+// @Param types query string false "Comma separated survey types"
+// @Produce json
+// @Success 200 {array} model.SurveyTemplate
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates [get]
+func (h AdminApisHandler) GetSurveyTemplates(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyTemplates(h.app, user, w, r)
+}
+
+// GetSurveyTemplate Retrieves a survey template by id
+// @Description Retrieves a survey template by id
+// @Tags Admin
+// @ID GetSurveyTemplate
+// @Param id path string true "Survey template ID"
+// @Produce json
+// @Success 200 {object} model.SurveyTemplate
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates/{id} [get]
+func (h AdminApisHandler) GetSurveyTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyTemplate(h.app, user, w, r)
+}
+
+// CreateSurveyTemplate Creates a survey template
+// @Description Creates a survey template. The survey of the template is stored without ids, tenant, audience or publication fields and gets the type of the template
+// @Tags Admin
+// @ID CreateSurveyTemplate
+// @Param data body model.SurveyTemplate true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SurveyTemplate
+// @Failure 400 {object} model.SurveyLint
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates [post]
+func (h AdminApisHandler) CreateSurveyTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyTemplate: %s", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyTemplate
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyTemplate: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdItem, err := h.app.Services.CreateSurveyTemplate(user, item)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.CreateSurveyTemplate: %s", err)
+		writeValidationError(w, lintErr)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyTemplate: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(createdItem)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyTemplate: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// UpdateSurveyTemplate Updates a survey template with the specified id
+// @Description Updates a survey template with the specified id
+// @Tags Admin
+// @ID UpdateSurveyTemplate
+// @Param id path string true "Survey template ID"
+// @Param data body model.SurveyTemplate true "body json"
+// @Accept json
+// @Success 200
+// @Failure 400 {object} model.SurveyLint
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates/{id} [put]
+func (h AdminApisHandler) UpdateSurveyTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyTemplate(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyTemplate
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyTemplate(%s): %s", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.app.Services.UpdateSurveyTemplate(user, id, item)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.UpdateSurveyTemplate(%s): %s", id, err)
+		writeValidationError(w, lintErr)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyTemplate(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteSurveyTemplate Deletes a survey template with the specified id
+// @Description Deletes a survey template with the specified id. The surveys created from the template are not changed
+// @Tags Admin
+// @ID DeleteSurveyTemplate
+// @Param id path string true "Survey template ID"
+// @Success 200
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates/{id} [delete]
+func (h AdminApisHandler) DeleteSurveyTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := h.app.Services.DeleteSurveyTemplate(user, id)
+	if err != nil {
+		log.Printf("Error on apis.DeleteSurveyTemplate(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetSurveyQuestions Retrieves the questions of the question bank
+// @Description Retrieves the questions of the question bank of the app and org
+// @Tags Admin
+// @ID GetSurveyQuestions
+// @Produce json
+// @Success 200 {array} model.SurveyQuestion
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions [get]
+func (h AdminApisHandler) GetSurveyQuestions(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyQuestions(h.app, user, w, r)
+}
+
+// GetSurveyQuestion Retrieves a question of the question bank by id
+// @Description Retrieves a question of the question bank by id
+// @Tags Admin
+// @ID GetSurveyQuestion
+// @Param id path string true "Survey question ID"
+// @Produce json
+// @Success 200 {object} model.SurveyQuestion
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions/{id} [get]
+func (h AdminApisHandler) GetSurveyQuestion(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyQuestion(h.app, user, w, r)
+}
+
+// CreateSurveyQuestion Creates a question of the question bank
+// @Description Creates a question of the question bank. The survey data reference it with their question_id and get its definition, while their flow stays defined by the survey
+// @Tags Admin
+// @ID CreateSurveyQuestion
+// @Param data body model.SurveyQuestion true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SurveyQuestion
+// @Failure 400
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions [post]
+func (h AdminApisHandler) CreateSurveyQuestion(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyQuestion: %s", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyQuestion
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyQuestion: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdItem, err := h.app.Services.CreateSurveyQuestion(user, item)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyQuestion: %s", err)
+		if errors.Is(err, model.ErrInvalidSurveyQuestion) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(createdItem)
+	if err != nil {
+		log.Printf("Error on apis.CreateSurveyQuestion: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// UpdateSurveyQuestion Updates a question of the question bank with the specified id
+// @Description Updates a question of the question bank with the specified id. Every survey which references the question is stored as a new survey version with the new definition
+// @Tags Admin
+// @ID UpdateSurveyQuestion
+// @Param id path string true "Survey question ID"
+// @Param data body model.SurveyQuestion true "body json"
+// @Accept json
+// @Success 200
+// @Success 207 {object} model.SurveyQuestionPropagationError
+// @Failure 400
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions/{id} [put]
+func (h AdminApisHandler) UpdateSurveyQuestion(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyQuestion(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyQuestion
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyQuestion(%s): %s", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.app.Services.UpdateSurveyQuestion(user, id, item)
+	var propagationErr *model.SurveyQuestionPropagationError
+	if errors.As(err, &propagationErr) {
+		log.Printf("Error on apis.UpdateSurveyQuestion(%s): %s", id, err)
+		jsonData, err := json.Marshal(propagationErr)
+		if err != nil {
+			log.Printf("Error on apis.UpdateSurveyQuestion(%s): %s", id, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write(jsonData)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyQuestion(%s): %s", id, err)
+		if errors.Is(err, model.ErrInvalidSurveyQuestion) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteSurveyQuestion Deletes a question of the question bank with the specified id
+// @Description Deletes a question of the question bank with the specified id. The questions which are referenced by surveys can not be deleted
+// @Tags Admin
+// @ID DeleteSurveyQuestion
+// @Param id path string true "Survey question ID"
+// @Success 200
+// @Failure 401
+// @Failure 409
+// @Security UserAuth
+// @Router /survey-questions/{id} [delete]
+func (h AdminApisHandler) DeleteSurveyQuestion(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := h.app.Services.DeleteSurveyQuestion(user, id)
+	if err != nil {
+		log.Printf("Error on apis.DeleteSurveyQuestion(%s): %s", id, err)
+		if errors.Is(err, model.ErrSurveyQuestionInUse) {
+			http.Error(w, model.ErrSurveyQuestionInUse.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetSurveyResults Retrieves the aggregated results of a survey
+// @Description Retrieves the aggregated results of the completed responses to a survey: the counts of the options, the distributions of the numeric responses and the scores, and the completion rates.
+// @Tags Admin
+// @ID GetSurveyResults
+// @Param id path string true "Survey ID"
+// @Param start_date query string false "Responses created at or after this date (RFC3339)"
+// @Param end_date query string false "Responses created before this date (RFC3339)"
+// @Param version_ids query string false "Comma separated survey version IDs"
+// @Produce json
+// @Success 200 {object} model.SurveyResults
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/results [get]
+func (h AdminApisHandler) GetSurveyResults(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	filter, err := surveyResultsFilterFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.GetSurveyResults(%s): %v", id, err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.GetSurveyResults(user, id, *filter, true)
+	if errors.Is(err, model.ErrNotSurveyCreator) {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, model.ErrNotSurveyCreator.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ExportSurveyResponses Exports the responses to a survey
+// @Description Exports the completed responses to a survey as CSV with one column per question in question order, or as JSON Lines. The export is streamed. The user ids are always pseudonymized for the sensitive surveys, and their responses are exported only when they have at least the minimum number of respondents
+// @Tags Admin
+// @ID ExportSurveyResponses
+// @Param id path string true "Survey ID"
+// @Param format query string false "csv (default) or jsonl"
+// @Param start_date query string false "Responses created at or after this date (RFC3339)"
+// @Param end_date query string false "Responses created before this date (RFC3339)"
+// @Param version_ids query string false "Comma separated survey version IDs"
+// @Param pseudonymize query bool false "Replace the user ids by pseudonyms which are stable for the survey"
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/responses/export [get]
+func (h AdminApisHandler) ExportSurveyResponses(user *model.User, w http.ResponseWriter, r *http.Request) {
+	exportSurveyResponses(h.app, user, w, r, true)
+}
+
 // DeleteSurvey Deletes a survey with the specified id
 // @Description Deletes a survey with the specified id
 // @Tags Admin
@@ -16,6 +16,7 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -67,6 +68,7 @@ func NewInternalApisHandler(app *core.Application, config *model.Config) Interna
 // @Description Retrieves  all polls by a filter params
 // @Tags Client
 // @ID GetPolls
+// @Param lang query string false "Comma separated languages of the poll texts, which take precedence over the Accept-Language header"
 // @Success 200 {array} model.PollResult
 // @Security UserAuth
 // @Router /polls [get]
@@ -149,7 +151,7 @@ func (h ApisHandler) GetPolls(user *model.User, w http.ResponseWriter, r *http.R
 	}
 	filter.Offset = &offset
 
-	resData, err := h.app.Services.GetPolls(user, filter, true)
+	resData, err := h.app.Services.GetPolls(user, filter, true, languagesFromRequest(r))
 	if err != nil {
 		log.Printf("Error on apis.GetPolls(): %s", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -179,6 +181,7 @@ func (h ApisHandler) GetPolls(user *model.User, w http.ResponseWriter, r *http.R
 // @Description Retrieves  all polls by a filter params
 // @Tags Client
 // @ID LoadPolls
+// @Param lang query string false "Comma separated languages of the poll texts, which take precedence over the Accept-Language header"
 // @Param data body model.PollsFilter false "body json for defined poll ids as request body"
 // @Success 200 {array} model.PollResult
 // @Security UserAuth
@@ -196,7 +199,7 @@ func (h ApisHandler) LoadPolls(user *model.User, w http.ResponseWriter, r *http.
 		}
 	}
 
-	resData, err := h.app.Services.GetPolls(user, filter, true)
+	resData, err := h.app.Services.GetPolls(user, filter, true, languagesFromRequest(r))
 	if err != nil {
 		log.Printf("Error on apis.LoadPolls(): %s", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -222,10 +225,103 @@ func (h ApisHandler) LoadPolls(user *model.User, w http.ResponseWriter, r *http.
 	w.Write(data)
 }
 
+// ExportPolls Exports the outcomes of the polls matching a filter
+// @Description Exports the outcomes of the polls matching a filter: the question, the options with their counts, the unique voters and the timestamps. Only the polls created by the user and the polls of the groups administered by the user are exported. The answers of every voter are exported on request for the polls of a group or to a list of members
+// @Tags Client
+// @ID ExportPolls
+// @Accept json
+// @Param data body model.PollsFilter false "body json for the polls filter"
+// @Param format query string false "csv (default) or json"
+// @Param voters query bool false "Include the answers of every voter where it is allowed"
+// @Produce text/csv
+// @Produce json
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Security UserAuth
+// @Router /polls/export [post]
+func (h ApisHandler) ExportPolls(user *model.User, w http.ResponseWriter, r *http.Request) {
+	export, err := pollsExportFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.ExportPolls: %v", err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var filter model.PollsFilter
+	bodyData, _ := io.ReadAll(r.Body)
+	if bodyData != nil && len(bodyData) > 0 {
+		err := json.Unmarshal(bodyData, &filter)
+		if err != nil {
+			log.Printf("Error on apis.ExportPolls(): %s", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writer := &exportResponseWriter{w: w, contentType: pollsExportContentTypes[export.Format], fileName: fmt.Sprintf("polls.%s", export.Format)}
+	err = h.app.Services.ExportPolls(user, filter, *export, writer)
+	if err != nil {
+		log.Printf("Error on apis.ExportPolls(): %s", err)
+		if !writer.started {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	writer.start()
+}
+
+// ExportPoll Exports the outcome of a poll
+// @Description Exports the outcome of a poll: the question, the options with their counts, the unique voters and the timestamps. Only the creator of the poll and the admins of its group can export it. The answers of every voter are exported on request for the polls of a group or to a list of members
+// @Tags Client
+// @ID ExportPoll
+// @Param id path string true "Poll ID"
+// @Param format query string false "csv (default) or json"
+// @Param voters query bool false "Include the answers of every voter where it is allowed"
+// @Produce text/csv
+// @Produce json
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Security UserAuth
+// @Router /polls/{id}/export [get]
+func (h ApisHandler) ExportPoll(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	export, err := pollsExportFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.ExportPoll(%s): %v", id, err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer := &exportResponseWriter{w: w, contentType: pollsExportContentTypes[export.Format], fileName: fmt.Sprintf("poll-%s.%s", id, export.Format)}
+	err = h.app.Services.ExportPoll(user, id, *export, writer)
+	if err != nil {
+		log.Printf("Error on apis.ExportPoll(%s): %s", id, err)
+		if writer.started {
+			return
+		}
+		if errors.Is(err, model.ErrNotPollCreator) {
+			http.Error(w, model.ErrNotPollCreator.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	writer.start()
+}
+
 // GetPoll Retrieves a poll by id
 // @Description Retrieves a poll by id
 // @Tags Client
 // @ID GetPoll
+// @Param lang query string false "Comma separated languages of the poll texts, which take precedence over the Accept-Language header"
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Poll
@@ -236,7 +332,7 @@ func (h ApisHandler) GetPoll(user *model.User, w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, languagesFromRequest(r))
 	if err != nil {
 		log.Printf("Error on apis.GetPoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -276,7 +372,7 @@ func (h ApisHandler) UpdatePoll(user *model.User, w http.ResponseWriter, r *http
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.UpdatePoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -307,6 +403,10 @@ func (h ApisHandler) UpdatePoll(user *model.User, w http.ResponseWriter, r *http
 	resData, err = h.app.Services.UpdatePoll(user, item)
 	if err != nil {
 		log.Printf("Error on apis.UpdatePoll(%s): %s", id, err)
+		if errors.Is(err, model.ErrInvalidPollTranslation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -352,6 +452,10 @@ func (h ApisHandler) CreatePoll(user *model.User, w http.ResponseWriter, r *http
 	createdItem, err := h.app.Services.CreatePoll(user, item)
 	if err != nil {
 		log.Printf("Error on apis.CreatePoll: %s", err)
+		if errors.Is(err, model.ErrInvalidPollTranslation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
@@ -379,7 +483,7 @@ func (h ApisHandler) DeletePoll(user *model.User, w http.ResponseWriter, r *http
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.DeletePoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -414,7 +518,7 @@ func (h ApisHandler) GetPollEvents(user *model.User, w http.ResponseWriter, r *h
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.GetPollEvents(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -473,7 +577,7 @@ func (h ApisHandler) VotePoll(user *model.User, w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.VotePoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -528,7 +632,7 @@ func (h ApisHandler) StartPoll(user *model.User, w http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.StartPoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -564,7 +668,7 @@ func (h ApisHandler) EndPoll(user *model.User, w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetPoll(user, id)
+	resData, err := h.app.Services.GetPoll(user, id, nil)
 	if err != nil {
 		log.Printf("Error on apis.EndPoll(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -593,6 +697,7 @@ func (h ApisHandler) EndPoll(user *model.User, w http.ResponseWriter, r *http.Re
 // @ID GetSurvey
 // @Accept json
 // @Produce json
+// @Param lang query string false "Comma separated languages of the survey texts, which take precedence over the Accept-Language header"
 // @Success 200 {object} model.Survey
 // @Failure 401
 // @Security UserAuth
@@ -602,7 +707,7 @@ func (h ApisHandler) GetSurvey(user *model.User, w http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	resData, err := h.app.Services.GetSurvey(user, id)
+	resData, err := h.app.Services.GetSurvey(user, id, false, languagesFromRequest(r))
 	if err != nil {
 		log.Printf("Error on apis.GetSurvey(%s): %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -629,6 +734,111 @@ func (h ApisHandler) GetSurvey(user *model.User, w http.ResponseWriter, r *http.
 	w.Write(data)
 }
 
+// GetSurveys Retrieves the surveys visible to the current user by a filter params
+// @Description Retrieves the surveys of the current user and the ones targeted at the current user by a filter params. Drafts of the other users are not included, nor their archived surveys unless the archived status is requested
+// @Tags Client
+// @ID GetSurveys
+// @Param types query string false "Comma separated survey types"
+// @Param creator_ids query string false "Comma separated creator IDs"
+// @Param statuses query string false "Comma separated statuses"
+// @Param title query string false "Text contained in the title"
+// @Param start_date query string false "Created at or after this date (RFC3339)"
+// @Param end_date query string false "Created at or before this date (RFC3339)"
+// @Param limit query integer false "The number of results to be loaded. Default is 20"
+// @Param offset query integer false "The number of results previously loaded. Default is 0"
+// @Produce json
+// @Success 200 {array} model.Survey
+// @Security UserAuth
+// @Router /surveys [get]
+func (h ApisHandler) GetSurveys(user *model.User, w http.ResponseWriter, r *http.Request) {
+	filter, err := surveysFilterFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.GetSurveys: %v", err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.GetSurveys(user, *filter, false)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveys: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.Survey{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveys: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetSurveysAssignedToUser Retrieves the surveys assigned to the current user
+// @Description Retrieves the surveys targeted at the current user directly, through the groups of the user or at everyone. Drafts and archived surveys are not included
+// @Tags Client
+// @ID GetSurveysAssignedToUser
+// @Param limit query integer false "The number of results to be loaded. Default is 20"
+// @Param offset query integer false "The number of results previously loaded. Default is 0"
+// @Produce json
+// @Success 200 {array} model.Survey
+// @Security UserAuth
+// @Router /surveys/assigned [get]
+func (h ApisHandler) GetSurveysAssignedToUser(user *model.User, w http.ResponseWriter, r *http.Request) {
+	limitRaw := r.URL.Query().Get("limit")
+	limit := 20
+	if len(limitRaw) > 0 {
+		intParsed, err := strconv.Atoi(limitRaw)
+		if err != nil {
+			err = fmt.Errorf("error on apis.GetSurveysAssignedToUser: invalid limit - %v", err)
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit = intParsed
+	}
+	offsetRaw := r.URL.Query().Get("offset")
+	offset := 0
+	if len(offsetRaw) > 0 {
+		intParsed, err := strconv.Atoi(offsetRaw)
+		if err != nil {
+			err = fmt.Errorf("error on apis.GetSurveysAssignedToUser: invalid offset - %v", err)
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		offset = intParsed
+	}
+
+	resData, err := h.app.Services.GetSurveysAssignedToUser(user, &limit, &offset)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveysAssignedToUser: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if resData == nil {
+		resData = []model.Survey{}
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveysAssignedToUser: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
 // CreateSurvey Create a new survey
 // @Description Create a new survey
 // @Tags Client
@@ -636,6 +846,7 @@ func (h ApisHandler) GetSurvey(user *model.User, w http.ResponseWriter, r *http.
 // @Param data body model.Survey true "body json"
 // @Accept json
 // @Success 200 {object} model.Survey
+// @Failure 400 {object} model.SurveyLint
 // @Security UserAuth
 // @Router /surveys [post]
 func (h ApisHandler) CreateSurvey(user *model.User, w http.ResponseWriter, r *http.Request) {
@@ -656,6 +867,12 @@ func (h ApisHandler) CreateSurvey(user *model.User, w http.ResponseWriter, r *ht
 	}
 
 	createdItem, err := h.app.Services.CreateSurvey(user, item, false)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.CreateSurvey: %s", err)
+		writeValidationError(w, lintErr)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.CreateSurvey: %s", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -674,6 +891,46 @@ func (h ApisHandler) CreateSurvey(user *model.User, w http.ResponseWriter, r *ht
 	w.Write(jsonData)
 }
 
+// LintSurvey Checks a survey definition without saving it
+// @Description Checks the flow of a survey definition without saving it. Reports duplicate keys, dangling keys, missing page keys, invalid rules, unreachable questions and loops without an exit
+// @Tags Client
+// @ID LintSurvey
+// @Param data body model.Survey true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SurveyLint
+// @Security UserAuth
+// @Router /surveys/lint [post]
+func (h ApisHandler) LintSurvey(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.LintSurvey: %s", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.Survey
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.LintSurvey: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lint := h.app.Services.LintSurvey(item)
+
+	jsonData, err := json.Marshal(lint)
+	if err != nil {
+		log.Printf("Error on apis.LintSurvey: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
 // UpdateSurvey Updates a survey type with the specified id
 // @Description Updates a survey type with the specified id
 // @Tags Client
@@ -682,6 +939,7 @@ func (h ApisHandler) CreateSurvey(user *model.User, w http.ResponseWriter, r *ht
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Survey
+// @Failure 400 {object} model.SurveyLint
 // @Failure 401
 // @Security UserAuth
 // @Router /surveys/{id} [put]
@@ -707,6 +965,12 @@ func (h ApisHandler) UpdateSurvey(user *model.User, w http.ResponseWriter, r *ht
 	}
 
 	err = h.app.Services.UpdateSurvey(user, item, id, false)
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.UpdateSurvey(%s): %s", id, err)
+		writeValidationError(w, lintErr)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.UpdateSurvey(%s): %s", id, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -741,6 +1005,201 @@ func (h ApisHandler) DeleteSurvey(user *model.User, w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetSurveyStatsEvents Subscribes to the aggregated stats of a survey as SSE
+// @Description Subscribes to the aggregated stats of a survey as SSE. Only the creator of the survey can subscribe
+// @Tags Client
+// @ID GetSurveyStatsEvents
+// @Produce json
+// @Success 200 {object} model.SurveyLiveStats
+// @Security UserAuth
+// @Router /surveys/{id}/stats/events [get]
+func (h ApisHandler) GetSurveyStatsEvents(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Connection doesn't support streaming", http.StatusBadRequest)
+		return
+	}
+
+	resultChan := make(chan map[string]interface{}, 1)
+	err := h.app.Services.SubscribeToSurveyStats(user, id, resultChan)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyStatsEvents(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	defer h.app.Services.UnsubscribeFromSurveyStats(user, id, resultChan)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	for {
+		select {
+		case data := <-resultChan:
+			jsonData, err := json.Marshal(data)
+			if err != nil {
+				log.Printf("Error on apis.GetSurveyStatsEvents(%s): %s", id, err)
+				continue
+			}
+			w.Write(jsonData)
+			flusher.Flush()
+		case <-r.Context().Done():
+			log.Printf("closing survey stats event stream for user %s and survey %s", user.Claims.Subject, id)
+			return
+		}
+	}
+}
+
+// GetSurveyResults Retrieves the aggregated results of a survey
+// @Description Retrieves the aggregated results of the completed responses to a survey: the counts of the options, the distributions of the numeric responses and the scores, and the completion rates. Only the creator of the survey can see its results
+// @Tags Client
+// @ID GetSurveyResults
+// @Param id path string true "Survey ID"
+// @Param start_date query string false "Responses created at or after this date (RFC3339)"
+// @Param end_date query string false "Responses created before this date (RFC3339)"
+// @Param version_ids query string false "Comma separated survey version IDs"
+// @Produce json
+// @Success 200 {object} model.SurveyResults
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/results [get]
+func (h ApisHandler) GetSurveyResults(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	filter, err := surveyResultsFilterFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.GetSurveyResults(%s): %v", id, err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.GetSurveyResults(user, id, *filter, false)
+	if errors.Is(err, model.ErrNotSurveyCreator) {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, model.ErrNotSurveyCreator.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResults(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ExportSurveyResponses Exports the responses to a survey
+// @Description Exports the completed responses to a survey as CSV with one column per question in question order, or as JSON Lines. The export is streamed. Only the creator of the survey can export its responses. The responses to the sensitive surveys are exported only to the admins
+// @Tags Client
+// @ID ExportSurveyResponses
+// @Param id path string true "Survey ID"
+// @Param format query string false "csv (default) or jsonl"
+// @Param start_date query string false "Responses created at or after this date (RFC3339)"
+// @Param end_date query string false "Responses created before this date (RFC3339)"
+// @Param version_ids query string false "Comma separated survey version IDs"
+// @Param pseudonymize query bool false "Replace the user ids by pseudonyms which are stable for the survey"
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Success 200
+// @Failure 400
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/responses/export [get]
+func (h ApisHandler) ExportSurveyResponses(user *model.User, w http.ResponseWriter, r *http.Request) {
+	exportSurveyResponses(h.app, user, w, r, false)
+}
+
+// GetSurveyTemplates Retrieves the survey templates
+// @Description Retrieves the survey templates curated by the admins, optionally of the given survey types
+// @Tags Client
+// @ID GetSurveyTemplates
+// @Param types query string false "Comma separated survey types"
+// @Produce json
+// @Success 200 {array} model.SurveyTemplate
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates [get]
+func (h ApisHandler) GetSurveyTemplates(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyTemplates(h.app, user, w, r)
+}
+
+// GetSurveyTemplate Retrieves a survey template by id
+// @Description Retrieves a survey template by id
+// @Tags Client
+// @ID GetSurveyTemplate
+// @Param id path string true "Survey template ID"
+// @Produce json
+// @Success 200 {object} model.SurveyTemplate
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates/{id} [get]
+func (h ApisHandler) GetSurveyTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyTemplate(h.app, user, w, r)
+}
+
+// CreateSurveyFromTemplate Creates a survey from a template
+// @Description Creates a draft survey of the current user from a survey template. The survey gets the type of the template
+// @Tags Client
+// @ID CreateSurveyFromTemplate
+// @Param id path string true "Survey template ID"
+// @Produce json
+// @Success 200 {object} model.Survey
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-templates/{id}/surveys [post]
+func (h ApisHandler) CreateSurveyFromTemplate(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	survey, err := h.app.Services.CreateSurveyFromTemplate(user, id)
+	writeImportedSurvey(w, fmt.Sprintf("CreateSurveyFromTemplate(%s)", id), survey, err)
+}
+
+// GetSurveyQuestions Retrieves the questions of the question bank
+// @Description Retrieves the questions of the question bank, which the survey data can reference with their question_id
+// @Tags Client
+// @ID GetSurveyQuestions
+// @Produce json
+// @Success 200 {array} model.SurveyQuestion
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions [get]
+func (h ApisHandler) GetSurveyQuestions(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyQuestions(h.app, user, w, r)
+}
+
+// GetSurveyQuestion Retrieves a question of the question bank by id
+// @Description Retrieves a question of the question bank by id
+// @Tags Client
+// @ID GetSurveyQuestion
+// @Param id path string true "Survey question ID"
+// @Produce json
+// @Success 200 {object} model.SurveyQuestion
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-questions/{id} [get]
+func (h ApisHandler) GetSurveyQuestion(user *model.User, w http.ResponseWriter, r *http.Request) {
+	getSurveyQuestion(h.app, user, w, r)
+}
+
 // GetSurveyResponses retrieves SurveyResponses for the current user
 // @Description Retrieves SurveyResponses for the current user
 // @Tags Client
@@ -873,11 +1332,13 @@ func (h ApisHandler) GetSurveyResponse(user *model.User, w http.ResponseWriter,
 
 // CreateSurveyResponse Create a new survey response
 // @Description Create a new survey response
+// @Description The survey rules are evaluated on the server to compute the stats, the scores and the result of the response. The responses to the survey data which are not reached are dropped
 // @Tags Client
 // @ID CreateSurveyResponse
 // @Param data body model.Survey true "body json"
 // @Accept json
 // @Success 200 {object} model.SurveyResponse
+// @Failure 400 {object} model.SurveyResponseValidationError
 // @Security UserAuth
 // @Router /survey-responses [post]
 func (h ApisHandler) CreateSurveyResponse(user *model.User, w http.ResponseWriter, r *http.Request) {
@@ -898,6 +1359,32 @@ func (h ApisHandler) CreateSurveyResponse(user *model.User, w http.ResponseWrite
 	}
 
 	createdItem, err := h.app.Services.CreateSurveyResponse(user, item)
+	var validationErr *model.SurveyResponseValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
+		writeValidationError(w, validationErr)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotOpen) {
+		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
+		http.Error(w, model.ErrSurveyNotOpen.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotAssigned) {
+		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
+		http.Error(w, model.ErrSurveyNotAssigned.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyResponseLimitReached) {
+		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
+		http.Error(w, model.ErrSurveyResponseLimitReached.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyQuotaReached) {
+		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
+		http.Error(w, model.ErrSurveyQuotaReached.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.CreateSurveyResponse: %s", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -917,14 +1404,17 @@ func (h ApisHandler) CreateSurveyResponse(user *model.User, w http.ResponseWrite
 }
 
 // UpdateSurveyResponse Updates a survey response type with the specified id
-// @Description Updates a survey response type with the specified id
+// @Description Updates a completed survey response with the specified id. The responses in progress are completed only by finalizing them.
+// @Description An update does not count as a new response in the response limits and the quotas of the survey
 // @Tags Client
 // @ID UpdateSurveyResponse
 // @Param data body model.Survey true "body json"
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.SurveyResponse
+// @Failure 400 {object} model.SurveyResponseValidationError
 // @Failure 401
+// @Failure 409
 // @Security UserAuth
 // @Router /survey-responses/{id} [put]
 func (h ApisHandler) UpdateSurveyResponse(user *model.User, w http.ResponseWriter, r *http.Request) {
@@ -949,6 +1439,32 @@ func (h ApisHandler) UpdateSurveyResponse(user *model.User, w http.ResponseWrite
 	}
 
 	err = h.app.Services.UpdateSurveyResponse(user, id, item)
+	var validationErr *model.SurveyResponseValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("Error on apis.UpdateSurveyResponse(%s): %s", id, err)
+		writeValidationError(w, validationErr)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyResponseSurveyMismatch) {
+		log.Printf("Error on apis.UpdateSurveyResponse(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyResponseSurveyMismatch.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyResponseInProgress) {
+		log.Printf("Error on apis.UpdateSurveyResponse(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyResponseInProgress.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotOpen) {
+		log.Printf("Error on apis.UpdateSurveyResponse(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotOpen.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotAssigned) {
+		log.Printf("Error on apis.UpdateSurveyResponse(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotAssigned.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		log.Printf("Error on apis.DeleteSurveyResponse(%s): %s", id, err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -959,6 +1475,182 @@ func (h ApisHandler) UpdateSurveyResponse(user *model.User, w http.ResponseWrite
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetSurveyResponseProgress Retrieves the survey response in progress of the current user
+// @Description Retrieves the survey response in progress of the current user, so that the survey can be resumed on another device
+// @Tags Client
+// @ID GetSurveyResponseProgress
+// @Param id path string true "Survey ID"
+// @Produce json
+// @Success 200 {object} model.SurveyResponse
+// @Failure 401
+// @Failure 404
+// @Security UserAuth
+// @Router /surveys/{id}/progress [get]
+func (h ApisHandler) GetSurveyResponseProgress(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	resData, err := h.app.Services.GetSurveyResponseProgress(user, id)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if resData == nil {
+		log.Printf("Error on apis.GetSurveyResponseProgress(%s): not found", id)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// SaveSurveyResponseProgress Saves the partial responses of the current user to a survey
+// @Description Saves the partial responses of the current user to a survey and the key of the current question.
+// @Description The responses saved before are kept unless they are sent again. The responses are validated when the response is finalized
+// @Tags Client
+// @ID SaveSurveyResponseProgress
+// @Param id path string true "Survey ID"
+// @Param data body model.SurveyResponseProgress true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SurveyResponse
+// @Failure 400 {object} model.SurveyResponseValidationError
+// @Failure 401
+// @Failure 403
+// @Security UserAuth
+// @Router /surveys/{id}/progress [put]
+func (h ApisHandler) SaveSurveyResponseProgress(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyResponseProgress
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.SaveSurveyResponseProgress(user, id, item)
+	var validationErr *model.SurveyResponseValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		writeValidationError(w, validationErr)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotOpen) {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotOpen.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotAssigned) {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotAssigned.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.SaveSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// FinalizeSurveyResponseProgress Completes the survey response in progress of the current user
+// @Description Completes the survey response in progress of the current user. The saved responses are evaluated and validated as a new survey response
+// @Tags Client
+// @ID FinalizeSurveyResponseProgress
+// @Param id path string true "Survey ID"
+// @Produce json
+// @Success 200 {object} model.SurveyResponse
+// @Failure 400 {object} model.SurveyResponseValidationError
+// @Failure 401
+// @Failure 403
+// @Failure 404
+// @Security UserAuth
+// @Router /surveys/{id}/progress/finalize [post]
+func (h ApisHandler) FinalizeSurveyResponseProgress(user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	resData, err := h.app.Services.FinalizeSurveyResponseProgress(user, id)
+	var validationErr *model.SurveyResponseValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		writeValidationError(w, validationErr)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyResponseProgressNotFound) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyResponseProgressNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotOpen) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotOpen.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyNotAssigned) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyNotAssigned.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyResponseLimitReached) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyResponseLimitReached.Error(), http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, model.ErrSurveyQuotaReached) {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, model.ErrSurveyQuotaReached.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.FinalizeSurveyResponseProgress(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
 // DeleteSurveyResponse Deletes a survey response with the specified id
 // @Description Deletes a survey response with the specified id
 // @Tags Client
@@ -1038,6 +1730,87 @@ func (h ApisHandler) DeleteSurveyResponses(user *model.User, w http.ResponseWrit
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetSurveyReminderSettings Retrieves the survey reminder settings of the current user
+// @Description Retrieves the timezone of the current user for the reminders of the scheduled surveys and the reminders the user opted out of
+// @Tags Client
+// @ID GetSurveyReminderSettings
+// @Produce json
+// @Success 200 {object} model.SurveyReminderSettings
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-reminder-settings [get]
+func (h ApisHandler) GetSurveyReminderSettings(user *model.User, w http.ResponseWriter, r *http.Request) {
+	resData, err := h.app.Services.GetSurveyReminderSettings(user)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// UpdateSurveyReminderSettings Updates the survey reminder settings of the current user
+// @Description Sets the timezone of the current user for the reminders of the scheduled surveys, and opts the user out of all the reminders or of the reminders of some surveys
+// @Tags Client
+// @ID UpdateSurveyReminderSettings
+// @Param data body model.SurveyReminderSettings true "body json"
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.SurveyReminderSettings
+// @Failure 400
+// @Failure 401
+// @Security UserAuth
+// @Router /survey-reminder-settings [put]
+func (h ApisHandler) UpdateSurveyReminderSettings(user *model.User, w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var item model.SurveyReminderSettings
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resData, err := h.app.Services.UpdateSurveyReminderSettings(user, item)
+	if errors.Is(err, model.ErrInvalidSurveyReminderSettings) {
+		log.Printf("Error on apis.UpdateSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, model.ErrInvalidSurveyReminderSettings.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(resData)
+	if err != nil {
+		log.Printf("Error on apis.UpdateSurveyReminderSettings(%s): %s", user.Claims.Subject, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
 // CreateSurveyAlert Creates a survey alert
 // @Description Create a new survey alert to be sent to notifications BB
 // @Tags Client
@@ -1103,3 +1876,93 @@ func (h ApisHandler) GetUserData(user *model.User, w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonData)
 }
+
+// writeValidationError responds with the validation errors of a survey or a survey response
+func writeValidationError(w http.ResponseWriter, validationErr error) {
+	data, err := json.Marshal(validationErr)
+	if err != nil {
+		http.Error(w, validationErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(data)
+}
+
+// surveysFilterFromQuery constructs a surveys filter from the request query params
+func surveysFilterFromQuery(r *http.Request) (*model.SurveysFilter, error) {
+	query := r.URL.Query()
+	limit := int64(20)
+	offset := int64(0)
+	filter := model.SurveysFilter{Limit: &limit, Offset: &offset}
+
+	if typesRaw := query.Get("types"); len(typesRaw) > 0 {
+		filter.Types = strings.Split(typesRaw, ",")
+	}
+	if creatorIDsRaw := query.Get("creator_ids"); len(creatorIDsRaw) > 0 {
+		filter.CreatorIDs = strings.Split(creatorIDsRaw, ",")
+	}
+	if statusesRaw := query.Get("statuses"); len(statusesRaw) > 0 {
+		filter.Statuses = strings.Split(statusesRaw, ",")
+	}
+	if title := query.Get("title"); len(title) > 0 {
+		filter.Title = &title
+	}
+	if startDateRaw := query.Get("start_date"); len(startDateRaw) > 0 {
+		dateParsed, err := time.Parse(time.RFC3339, startDateRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date - %v", err)
+		}
+		filter.StartDate = &dateParsed
+	}
+	if endDateRaw := query.Get("end_date"); len(endDateRaw) > 0 {
+		dateParsed, err := time.Parse(time.RFC3339, endDateRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date - %v", err)
+		}
+		filter.EndDate = &dateParsed
+	}
+	if limitRaw := query.Get("limit"); len(limitRaw) > 0 {
+		intParsed, err := strconv.Atoi(limitRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit - %v", err)
+		}
+		limit = int64(intParsed)
+	}
+	if offsetRaw := query.Get("offset"); len(offsetRaw) > 0 {
+		intParsed, err := strconv.Atoi(offsetRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset - %v", err)
+		}
+		offset = int64(intParsed)
+	}
+
+	return &filter, nil
+}
+
+// surveyResultsFilterFromQuery constructs a survey results filter from the request query params
+func surveyResultsFilterFromQuery(r *http.Request) (*model.SurveyResultsFilter, error) {
+	query := r.URL.Query()
+	filter := model.SurveyResultsFilter{}
+
+	if startDateRaw := query.Get("start_date"); len(startDateRaw) > 0 {
+		dateParsed, err := time.Parse(time.RFC3339, startDateRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date - %v", err)
+		}
+		filter.StartDate = &dateParsed
+	}
+	if endDateRaw := query.Get("end_date"); len(endDateRaw) > 0 {
+		dateParsed, err := time.Parse(time.RFC3339, endDateRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date - %v", err)
+		}
+		filter.EndDate = &dateParsed
+	}
+	if versionIDsRaw := query.Get("version_ids"); len(versionIDsRaw) > 0 {
+		filter.VersionIDs = strings.Split(versionIDsRaw, ",")
+	}
+
+	return &filter, nil
+}
@@ -16,7 +16,9 @@ package rest
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 func getStringQueryParam(r *http.Request, paramName string) *string {
@@ -62,3 +64,50 @@ func getBoolQueryParam(r *http.Request, paramName string, defaultValue bool) boo
 	}
 	return defaultValue
 }
+
+// explicitLanguagesFromRequest gives the languages requested by the lang query param, ignoring the Accept-Language header
+func explicitLanguagesFromRequest(r *http.Request) []string {
+	if lang := r.URL.Query().Get("lang"); len(lang) > 0 {
+		return strings.Split(lang, ",")
+	}
+	return nil
+}
+
+// languagesFromRequest gives the languages requested by the lang query param, or by the Accept-Language header in the order of
+// their quality values
+func languagesFromRequest(r *http.Request) []string {
+	if languages := explicitLanguagesFromRequest(r); languages != nil {
+		return languages
+	}
+
+	type weightedLanguage struct {
+		language string
+		quality  float64
+	}
+	weighted := []weightedLanguage{}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		language, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		language = strings.TrimSpace(language)
+		if len(language) == 0 || language == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			quality = parsed
+		}
+		weighted = append(weighted, weightedLanguage{language: language, quality: quality})
+	}
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].quality > weighted[j].quality
+	})
+
+	languages := make([]string, len(weighted))
+	for i, item := range weighted {
+		languages[i] = item.language
+	}
+	return languages
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"polls/core"
+	"polls/core/model"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// the content types of the export formats
+var surveyResponsesExportContentTypes = map[string]string{
+	model.SurveyResponsesExportFormatCSV:   "text/csv; charset=utf-8",
+	model.SurveyResponsesExportFormatJSONL: "application/x-ndjson; charset=utf-8",
+}
+
+var pollsExportContentTypes = map[string]string{
+	model.PollsExportFormatCSV:  "text/csv; charset=utf-8",
+	model.PollsExportFormatJSON: "application/json; charset=utf-8",
+}
+
+// exportResponseWriter sends the headers of an export with its first data, so the errors which happen before anything is exported
+// can still be sent as errors
+type exportResponseWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (e *exportResponseWriter) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.w.Header().Set("Content-Type", e.contentType)
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.fileName))
+	e.w.WriteHeader(http.StatusOK)
+}
+
+func (e *exportResponseWriter) Write(data []byte) (int, error) {
+	e.start()
+	return e.w.Write(data)
+}
+
+// exportSurveyResponses streams the export of the responses to the survey in the path
+func exportSurveyResponses(app *core.Application, user *model.User, w http.ResponseWriter, r *http.Request, admin bool) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	filter, err := surveyResultsFilterFromQuery(r)
+	if err != nil {
+		err = fmt.Errorf("error on apis.ExportSurveyResponses(%s): %v", id, err)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	export := model.SurveyResponsesExport{Format: model.SurveyResponsesExportFormatCSV, Filter: *filter}
+	if format := r.URL.Query().Get("format"); len(format) > 0 {
+		export.Format = format
+	}
+	contentType, ok := surveyResponsesExportContentTypes[export.Format]
+	if !ok {
+		err = fmt.Errorf("error on apis.ExportSurveyResponses(%s): invalid format %s", id, export.Format)
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if pseudonymizeRaw := r.URL.Query().Get("pseudonymize"); len(pseudonymizeRaw) > 0 {
+		export.Pseudonymize, err = strconv.ParseBool(pseudonymizeRaw)
+		if err != nil {
+			err = fmt.Errorf("error on apis.ExportSurveyResponses(%s): invalid pseudonymize - %v", id, err)
+			log.Println(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writer := &exportResponseWriter{w: w, contentType: contentType, fileName: fmt.Sprintf("survey-%s-responses.%s", id, export.Format)}
+	err = app.Services.ExportSurveyResponses(user, id, export, admin, writer)
+	if err != nil {
+		log.Printf("Error on apis.ExportSurveyResponses(%s): %s", id, err)
+		if writer.started {
+			// the export is sent partially, so the client gets an incomplete file
+			return
+		}
+		if errors.Is(err, model.ErrNotSurveyCreator) {
+			http.Error(w, model.ErrNotSurveyCreator.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, model.ErrSensitiveSurveyExport) {
+			http.Error(w, model.ErrSensitiveSurveyExport.Error(), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, model.ErrSurveyTooFewRespondents) {
+			http.Error(w, model.ErrSurveyTooFewRespondents.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writer.start()
+}
+
+// pollsExportFromQuery constructs the options of a polls export from the request query params
+func pollsExportFromQuery(r *http.Request) (*model.PollsExport, error) {
+	export := model.PollsExport{Format: model.PollsExportFormatCSV}
+	if format := r.URL.Query().Get("format"); len(format) > 0 {
+		export.Format = format
+	}
+	if _, ok := pollsExportContentTypes[export.Format]; !ok {
+		return nil, fmt.Errorf("invalid format %s", export.Format)
+	}
+	if votersRaw := r.URL.Query().Get("voters"); len(votersRaw) > 0 {
+		voters, err := strconv.ParseBool(votersRaw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid voters - %v", err)
+		}
+		export.Voters = voters
+	}
+	return &export, nil
+}
@@ -15,6 +15,9 @@
 package rest
 
 import (
+	"encoding/json"
+	"log"
+	"net/http"
 	"polls/core"
 	"polls/core/model"
 )
@@ -24,3 +27,33 @@ type InternalApisHandler struct {
 	app    *core.Application
 	config *model.Config
 }
+
+// GetHealth Retrieves the health of the service
+// @Description Retrieves the health of the service including the state of the change stream watchers which drive the live updates.
+// @Description The status is starting until the change stream watchers connect, and degraded when any of them failed
+// @Tags Internal
+// @ID GetHealth
+// @Produce json
+// @Success 200 {object} model.Health
+// @Failure 503 {object} model.Health
+// @Security InternalApiAuth
+// @Router /int/health [get]
+func (h InternalApisHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
+	health := h.app.Services.GetHealth()
+
+	data, err := json.Marshal(health)
+	if err != nil {
+		log.Printf("Error on internalapis.GetHealth: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if health.Status == model.HealthStatusDegraded {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 Board of Trustees of the University of Illinois.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"polls/core"
+	"polls/core/model"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// writeImportedSurvey sends the survey created from an import, a clone or a template, or the errors of its definition
+func writeImportedSurvey(w http.ResponseWriter, name string, survey *model.Survey, err error) {
+	var importErr *model.SurveyImportError
+	if errors.As(err, &importErr) {
+		log.Printf("Error on apis.%s: %s", name, err)
+		writeValidationError(w, importErr)
+		return
+	}
+	var lintErr *model.SurveyLintError
+	if errors.As(err, &lintErr) {
+		log.Printf("Error on apis.%s: %s", name, err)
+		writeValidationError(w, lintErr)
+		return
+	}
+	if err != nil {
+		log.Printf("Error on apis.%s: %s", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	jsonData, err := json.Marshal(survey)
+	if err != nil {
+		log.Printf("Error on apis.%s: %s", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonData)
+}
+
+// getSurveyTemplates sends the survey templates of the types in the query
+func getSurveyTemplates(app *core.Application, user *model.User, w http.ResponseWriter, r *http.Request) {
+	var types []string
+	if typesRaw := r.URL.Query().Get("types"); len(typesRaw) > 0 {
+		types = strings.Split(typesRaw, ",")
+	}
+
+	templates, err := app.Services.GetSurveyTemplates(user, types)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyTemplates: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if templates == nil {
+		templates = []model.SurveyTemplate{}
+	}
+
+	data, err := json.Marshal(templates)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyTemplates: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// getSurveyTemplate sends the survey template in the path
+func getSurveyTemplate(app *core.Application, user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	template, err := app.Services.GetSurveyTemplate(user, id)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyTemplate(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(template)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyTemplate(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// getSurveyQuestions sends the questions of the question bank
+func getSurveyQuestions(app *core.Application, user *model.User, w http.ResponseWriter, r *http.Request) {
+	questions, err := app.Services.GetSurveyQuestions(user)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyQuestions: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if questions == nil {
+		questions = []model.SurveyQuestion{}
+	}
+
+	data, err := json.Marshal(questions)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyQuestions: %s", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// getSurveyQuestion sends the question of the question bank in the path
+func getSurveyQuestion(app *core.Application, user *model.User, w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	question, err := app.Services.GetSurveyQuestion(user, id)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyQuestion(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(question)
+	if err != nil {
+		log.Printf("Error on apis.GetSurveyQuestion(%s): %s", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
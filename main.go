@@ -21,6 +21,7 @@ import (
 	"polls/core/model"
 	cacheadapter "polls/driven/cache"
 	corebb "polls/driven/core"
+	"polls/driven/encryption"
 	"polls/driven/groups"
 	"polls/driven/notifications"
 	storage "polls/driven/storage"
@@ -77,6 +78,18 @@ func main() {
 	// Notifications BB Host
 	notificationsBBHost := envLoader.GetAndLogEnvVar(envPrefix+"NOTIFICATIONS_BB_HOST", true, false)
 
+	// the hours after which the survey responses in progress expire
+	surveyProgressExpirationHours := envLoader.GetAndLogEnvVar(envPrefix+"SURVEY_PROGRESS_EXPIRATION_HOURS", false, false)
+
+	// the minimum number of respondents behind the aggregated results of the sensitive surveys
+	sensitiveSurveyMinRespondents := envLoader.GetAndLogEnvVar(envPrefix+"SENSITIVE_SURVEY_MIN_RESPONDENTS", false, false)
+
+	// the file with the keys which encrypt the responses to the sensitive surveys
+	encryptionKeysFile := envLoader.GetAndLogEnvVar(envPrefix+"ENCRYPTION_KEYS_FILE", false, false)
+
+	// the key of the pseudonyms of the users in the survey responses exports
+	exportPseudonymKey := envLoader.GetAndLogEnvVar(envPrefix+"EXPORT_PSEUDONYM_KEY", false, true)
+
 	authService := authservice.AuthService{
 		ServiceID:   serviceID,
 		ServiceHost: serviceURL,
@@ -131,6 +144,11 @@ func main() {
 		UiucOrgID:         uiucOrgID,
 		GroupsHost:        groupsBBHost,
 		NotificationsHost: notificationsBBHost,
+
+		SurveyProgressExpirationHours: surveyProgressExpirationHours,
+		SensitiveSurveyMinRespondents: sensitiveSurveyMinRespondents,
+		EncryptionKeysFile:            encryptionKeysFile,
+		ExportPseudonymKey:            exportPseudonymKey,
 	}
 
 	storageAdapter := storage.NewStorageAdapter(config, logger)
@@ -148,6 +166,11 @@ func main() {
 
 	groupsAdapter := groups.NewGroupsAdapter(config)
 
+	encryptionAdapter, err := encryption.NewEncryptionAdapter(config)
+	if err != nil {
+		log.Fatal("Cannot start the encryption adapter - " + err.Error())
+	}
+
 	defaultCacheExpirationSeconds := envLoader.GetAndLogEnvVar("DEFAULT_CACHE_EXPIRATION_SECONDS", false, false)
 	cacheAdapter := cacheadapter.NewCacheAdapter(defaultCacheExpirationSeconds)
 
@@ -156,7 +179,7 @@ func main() {
 
 	// application
 	application := core.NewApplication(Version, Build, storageAdapter, cacheAdapter, notificationsBBAdapter,
-		groupsAdapter, serviceID, coreAdapter, logger)
+		groupsAdapter, encryptionAdapter, serviceID, coreAdapter, config, logger)
 	application.Start()
 
 	var corsAllowedHeaders []string
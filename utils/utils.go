@@ -16,7 +16,9 @@ package utils
 
 import (
 	"fmt"
+	"hash/fnv"
 	"log"
+	"math/rand"
 	"net/http"
 	"sort"
 	"strconv"
@@ -247,3 +249,13 @@ func IsVersionLess(v1 string, v2 string) bool {
 	// they are equals
 	return false
 }
+
+// ShuffledOrder gives a random order of n items as their indexes. The order is determined by the seeds, so the same seeds give the same order
+func ShuffledOrder(n int, seeds ...string) []int {
+	hash := fnv.New64a()
+	for _, seed := range seeds {
+		hash.Write([]byte(seed))
+		hash.Write([]byte{0})
+	}
+	return rand.New(rand.NewSource(int64(hash.Sum64()))).Perm(n)
+}